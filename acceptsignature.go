@@ -0,0 +1,310 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AcceptSignature signature requested by the Accept-Signature header (RFC 9421 section 5.1)
+type AcceptSignature struct {
+	Label      string
+	Components []SignatureComponent
+	// Params signature params, e.g. keyid, alg, tag. Values are string (strings & tokens), int64, float64, bool
+	// or []byte
+	Params map[string]interface{}
+}
+
+// SignatureComponent covered component name (e.g. "@method", "content-digest") & its params
+type SignatureComponent struct {
+	Name   string
+	Params map[string]interface{}
+}
+
+// KeyID requested keyid param (empty if not set)
+func (a AcceptSignature) KeyID() string {
+	return a.stringParam("keyid")
+}
+
+// Algorithm requested alg param (empty if not set)
+func (a AcceptSignature) Algorithm() string {
+	return a.stringParam("alg")
+}
+
+// Tag requested tag param (empty if not set)
+func (a AcceptSignature) Tag() string {
+	return a.stringParam("tag")
+}
+
+func (a AcceptSignature) stringParam(name string) string {
+	v, _ := a.Params[name].(string)
+	return v
+}
+
+// ParseAcceptSignatureHeader parse Accept-Signature header (structured field dictionary, RFC 8941), e.g.
+// sig1=("@method" "@target-uri" "content-digest");keyid="test-key";created
+// Signatures keep the header order.
+func ParseAcceptSignatureHeader(header string) ([]AcceptSignature, error) {
+	p := sfParser{s: header}
+	res, err := p.parseAcceptSignature()
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// sfParser structured field values parser (RFC 8941), only dictionaries of inner lists are supported
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) parseAcceptSignature() ([]AcceptSignature, *ErrParser) {
+	p.skipSP()
+	if p.eof() {
+		return nil, &ErrParser{Message: "empty accept-signature header"}
+	}
+
+	var res []AcceptSignature
+	for {
+		label, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if p.eof() || p.s[p.i] != equal {
+			return nil, p.errorf("member '%s' is not an inner list", label)
+		}
+		p.i++
+		if p.eof() || p.s[p.i] != '(' {
+			return nil, p.errorf("member '%s' is not an inner list", label)
+		}
+		as := AcceptSignature{Label: label}
+		if as.Components, err = p.parseInnerList(); err != nil {
+			return nil, err
+		}
+		if as.Params, err = p.parseParams(); err != nil {
+			return nil, err
+		}
+
+		// Duplicated member overrides the previous one
+		replaced := false
+		for k := range res {
+			if res[k].Label == label {
+				res[k] = as
+				replaced = true
+			}
+		}
+		if !replaced {
+			res = append(res, as)
+		}
+
+		p.skipOWS()
+		if p.eof() {
+			return res, nil
+		}
+		if p.s[p.i] != div {
+			return nil, p.errorf("found '%s' — unsupported symbol, expected ','", string(p.s[p.i]))
+		}
+		p.i++
+		p.skipOWS()
+		if p.eof() {
+			return nil, p.errorf("unexpected end of header, expected member")
+		}
+	}
+}
+
+func (p *sfParser) parseInnerList() ([]SignatureComponent, *ErrParser) {
+	p.i++ // (
+	var res []SignatureComponent
+	for !p.eof() {
+		p.skipSP()
+		if p.eof() {
+			break
+		}
+		if p.s[p.i] == ')' {
+			p.i++
+			return res, nil
+		}
+		if p.s[p.i] != quote {
+			return nil, p.errorf("found '%s' — unsupported symbol, expected component name", string(p.s[p.i]))
+		}
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		c := SignatureComponent{Name: name}
+		if c.Params, err = p.parseParams(); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+		if !p.eof() && p.s[p.i] != space && p.s[p.i] != ')' {
+			return nil, p.errorf("found '%s' — unsupported symbol, expected space or ')'", string(p.s[p.i]))
+		}
+	}
+	return nil, p.errorf("unexpected end of header, expected ')'")
+}
+
+// parseParams params are nil if not set, params without value are true
+func (p *sfParser) parseParams() (map[string]interface{}, *ErrParser) {
+	var params map[string]interface{}
+	for !p.eof() && p.s[p.i] == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{} = true
+		if !p.eof() && p.s[p.i] == equal {
+			p.i++
+			if v, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		params[key] = v
+	}
+	return params, nil
+}
+
+// parseKey key = ( lcalpha / "*" ) *( lcalpha / DIGIT / "_" / "-" / "." / "*" )
+func (p *sfParser) parseKey() (string, *ErrParser) {
+	start := p.i
+	if p.eof() || !(p.s[p.i] >= froma && p.s[p.i] <= toz || p.s[p.i] == '*') {
+		return "", p.errorf("expected key")
+	}
+	for !p.eof() {
+		c := p.s[p.i]
+		if !(c >= froma && c <= toz || c >= from0 && c <= to9 || c == '_' || c == min || c == dot || c == '*') {
+			break
+		}
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) parseBareItem() (interface{}, *ErrParser) {
+	if p.eof() {
+		return nil, p.errorf("unexpected end of header, expected value")
+	}
+	c := p.s[p.i]
+	switch {
+	case c == min || (c >= from0 && c <= to9):
+		return p.parseNumber()
+	case c == quote:
+		return p.parseString()
+	case c == '*' || (c >= fromA && c <= toZ) || (c >= froma && c <= toz):
+		return p.parseToken(), nil
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		if p.i+1 < len(p.s) && (p.s[p.i+1] == '0' || p.s[p.i+1] == '1') {
+			p.i += 2
+			return p.s[p.i-1] == '1', nil
+		}
+		return nil, p.errorf("wrong boolean value")
+	}
+	return nil, p.errorf("found '%s' — unsupported symbol, expected value", string(c))
+}
+
+func (p *sfParser) parseNumber() (interface{}, *ErrParser) {
+	start := p.i
+	if p.s[p.i] == min {
+		p.i++
+	}
+	decimal := false
+	for !p.eof() && (p.s[p.i] >= from0 && p.s[p.i] <= to9 || p.s[p.i] == dot && !decimal) {
+		if p.s[p.i] == dot {
+			decimal = true
+		}
+		p.i++
+	}
+	num := p.s[start:p.i]
+	if decimal {
+		v, err := strconv.ParseFloat(num, 64)
+		if err != nil || strings.HasSuffix(num, ".") {
+			return nil, p.errorf("wrong decimal value '%s'", num)
+		}
+		return v, nil
+	}
+	v, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || len(strings.TrimPrefix(num, "-")) > 15 {
+		return nil, p.errorf("wrong integer value '%s'", num)
+	}
+	return v, nil
+}
+
+func (p *sfParser) parseString() (string, *ErrParser) {
+	p.i++ // "
+	var b strings.Builder
+	for !p.eof() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == bslash:
+			if p.eof() || (p.s[p.i] != quote && p.s[p.i] != bslash) {
+				return "", p.errorf("wrong escape sequence in string")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == quote:
+			return b.String(), nil
+		case c < space || c > '~':
+			return "", p.errorf("found not printable symbol in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unexpected end of header, expected '\"' symbol")
+}
+
+// parseToken token = ( ALPHA / "*" ) *( tchar / ":" / "/" )
+func (p *sfParser) parseToken() string {
+	start := p.i
+	for !p.eof() && (isTChar(p.s[p.i]) || p.s[p.i] == ':' || p.s[p.i] == '/') {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+func (p *sfParser) parseByteSequence() ([]byte, *ErrParser) {
+	p.i++ // :
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, p.errorf("unexpected end of header, expected ':' symbol")
+	}
+	v, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	if err != nil {
+		return nil, &ErrParser{Message: "wrong byte sequence value", Err: err, Pos: p.i + 1}
+	}
+	p.i += end + 1
+	return v, nil
+}
+
+func (p *sfParser) skipSP() {
+	for !p.eof() && p.s[p.i] == space {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for !p.eof() && (p.s[p.i] == space || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+// errorf error at the current position (counting from 1), the last symbol for the end of header
+func (p *sfParser) errorf(format string, a ...interface{}) *ErrParser {
+	pos := p.i + 1
+	if p.eof() {
+		pos = len(p.s)
+	}
+	return &ErrParser{Message: fmt.Sprintf(format, a...), Pos: pos}
+}
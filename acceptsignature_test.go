@@ -0,0 +1,185 @@
+package httpsignatures
+
+import (
+	"testing"
+)
+
+func TestParseAcceptSignatureHeader(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []AcceptSignature
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "RFC 9421 example",
+			args: args{
+				header: `sig1=("@method" "@target-uri" "@authority" "content-digest" "cache-control");` +
+					`keyid="test-key-rsa-pss";created;tag="app-123"`,
+			},
+			want: []AcceptSignature{
+				{
+					Label: "sig1",
+					Components: []SignatureComponent{
+						{Name: "@method"},
+						{Name: "@target-uri"},
+						{Name: "@authority"},
+						{Name: "content-digest"},
+						{Name: "cache-control"},
+					},
+					Params: map[string]interface{}{"keyid": "test-key-rsa-pss", "created": true, "tag": "app-123"},
+				},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Many signatures & param types",
+			args: args{
+				header: `sig1=("@query-param";name="id" "date");alg=rsa-pss-sha512;expires=300, ` +
+					`sig2=();nonce=?0;ratio=0.5;key=:dGVzdA==:`,
+			},
+			want: []AcceptSignature{
+				{
+					Label: "sig1",
+					Components: []SignatureComponent{
+						{Name: "@query-param", Params: map[string]interface{}{"name": "id"}},
+						{Name: "date"},
+					},
+					Params: map[string]interface{}{"alg": "rsa-pss-sha512", "expires": int64(300)},
+				},
+				{
+					Label:  "sig2",
+					Params: map[string]interface{}{"nonce": false, "ratio": 0.5, "key": []byte("test")},
+				},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Duplicated member overrides",
+			args: args{
+				header: `sig1=("date"),sig2=("host"),sig1=("digest")`,
+			},
+			want: []AcceptSignature{
+				{Label: "sig1", Components: []SignatureComponent{{Name: "digest"}}},
+				{Label: "sig2", Components: []SignatureComponent{{Name: "host"}}},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Escaped string",
+			args: args{
+				header: `sig1=("a\"b\\c")`,
+			},
+			want:        []AcceptSignature{{Label: "sig1", Components: []SignatureComponent{{Name: `a"b\c`}}}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Empty header",
+			args: args{
+				header: ` `,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty accept-signature header",
+		},
+		{
+			name: "Not an inner list",
+			args: args{
+				header: `sig1="date"`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: member 'sig1' is not an inner list at position 6",
+		},
+		{
+			name: "Uppercase key",
+			args: args{
+				header: `Sig1=("date")`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: expected key at position 1",
+		},
+		{
+			name: "Trailing comma",
+			args: args{
+				header: `sig1=("date"), `,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected member at position 15",
+		},
+		{
+			name: "Not closed inner list",
+			args: args{
+				header: `sig1=("date"`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected ')' at position 12",
+		},
+		{
+			name: "Not a string component",
+			args: args{
+				header: `sig1=(date)`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found 'd' — unsupported symbol, expected component name at position 7",
+		},
+		{
+			name: "Wrong boolean",
+			args: args{
+				header: `sig1=();created=?2`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong boolean value at position 17",
+		},
+		{
+			name: "Wrong decimal",
+			args: args{
+				header: `sig1=();q=1.`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong decimal value '1.' at position 12",
+		},
+		{
+			name: "Wrong byte sequence",
+			args: args{
+				header: `sig1=();k=:dGV@:`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong byte sequence value at position 12: " +
+				"illegal base64 data at input byte 3",
+		},
+		{
+			name: "Not expected symbol after member",
+			args: args{
+				header: `sig1=() x`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found 'x' — unsupported symbol, expected ',' at position 9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAcceptSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestAcceptSignatureParams(t *testing.T) {
+	a := AcceptSignature{Params: map[string]interface{}{"keyid": "k1", "alg": "ed25519", "tag": true}}
+	if a.KeyID() != "k1" || a.Algorithm() != "ed25519" || a.Tag() != "" {
+		t.Errorf("got keyid = %s, alg = %s, tag = %s", a.KeyID(), a.Algorithm(), a.Tag())
+	}
+}
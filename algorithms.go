@@ -0,0 +1,91 @@
+package httpsignatures
+
+import (
+	"sort"
+	"strings"
+)
+
+// AlgorithmInfo registered signature or digest algorithm, e.g. for /capabilities endpoint or negotiation
+type AlgorithmInfo struct {
+	Name    string
+	Aliases []string
+	// Create signatures (digests) could be created
+	Create bool
+	// Verify signatures (digests) could be verified
+	Verify bool
+	// SecurityBits approximate security strength in bits (with recommended key sizes), 0 if unknown, e.g. custom
+	// algorithm which doesn't implement AlgorithmSecurity. Broken algorithms (MD5) are 0 too.
+	SecurityBits int
+}
+
+// AlgorithmCapabilities optional interface of custom algorithms which can't both create & verify
+type AlgorithmCapabilities interface {
+	CanCreate() bool
+	CanVerify() bool
+}
+
+// AlgorithmSecurity optional interface of custom algorithms to report security strength in bits
+type AlgorithmSecurity interface {
+	SecurityBits() int
+}
+
+// algorithmSecurityBits security strength of built-in algorithms. RSA keys are assumed to be 2048 bits for SHA-256
+// & 3072 bits for SHA-512 variants, ECDSA curves P-256 & P-521.
+var algorithmSecurityBits = map[string]int{
+	algED25519:         128,
+	algEcdsaSha256:     128,
+	algEcdsaSha512:     256,
+	algRsaSsaPssSha256: 112,
+	algRsaSsaPssSha512: 128,
+	algRsaSha256:       112,
+	algRsaSha512:       128,
+	algHmacSha256:      128,
+	algHmacSha512:      256,
+	algSha256:          128,
+	algSha512:          256,
+}
+
+// Algorithms registered signature algorithms sorted by name. Aliases of algorithms derived from the key (e.g.
+// "hs2019") are not listed.
+func (hs *HTTPSignatures) Algorithms() []AlgorithmInfo {
+	res := make([]AlgorithmInfo, 0, len(hs.alg))
+	for name, a := range hs.alg {
+		res = append(res, algorithmInfo(name, a, hs.algAliases))
+	}
+	sortAlgorithms(res)
+	return res
+}
+
+// DigestAlgorithms registered digest algorithms sorted by name
+func (hs *HTTPSignatures) DigestAlgorithms() []AlgorithmInfo {
+	res := make([]AlgorithmInfo, 0, len(hs.d.alg))
+	for name, a := range hs.d.alg {
+		res = append(res, algorithmInfo(name, a, hs.d.aliases))
+	}
+	sortAlgorithms(res)
+	return res
+}
+
+// algorithmInfo describe algorithm registered by name, aliases map alias to algorithm name
+func algorithmInfo(name string, a interface{}, aliases map[string]string) AlgorithmInfo {
+	info := AlgorithmInfo{Name: name, Create: true, Verify: true, SecurityBits: algorithmSecurityBits[name]}
+	if c, ok := a.(AlgorithmCapabilities); ok {
+		info.Create, info.Verify = c.CanCreate(), c.CanVerify()
+	}
+	if s, ok := a.(AlgorithmSecurity); ok {
+		info.SecurityBits = s.SecurityBits()
+	}
+	for alias, alg := range aliases {
+		if strings.EqualFold(alg, name) {
+			info.Aliases = append(info.Aliases, alias)
+		}
+	}
+	sort.Strings(info.Aliases)
+	return info
+}
+
+func sortAlgorithms(list []AlgorithmInfo) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+}
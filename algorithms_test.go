@@ -0,0 +1,68 @@
+package httpsignatures
+
+import (
+	"reflect"
+	"testing"
+)
+
+const algRsaSha1Test = "RSA-SHA1"
+
+// testVerifyOnlyAlgorithm legacy algorithm accepted from old clients only
+type testVerifyOnlyAlgorithm struct {
+	RsaSha256
+}
+
+func (a testVerifyOnlyAlgorithm) Algorithm() string {
+	return algRsaSha1Test
+}
+
+func (a testVerifyOnlyAlgorithm) CanCreate() bool {
+	return false
+}
+
+func (a testVerifyOnlyAlgorithm) CanVerify() bool {
+	return true
+}
+
+func TestAlgorithms(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetSignatureHashAlgorithm(testVerifyOnlyAlgorithm{})
+	if err := hs.SetSignatureAlgorithmAlias("rsa-sha2-256", algRsaSha256); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]AlgorithmInfo{}
+	var names []string
+	for _, a := range hs.Algorithms() {
+		got[a.Name] = a
+		names = append(names, a.Name)
+	}
+	wantNames := []string{
+		algEcdsaSha256, algEcdsaSha512, algED25519, algHmacSha256, algHmacSha512, algRsaSha1Test,
+		algRsaSha256, algRsaSha512, algRsaSsaPssSha256, algRsaSsaPssSha512,
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("Algorithms() names = %v, want %v", names, wantNames)
+	}
+	tests := []AlgorithmInfo{
+		{Name: algED25519, Create: true, Verify: true, SecurityBits: 128},
+		{Name: algRsaSha256, Aliases: []string{"RSA-SHA2-256"}, Create: true, Verify: true, SecurityBits: 112},
+		{Name: algRsaSha1Test, Verify: true},
+	}
+	for _, want := range tests {
+		if !reflect.DeepEqual(got[want.Name], want) {
+			t.Errorf("Algorithms() %s = %+v, want %+v", want.Name, got[want.Name], want)
+		}
+	}
+}
+
+func TestDigestAlgorithms(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	want := []AlgorithmInfo{
+		{Name: algMd5, Create: true, Verify: true},
+		{Name: algSha256, Aliases: []string{"SHA256"}, Create: true, Verify: true, SecurityBits: 128},
+		{Name: algSha512, Aliases: []string{"SHA512"}, Create: true, Verify: true, SecurityBits: 256},
+	}
+	if got := hs.DigestAlgorithms(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DigestAlgorithms() = %+v, want %+v", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"context"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
@@ -146,11 +147,23 @@ func (s *SecretsManagerStorage) SetAlgorithm(a string) {
 
 // Get get secret from cache by KeyID or from AWS Secrets Manager for first time
 func (s SecretsManagerStorage) Get(keyID string) (httpsignatures.Secret, error) {
+	return s.get(keyID, s.getSMSecret)
+}
+
+// GetContext get secret like Get, requests to AWS Secrets Manager are canceled when ctx is done
+func (s SecretsManagerStorage) GetContext(ctx context.Context, keyID string) (httpsignatures.Secret, error) {
+	return s.get(keyID, func(smKeyID string) ([]byte, error) {
+		return s.getSMSecretContext(ctx, smKeyID)
+	})
+}
+
+func (s SecretsManagerStorage) get(keyID string, getSMSecret func(smKeyID string) ([]byte, error)) (
+	httpsignatures.Secret, error) {
 	secret, err := s.storage.Value(keyID)
 	if err == nil {
 		return secret.Data().(httpsignatures.Secret), nil
 	}
-	secretVal, err := s.getSecret(keyID)
+	secretVal, err := s.getSecretWith(keyID, getSMSecret)
 	if err != nil {
 		return httpsignatures.Secret{}, &httpsignatures.ErrSecret{Message: "secret not found", Err: err}
 	}
@@ -164,6 +177,11 @@ func (s SecretsManagerStorage) Get(keyID string) (httpsignatures.Secret, error)
 // 2) Service used to sign outgoing requests (signed by itself)
 // 3) Service used to sign outgoing requests on behalf of other services
 func (s SecretsManagerStorage) getSecret(keyID string) (*httpsignatures.Secret, error) {
+	return s.getSecretWith(keyID, s.getSMSecret)
+}
+
+func (s SecretsManagerStorage) getSecretWith(keyID string, getSMSecret func(smKeyID string) ([]byte, error)) (
+	*httpsignatures.Secret, error) {
 	secret := &httpsignatures.Secret{}
 
 	keys := []string{publicKey, algorithm, privateKey}
@@ -187,7 +205,7 @@ func (s SecretsManagerStorage) getSecret(keyID string) (*httpsignatures.Secret,
 		secret.KeyID = keyID
 		output, ok := outputs[smKeyID]
 		if !ok {
-			output, err = s.getSMSecret(smKeyID)
+			output, err = getSMSecret(smKeyID)
 			if err != nil {
 				return nil, &httpsignatures.ErrSecret{
 					Message: fmt.Sprintf("error get secret value '%s'", secret.KeyID),
@@ -221,3 +239,17 @@ func (s SecretsManagerStorage) getSMSecret(smKeyID string) ([]byte, error) {
 	}
 	return smOutput.SecretBinary, nil
 }
+
+func (s SecretsManagerStorage) getSMSecretContext(ctx context.Context, smKeyID string) ([]byte, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(smKeyID),
+	}
+	smOutput, err := s.sm.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		return nil, &httpsignatures.ErrSecret{
+			Message: fmt.Sprintf("error get secret value '%s'", smKeyID),
+			Err:     err,
+		}
+	}
+	return smOutput.SecretBinary, nil
+}
@@ -1,7 +1,9 @@
 package aws
 
 import (
+	"context"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/go-test/deep"
@@ -289,6 +291,14 @@ func (m *mockSecretsManagerClient) GetSecretValue(input *secretsmanager.GetSecre
 	return nil, fmt.Errorf("error")
 }
 
+func (m *mockSecretsManagerClient) GetSecretValueWithContext(ctx context.Context,
+	input *secretsmanager.GetSecretValueInput, _ ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetSecretValue(input)
+}
+
 func TestAwsSecretsManagerStorageGetSecret(t *testing.T) {
 	type args struct {
 		keyID               string
@@ -443,6 +453,62 @@ func TestAwsSecretsManagerStorageGetSMSecret(t *testing.T) {
 	}
 }
 
+func TestAwsSecretsManagerStorageGetSMSecretContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	type args struct {
+		ctx     context.Context
+		smKeyID string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []byte
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Ok",
+			args: args{
+				ctx:     context.Background(),
+				smKeyID: "/prod/k1/PublicKey",
+			},
+			want: []byte("PublicKey"),
+		},
+		{
+			name: "Canceled",
+			args: args{
+				ctx:     canceled,
+				smKeyID: "/prod/k1/PublicKey",
+			},
+			wantErrType: testSecretErrType,
+			wantErrMsg:  "ErrSecret: error get secret value '/prod/k1/PublicKey': context canceled",
+		},
+	}
+
+	mockSvc := &mockSecretsManagerClient{}
+	sm := NewAwsSecretsManagerStorage("prod", mockSvc)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.getSMSecretContext(tt.args.ctx, tt.args.smKeyID)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestAwsSecretsManagerStorageGetContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mockSvc := &mockSecretsManagerClient{}
+	sm := NewAwsSecretsManagerStorage("prod", mockSvc)
+	got, err := sm.GetContext(canceled, "k3")
+	wantErrMsg := "ErrSecret: secret not found: ErrSecret: error get secret value 'k3': ErrSecret: error get " +
+		"secret value '/prod/k3/PublicKey': context canceled"
+	assert(t, got, err, testSecretErrType, "Canceled", httpsignatures.Secret{}, wantErrMsg)
+}
+
 func TestAwsSecretsManagerStorageGet(t *testing.T) {
 	type args struct {
 		keyID string
@@ -0,0 +1,13 @@
+module github.com/igor-pavlenko/httpsignatures-go/aws
+
+go 1.15
+
+require (
+	github.com/aws/aws-sdk-go v1.35.1
+	github.com/go-test/deep v1.0.7
+	github.com/igor-pavlenko/httpsignatures-go v0.0.14
+	github.com/muesli/cache2go v0.0.0-20200423001931-a100c5aac93f
+)
+
+// Use the core module from this repository
+replace github.com/igor-pavlenko/httpsignatures-go => ../
@@ -0,0 +1,53 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// batchSecrets secrets storage wrapper which fetches every keyId once per batch
+type batchSecrets struct {
+	hs      *HTTPSignatures
+	secrets map[string]batchSecret
+}
+
+type batchSecret struct {
+	secret Secret
+	err    error
+}
+
+// Get get secret from the batch or from the storage
+func (s *batchSecrets) Get(keyID string) (Secret, error) {
+	return s.GetContext(context.Background(), keyID)
+}
+
+// GetContext get secret from the batch or from the storage with context
+func (s *batchSecrets) GetContext(ctx context.Context, keyID string) (Secret, error) {
+	if bs, ok := s.secrets[keyID]; ok {
+		return bs.secret, bs.err
+	}
+	secret, err := s.hs.getSecret(ctx, keyID)
+	if ctx.Err() == nil && !errors.Is(err, ErrKeyResolutionTimeout) {
+		s.secrets[keyID] = batchSecret{secret: secret, err: err}
+	}
+	return secret, err
+}
+
+// VerifyAll verify signatures of requests batch (e.g. recorded requests). Every keyId is fetched from secrets
+// storage once per batch. Returns error for every request, nil if signature is valid.
+func (hs *HTTPSignatures) VerifyAll(reqs []*http.Request) []error {
+	return hs.VerifyAllCtx(context.Background(), reqs)
+}
+
+// VerifyAllCtx verify signatures of requests batch with context
+func (hs *HTTPSignatures) VerifyAllCtx(ctx context.Context, reqs []*http.Request) []error {
+	batch := *hs
+	batch.ss = &batchSecrets{hs: hs, secrets: make(map[string]batchSecret)}
+	batch.fetches = newSecretFetches()
+	errs := make([]error, len(reqs))
+	for i, r := range reqs {
+		_, errs[i] = batch.VerifyAndIdentifyCtx(ctx, r)
+	}
+	return errs
+}
@@ -0,0 +1,59 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type testCountingSecrets struct {
+	ss    Secrets
+	calls map[string]int
+}
+
+func (s *testCountingSecrets) Get(keyID string) (Secret, error) {
+	s.calls[keyID]++
+	return s.ss.Get(keyID)
+}
+
+func TestVerifyAll(t *testing.T) {
+	ss := &testCountingSecrets{ss: testBenchSecrets, calls: map[string]int{}}
+	signer := testBenchHS()
+	hs := NewHTTPSignatures(ss)
+
+	var keys = []string{"hmac", "rsa", "hmac", "ecdsa", "rsa", "hmac"}
+	reqs := make([]*http.Request, 0, len(keys)+2)
+	for _, keyID := range keys {
+		r := testBenchRequest()
+		if err := signer.Sign(keyID, r); err != nil {
+			t.Fatal(err)
+		}
+		reqs = append(reqs, r)
+	}
+	unknown := testBenchRequest()
+	unknown.Header.Set(signatureHeader, `keyId="unknown",algorithm="hmac-sha256",signature="e30="`)
+	reqs = append(reqs, unknown, unknown)
+
+	errs := hs.VerifyAll(reqs)
+	if len(errs) != len(reqs) {
+		t.Fatalf("got %d errors, want %d", len(errs), len(reqs))
+	}
+	for i := range keys {
+		if errs[i] != nil {
+			t.Errorf("request %d: unexpected error: %s", i, errs[i])
+		}
+	}
+	for _, err := range errs[len(keys):] {
+		if !errors.Is(err, ErrUnknownKeyID) {
+			t.Errorf("got error %v, want ErrUnknownKeyID", err)
+		}
+	}
+	for keyID, n := range ss.calls {
+		if n != 1 {
+			t.Errorf("keyId '%s' fetched %d times, want once", keyID, n)
+		}
+	}
+	if hs.ss != ss {
+		t.Errorf("secrets storage is replaced")
+	}
+}
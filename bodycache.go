@@ -0,0 +1,37 @@
+package httpsignatures
+
+import (
+	"context"
+	"net/http"
+)
+
+type bodyContextKey struct{}
+
+// SetBufferRequestBody read the whole request body in VerifyRequests middleware before verification: digest is
+// verified before the next handler is called, body bytes are stored in the request context (RequestBodyFromContext)
+// & request body is replaced with in-memory copy of known length, so handlers needing the raw payload (e.g. webhook
+// processors) don't read it again. Body size is limited by SetMaxBodySize.
+func (hs *HTTPSignatures) SetBufferRequestBody(v bool) {
+	hs.bufferRequestBody = v
+}
+
+// RequestBodyFromContext return request body read by VerifyRequests middleware (SetBufferRequestBody)
+func RequestBodyFromContext(ctx context.Context) ([]byte, bool) {
+	b, ok := ctx.Value(bodyContextKey{}).([]byte)
+	return b, ok
+}
+
+// bufferRequest replace request body with in-memory copy & store body bytes in the request context
+func (hs *HTTPSignatures) bufferRequest(r *http.Request) (*http.Request, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return r, nil
+	}
+	body, rc, err := hs.d.bufferBody(r.Body)
+	r.Body = rc
+	if err != nil {
+		return r, err
+	}
+	hs.d.resetBody(r, body)
+	r.ContentLength = int64(len(body))
+	return r.WithContext(context.WithValue(r.Context(), bodyContextKey{}, body)), nil
+}
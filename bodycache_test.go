@@ -0,0 +1,79 @@
+package httpsignatures
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBufferRequestBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Valid digest", body: testBodyExample, wantStatus: http.StatusOK, wantBody: testBodyExample},
+		{name: "Wrong digest", body: `{"hello": "world!"}`, wantStatus: http.StatusUnauthorized,
+			wantBody: "Unauthorized\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+			hs.SetBufferRequestBody(true)
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				cached, ok := RequestBodyFromContext(r.Context())
+				if !ok {
+					t.Error("no body in context")
+				}
+				if r.ContentLength != int64(len(cached)) {
+					t.Errorf("ContentLength = %d, want %d", r.ContentLength, len(cached))
+				}
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil || string(b) != string(cached) {
+					t.Errorf("body = %s, %v, want %s", b, err, cached)
+				}
+				_, _ = w.Write(cached)
+			}))
+
+			r := testGetRequest()
+			if err := hs.Sign("Test", r); err != nil {
+				t.Fatalf("Sign error = %v", err)
+			}
+			sr := httptest.NewRequest(r.Method, r.URL.String(), strings.NewReader(tt.body))
+			sr.Header = r.Header
+			sr.ContentLength = -1
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, sr)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("got body = %s, want %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestBufferRequestBodyTooLarge(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetBufferRequestBody(true)
+	hs.SetMaxBodySize(4)
+	hs.SetReportOnly(true)
+	h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := RequestBodyFromContext(r.Context()); ok {
+			t.Error("body in context")
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testBodyExample)))
+	if rec.Body.String() != testBodyExample {
+		t.Errorf("got body = %s, want %s", rec.Body.String(), testBodyExample)
+	}
+}
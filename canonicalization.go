@@ -0,0 +1,157 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// HeaderCanonicalization rules to canonicalize signed header values while building signature string
+type HeaderCanonicalization struct {
+	TrimSpace          bool // Trim leading & trailing whitespace
+	CollapseWhitespace bool // Replace sequences of spaces & tabs inside the value with a single space
+	JoinValues         bool // Join multiple values of the header with ", " (otherwise only the first value is used)
+	UnfoldObsFold      bool // Replace obs-fold (line break followed by spaces or tabs) with a single space
+	NonASCII           NonASCIIPolicy
+}
+
+// NonASCIIPolicy handling of non-ASCII bytes (UTF-8, latin-1 etc.) in signed header values, implementations differ
+type NonASCIIPolicy int
+
+const (
+	// NonASCIIPassThrough sign bytes as is (default)
+	NonASCIIPassThrough NonASCIIPolicy = iota
+	// NonASCIIPercentEncode replace non-ASCII bytes with %XX
+	NonASCIIPercentEncode
+	// NonASCIIReject fail to sign & verify headers with non-ASCII bytes
+	NonASCIIReject
+)
+
+// defaultHeaderCanonicalization trims header values & joins multiple values in order with ", " (spec)
+var defaultHeaderCanonicalization = HeaderCanonicalization{
+	TrimSpace:  true,
+	JoinValues: true,
+}
+
+// canonicalize build header value for signature string
+func (c HeaderCanonicalization) canonicalize(values []string) string {
+	var b bytes.Buffer
+	c.writeTo(&b, values)
+	return b.String()
+}
+
+// writeTo write canonicalized header value to the buffer. It doesn't allocate unless the value has obs-fold or
+// whitespace sequences to replace.
+func (c HeaderCanonicalization) writeTo(b *bytes.Buffer, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	if !c.JoinValues {
+		values = values[:1]
+	}
+	for i, v := range values {
+		if c.UnfoldObsFold {
+			v = unfoldObsFold(v)
+		}
+		if c.CollapseWhitespace {
+			v = collapseWhitespace(v)
+		}
+		if c.TrimSpace {
+			v = strings.TrimSpace(v)
+		}
+		if c.NonASCII == NonASCIIPercentEncode {
+			v = percentEncodeNonASCII(v)
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(v)
+	}
+}
+
+// checkValues check header values are allowed by non-ASCII policy
+func (c HeaderCanonicalization) checkValues(name string, values []string) error {
+	if c.NonASCII != NonASCIIReject {
+		return nil
+	}
+	for _, v := range values {
+		if !isASCII(v) {
+			return &ErrHS{Message: fmt.Sprintf("header '%s' has non-ASCII value", name)}
+		}
+	}
+	return nil
+}
+
+// isASCII check string has only ASCII bytes
+func isASCII(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// percentEncodeNonASCII replace non-ASCII bytes with %XX (upper case hex)
+func percentEncodeNonASCII(v string) string {
+	if isASCII(v) {
+		return v
+	}
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(v) * 3)
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c >= 0x80 {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+			continue
+		}
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
+
+// unfoldObsFold replace CRLF (or LF) followed by spaces or tabs with a single space
+func unfoldObsFold(v string) string {
+	if !strings.ContainsAny(v, "\r\n") {
+		return v
+	}
+	var b strings.Builder
+	b.Grow(len(v))
+	for i := 0; i < len(v); i++ {
+		j := i
+		if v[j] == '\r' && j+1 < len(v) && v[j+1] == '\n' {
+			j++
+		}
+		if v[j] == '\n' && j+1 < len(v) && (v[j+1] == ' ' || v[j+1] == '\t') {
+			for j+1 < len(v) && (v[j+1] == ' ' || v[j+1] == '\t') {
+				j++
+			}
+			b.WriteByte(' ')
+			i = j
+			continue
+		}
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
+
+// collapseWhitespace replace sequences of spaces & tabs with a single space
+func collapseWhitespace(v string) string {
+	var b strings.Builder
+	b.Grow(len(v))
+	ws := false
+	for i := 0; i < len(v); i++ {
+		if v[i] == ' ' || v[i] == '\t' {
+			if !ws {
+				b.WriteByte(' ')
+			}
+			ws = true
+			continue
+		}
+		ws = false
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
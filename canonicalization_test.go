@@ -0,0 +1,144 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHeaderCanonicalization(t *testing.T) {
+	type args struct {
+		c      HeaderCanonicalization
+		values []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "Default: values trimmed & joined",
+			args: args{
+				c:      defaultHeaderCanonicalization,
+				values: []string{"  a  b ", "c"},
+			},
+			want: "a  b, c",
+		},
+		{
+			name: "First value trimmed",
+			args: args{
+				c:      HeaderCanonicalization{TrimSpace: true},
+				values: []string{"  a  b ", "c"},
+			},
+			want: "a  b",
+		},
+		{
+			name: "No rules",
+			args: args{
+				c:      HeaderCanonicalization{},
+				values: []string{"  a  b "},
+			},
+			want: "  a  b ",
+		},
+		{
+			name: "Join values",
+			args: args{
+				c:      HeaderCanonicalization{TrimSpace: true, JoinValues: true},
+				values: []string{" a ", "b", " c"},
+			},
+			want: "a, b, c",
+		},
+		{
+			name: "Collapse whitespace",
+			args: args{
+				c:      HeaderCanonicalization{TrimSpace: true, CollapseWhitespace: true},
+				values: []string{" a \t  b  c "},
+			},
+			want: "a b c",
+		},
+		{
+			name: "Unfold obs-fold",
+			args: args{
+				c:      HeaderCanonicalization{TrimSpace: true, UnfoldObsFold: true},
+				values: []string{"a\r\n   b\n\tc\nd"},
+			},
+			want: "a b c\nd",
+		},
+		{
+			name: "Percent-encode non-ASCII",
+			args: args{
+				c:      HeaderCanonicalization{TrimSpace: true, NonASCII: NonASCIIPercentEncode},
+				values: []string{" caf\u00e9 %41 \xe9"},
+			},
+			want: "caf%C3%A9 %41 %E9",
+		},
+		{
+			name: "Empty values",
+			args: args{
+				c:      defaultHeaderCanonicalization,
+				values: []string{},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.args.c.canonicalize(tt.args.values)
+			if got != tt.want {
+				t.Errorf(tt.name+"\ngot  = %q,\nwant = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSSetHeaderCanonicalization(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetHeaderCanonicalization(HeaderCanonicalization{TrimSpace: true, JoinValues: true})
+	r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+	r.Header.Add("Cache-Control", "max-age=60")
+	r.Header.Add("Cache-Control", " must-revalidate")
+	got, err := hs.buildSignatureString(Headers{Headers: []string{"cache-control"}}, r)
+	want := []byte("cache-control: max-age=60, must-revalidate")
+	assert(t, got, err, testHSErrType, "Join values", want, "")
+}
+
+func TestHeaderNonASCIIReject(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetHeaderCanonicalization(HeaderCanonicalization{TrimSpace: true, NonASCII: NonASCIIReject})
+	r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+	r.Header.Set("X-Name", "caf\u00e9")
+	r.Header.Set("X-Id", "cafe")
+	_, err := hs.buildSignatureString(Headers{Headers: []string{"x-id", "x-name"}}, r)
+	if err == nil {
+		t.Fatal("no error, want non-ASCII value error")
+	}
+	assert(t, nil, err, testHSErrType, "Non-ASCII value", nil, "header 'x-name' has non-ASCII value")
+	got, err := hs.buildSignatureString(Headers{Headers: []string{"x-id"}}, r)
+	assert(t, got, err, testHSErrType, "ASCII value", []byte("x-id: cafe"), "")
+}
+
+func TestSignVerifyMultiValueHeader(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "cache-control"})
+	r, _ := http.NewRequest(http.MethodGet, testHostExamplePath, nil)
+	r.Header.Add("Cache-Control", "max-age=60")
+	r.Header.Add("Cache-Control", "must-revalidate")
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// Values are signed in order
+	r.Header["Cache-Control"] = []string{"must-revalidate", "max-age=60"}
+	if err := hs.Verify(r); !errors.Is(err, ErrWrongSignature) {
+		t.Errorf("got error %v, want ErrWrongSignature", err)
+	}
+	// Joined values are the same as single value of the compliant peer
+	r.Header["Cache-Control"] = []string{"max-age=60, must-revalidate"}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
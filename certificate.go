@@ -0,0 +1,98 @@
+package httpsignatures
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+const certificateBlock = "CERTIFICATE"
+
+// SetCertificateRoots set root pool to validate X.509 certificates of secrets: if secret public key is PEM encoded
+// certificate chain (leaf first, then intermediates), the chain is validated (signatures, validity period at
+// the verification time, key usages) & leaf public key is used for verification. Nil roots — system roots.
+// Certificates must allow digital signatures (if key usage is set) & one of extended key usages (any by default).
+// Revocation is checked by SetRevocationChecker.
+func (hs *HTTPSignatures) SetCertificateRoots(roots *x509.CertPool, usages ...x509.ExtKeyUsage) {
+	hs.certRoots = roots
+	hs.certUsages = usages
+}
+
+// CertificateChainPEM convert x5c chain (base64 DER certificates, e.g. from JWK) to PEM encoded chain for
+// Secret.PublicKey
+func CertificateChainPEM(x5c []string) (string, error) {
+	var res []byte
+	for _, c := range x5c {
+		der, err := base64.StdEncoding.DecodeString(c)
+		if err != nil {
+			return "", &ErrCrypto{Message: "error decode certificate from base64", Err: err}
+		}
+		if _, err := x509.ParseCertificate(der); err != nil {
+			return "", &ErrCrypto{Message: "error parsing certificate", Err: err}
+		}
+		res = append(res, pem.EncodeToMemory(&pem.Block{Type: certificateBlock, Bytes: der})...)
+	}
+	return string(res), nil
+}
+
+// certificateKey validate certificate chain of the secret & replace it with the leaf public key.
+// Secrets with public keys are returned as is.
+func (hs *HTTPSignatures) certificateKey(ctx context.Context, secret Secret) (Secret, error) {
+	var certs []*x509.Certificate
+	for rest := []byte(secret.PublicKey); ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil || block.Type != certificateBlock {
+			break
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Secret{}, hs.certificateError(secret.KeyID, &ErrCrypto{Message: "error parsing certificate", Err: err})
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return secret, nil
+	}
+
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	usages := hs.certUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         hs.certRoots,
+		Intermediates: intermediates,
+		CurrentTime:   hs.now(),
+		KeyUsages:     usages,
+	})
+	if err != nil {
+		return Secret{}, hs.certificateError(secret.KeyID, err)
+	}
+	if leaf.KeyUsage != 0 && leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return Secret{}, hs.certificateError(secret.KeyID,
+			&ErrCrypto{Message: "certificate key usage doesn't allow digital signature"})
+	}
+	if err := hs.checkRevocation(ctx, chains[0]); err != nil {
+		return Secret{}, hs.certificateError(secret.KeyID, err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(leaf.PublicKey)
+	if err != nil {
+		return Secret{}, hs.certificateError(secret.KeyID, err)
+	}
+	secret.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return secret, nil
+}
+
+func (hs *HTTPSignatures) certificateError(keyID string, err error) *ErrHS {
+	return &ErrHS{
+		Message: fmt.Sprintf("certificate of keyId '%s' is not valid", keyID),
+		Err:     err,
+		kind:    ErrInvalidCertificate,
+	}
+}
@@ -0,0 +1,125 @@
+package httpsignatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate create certificate signed by parent (self-signed if parent is nil)
+func testCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, ca bool,
+	usage x509.KeyUsage, crl ...string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              usage,
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+		CRLDistributionPoints: crl,
+	}
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestCertificateKey(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", nil, nil, true, x509.KeyUsageCertSign)
+	inter, interKey := testCertificate(t, "intermediate", ca, caKey, true, x509.KeyUsageCertSign)
+	leaf, leafKey := testCertificate(t, "leaf", inter, interKey, false, x509.KeyUsageDigitalSignature)
+	encLeaf, encKey := testCertificate(t, "enc", inter, interKey, false, x509.KeyUsageKeyEncipherment)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	later := time.Now().Add(2 * time.Hour)
+
+	chain := func(certs ...*x509.Certificate) string {
+		x5c := make([]string, len(certs))
+		for i, c := range certs {
+			x5c[i] = base64.StdEncoding.EncodeToString(c.Raw)
+		}
+		s, err := CertificateChainPEM(x5c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	private := func(k *ecdsa.PrivateKey) string {
+		b, _ := x509.MarshalECPrivateKey(k)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}))
+	}
+
+	tests := []struct {
+		name       string
+		publicKey  string
+		privateKey string
+		roots      *x509.CertPool
+		now        time.Time
+		wantErrMsg string
+	}{
+		{name: "Valid chain", publicKey: chain(leaf, inter), privateKey: private(leafKey), roots: roots},
+		{name: "Missing intermediate", publicKey: chain(leaf), privateKey: private(leafKey), roots: roots,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: x509: certificate signed by unknown authority"},
+		{name: "Unknown root", publicKey: chain(leaf, inter), privateKey: private(leafKey), roots: x509.NewCertPool(),
+			wantErrMsg: "certificate of keyId 'cert' is not valid: x509: certificate signed by unknown authority"},
+		{name: "Expired", publicKey: chain(leaf, inter), privateKey: private(leafKey), roots: roots,
+			now: later, wantErrMsg: "certificate of keyId 'cert' is not valid: " +
+				"x509: certificate has expired or is not yet valid: current time " +
+				later.UTC().Format(time.RFC3339) + " is after " +
+				leaf.NotAfter.UTC().Format(time.RFC3339)},
+		{name: "Key usage", publicKey: chain(encLeaf, inter), privateKey: private(encKey), roots: roots,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: " +
+				"ErrCrypto: certificate key usage doesn't allow digital signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+				"cert": {KeyID: "cert", PublicKey: tt.publicKey, PrivateKey: tt.privateKey, Algorithm: algEcdsaSha256},
+			}))
+			hs.SetCertificateRoots(tt.roots)
+			r := testBenchRequest()
+			if err := hs.Sign("cert", r); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.now.IsZero() {
+				hs.SetClock(func() time.Time { return tt.now })
+			}
+			err := hs.Verify(r)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if len(tt.wantErrMsg) > 0 && !errors.Is(err, ErrInvalidCertificate) {
+				t.Errorf("error = %v, want ErrInvalidCertificate", err)
+			}
+		})
+	}
+}
+
+func TestCertificateChainPEM(t *testing.T) {
+	_, err := CertificateChainPEM([]string{"%"})
+	assert(t, nil, err, testErrCryptoType, "Not base64", nil,
+		"ErrCrypto: error decode certificate from base64: illegal base64 data at input byte 0")
+	_, err = CertificateChainPEM([]string{"AAAA"})
+	if err == nil {
+		t.Error("CertificateChainPEM() no error for wrong certificate")
+	}
+}
@@ -0,0 +1,368 @@
+// Package config builds HTTPSignatures from declarative configuration (JSON document or Config struct), so gateways
+// can manage signature policy without Go code. YAML documents could be decoded into Config by any YAML library,
+// fields have yaml tags.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+// Config HTTPSignatures configuration, empty fields keep defaults
+type Config struct {
+	// Secrets static secrets storage, set another storage with httpsignatures.WithSecretsStorage option of Build
+	Secrets             []Secret            `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	SignatureHeaders    []string            `json:"signatureHeaders,omitempty" yaml:"signatureHeaders,omitempty"`
+	ExpiresSeconds      *uint32             `json:"expiresSeconds,omitempty" yaml:"expiresSeconds,omitempty"`
+	DefaultDigest       string              `json:"defaultDigest,omitempty" yaml:"defaultDigest,omitempty"`
+	VerifyDigest        *bool               `json:"verifyDigest,omitempty" yaml:"verifyDigest,omitempty"`
+	MaxSignatureHeaders *int                `json:"maxSignatureHeaders,omitempty" yaml:"maxSignatureHeaders,omitempty"`
+	ClockSkew           Duration            `json:"clockSkew,omitempty" yaml:"clockSkew,omitempty"`
+	ParserMode          string              `json:"parserMode,omitempty" yaml:"parserMode,omitempty"`
+	AlgorithmAliases    map[string]string   `json:"algorithmAliases,omitempty" yaml:"algorithmAliases,omitempty"`
+	DigestAliases       map[string]string   `json:"digestAliases,omitempty" yaml:"digestAliases,omitempty"`
+	Policy              *Policy             `json:"policy,omitempty" yaml:"policy,omitempty"`
+	Quirks              *Quirks             `json:"quirks,omitempty" yaml:"quirks,omitempty"`
+	KeyQuirks           map[string]Quirks   `json:"keyQuirks,omitempty" yaml:"keyQuirks,omitempty"`
+	KeyPins             map[string]string   `json:"keyPins,omitempty" yaml:"keyPins,omitempty"`
+	Profiles            map[string]Profile  `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Identities          map[string]Identity `json:"identities,omitempty" yaml:"identities,omitempty"`
+}
+
+// Secret static secret, keys are set inline or read from PEM files
+type Secret struct {
+	KeyID          string    `json:"keyId" yaml:"keyId"`
+	Algorithm      string    `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	PublicKey      string    `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+	PrivateKey     string    `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+	PublicKeyFile  string    `json:"publicKeyFile,omitempty" yaml:"publicKeyFile,omitempty"`
+	PrivateKeyFile string    `json:"privateKeyFile,omitempty" yaml:"privateKeyFile,omitempty"`
+	NotBefore      time.Time `json:"notBefore,omitempty" yaml:"notBefore,omitempty"`
+	NotAfter       time.Time `json:"notAfter,omitempty" yaml:"notAfter,omitempty"`
+}
+
+// Policy signature verification policy, see httpsignatures.Policy
+type Policy struct {
+	RequiredHeaders []string `json:"requiredHeaders,omitempty" yaml:"requiredHeaders,omitempty"`
+	MaxAge          Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	MaxDateAge      Duration `json:"maxDateAge,omitempty" yaml:"maxDateAge,omitempty"`
+	Challenge       bool     `json:"challenge,omitempty" yaml:"challenge,omitempty"`
+	Realm           string   `json:"realm,omitempty" yaml:"realm,omitempty"`
+}
+
+// Quirks compatibility quirks, see httpsignatures.Quirks. MissingHeaders is "error", "empty" or "skip",
+// SignatureEncoding is "base64", "base64url", "hex" or "multibase".
+type Quirks struct {
+	AllowMissingAlgorithm bool   `json:"allowMissingAlgorithm,omitempty" yaml:"allowMissingAlgorithm,omitempty"`
+	CaseSensitiveHeaders  bool   `json:"caseSensitiveHeaders,omitempty" yaml:"caseSensitiveHeaders,omitempty"`
+	MissingHeaders        string `json:"missingHeaders,omitempty" yaml:"missingHeaders,omitempty"`
+	SignatureEncoding     string `json:"signatureEncoding,omitempty" yaml:"signatureEncoding,omitempty"`
+}
+
+// Profile signing profile, see httpsignatures.Profile
+type Profile struct {
+	Algorithm         string   `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	Headers           []string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	DigestAlgorithm   string   `json:"digestAlgorithm,omitempty" yaml:"digestAlgorithm,omitempty"`
+	Header            string   `json:"header,omitempty" yaml:"header,omitempty"`
+	TTL               Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	SignatureEncoding string   `json:"signatureEncoding,omitempty" yaml:"signatureEncoding,omitempty"`
+}
+
+// Identity signer identity, its secret is looked up in the secrets storage
+type Identity struct {
+	KeyID   string `json:"keyId" yaml:"keyId"`
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+}
+
+// Duration time.Duration encoded as string, e.g. "5m"
+type Duration time.Duration
+
+// UnmarshalText parse duration string
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText format duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Parse parse JSON config, unknown fields are rejected
+func Parse(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	c := new(Config)
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+	return c, nil
+}
+
+// Load read JSON config & build HTTPSignatures, opts are applied after the config
+func Load(r io.Reader, opts ...httpsignatures.Option) (*httpsignatures.HTTPSignatures, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Build(opts...)
+}
+
+// Build build HTTPSignatures, opts are applied after the config (e.g. custom algorithms or secrets storage)
+func (c *Config) Build(opts ...httpsignatures.Option) (*httpsignatures.HTTPSignatures, error) {
+	cOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return httpsignatures.New(append(cOpts, opts...)...)
+}
+
+// Options convert config to httpsignatures options, applied in dependency order (aliases before profiles,
+// profiles before identities)
+func (c *Config) Options() ([]httpsignatures.Option, error) {
+	var opts []httpsignatures.Option
+	if len(c.Secrets) > 0 {
+		ss, err := c.secrets()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpsignatures.WithSecretsStorage(ss))
+	}
+	if len(c.SignatureHeaders) > 0 {
+		opts = append(opts, httpsignatures.WithSignatureHeaders(c.SignatureHeaders...))
+	}
+	if c.ExpiresSeconds != nil {
+		opts = append(opts, httpsignatures.WithExpiresSeconds(*c.ExpiresSeconds))
+	}
+	for _, alias := range sortedKeys(c.DigestAliases) {
+		alias, alg := alias, c.DigestAliases[alias]
+		opts = append(opts, func(hs *httpsignatures.HTTPSignatures) error {
+			return hs.SetDigestAlgorithmAlias(alias, alg)
+		})
+	}
+	if len(c.DefaultDigest) > 0 {
+		opts = append(opts, httpsignatures.WithDefaultDigest(c.DefaultDigest))
+	}
+	if c.VerifyDigest != nil {
+		opts = append(opts, httpsignatures.WithVerifyDigest(*c.VerifyDigest))
+	}
+	if c.MaxSignatureHeaders != nil {
+		opts = append(opts, httpsignatures.WithMaxSignatureHeaders(*c.MaxSignatureHeaders))
+	}
+	if c.ClockSkew > 0 {
+		opts = append(opts, func(hs *httpsignatures.HTTPSignatures) error {
+			hs.SetDefaultTimeGap(int64(c.ClockSkew))
+			return nil
+		})
+	}
+	if len(c.ParserMode) > 0 {
+		m, err := parserMode(c.ParserMode)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpsignatures.WithParserMode(m))
+	}
+	for _, alias := range sortedKeys(c.AlgorithmAliases) {
+		alias, alg := alias, c.AlgorithmAliases[alias]
+		opts = append(opts, func(hs *httpsignatures.HTTPSignatures) error {
+			return hs.SetSignatureAlgorithmAlias(alias, alg)
+		})
+	}
+	if c.Policy != nil {
+		opts = append(opts, httpsignatures.WithPolicy(c.Policy.policy()))
+	}
+	if c.Quirks != nil {
+		q, err := c.Quirks.quirks()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpsignatures.WithQuirks(q))
+	}
+	for _, keyID := range sortedKeys(c.KeyQuirks) {
+		q, err := c.KeyQuirks[keyID].quirks()
+		if err != nil {
+			return nil, fmt.Errorf("quirks of keyId '%s': %w", keyID, err)
+		}
+		opts = append(opts, httpsignatures.WithKeyQuirks(keyID, q))
+	}
+	for _, keyID := range sortedKeys(c.KeyPins) {
+		opts = append(opts, httpsignatures.WithKeyPin(keyID, c.KeyPins[keyID]))
+	}
+	for _, name := range sortedKeys(c.Profiles) {
+		p, err := c.Profiles[name].profile()
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", name, err)
+		}
+		opts = append(opts, httpsignatures.WithProfile(name, p))
+	}
+	for _, name := range sortedKeys(c.Identities) {
+		id := c.Identities[name]
+		opts = append(opts, httpsignatures.WithIdentity(name, httpsignatures.Identity{
+			KeyID:   id.KeyID,
+			Profile: id.Profile,
+		}))
+	}
+	return opts, nil
+}
+
+// secrets static secrets storage, keys are read from files
+func (c *Config) secrets() (httpsignatures.Secrets, error) {
+	storage := make(map[string]httpsignatures.Secret, len(c.Secrets))
+	for _, s := range c.Secrets {
+		if len(s.KeyID) == 0 {
+			return nil, fmt.Errorf("secret without keyId")
+		}
+		if _, ok := storage[s.KeyID]; ok {
+			return nil, fmt.Errorf("duplicate secret '%s'", s.KeyID)
+		}
+		secret := httpsignatures.Secret{
+			KeyID:      s.KeyID,
+			PublicKey:  s.PublicKey,
+			PrivateKey: s.PrivateKey,
+			Algorithm:  s.Algorithm,
+			NotBefore:  s.NotBefore,
+			NotAfter:   s.NotAfter,
+		}
+		var err error
+		if secret.PublicKey, err = readKey(secret.PublicKey, s.PublicKeyFile); err != nil {
+			return nil, fmt.Errorf("public key of secret '%s': %w", s.KeyID, err)
+		}
+		if secret.PrivateKey, err = readKey(secret.PrivateKey, s.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("private key of secret '%s': %w", s.KeyID, err)
+		}
+		storage[s.KeyID] = secret
+	}
+	return httpsignatures.NewSimpleSecretsStorage(storage), nil
+}
+
+// readKey read key from file if it's set, inline key otherwise
+func readKey(key string, file string) (string, error) {
+	if len(file) == 0 {
+		return key, nil
+	}
+	if len(key) > 0 {
+		return "", fmt.Errorf("both key & key file are set")
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (p Policy) policy() httpsignatures.Policy {
+	return httpsignatures.Policy{
+		RequiredHeaders: p.RequiredHeaders,
+		MaxAge:          time.Duration(p.MaxAge),
+		MaxDateAge:      time.Duration(p.MaxDateAge),
+		Challenge:       p.Challenge,
+		Realm:           p.Realm,
+	}
+}
+
+func (q Quirks) quirks() (httpsignatures.Quirks, error) {
+	res := httpsignatures.Quirks{
+		AllowMissingAlgorithm: q.AllowMissingAlgorithm,
+		CaseSensitiveHeaders:  q.CaseSensitiveHeaders,
+	}
+	switch strings.ToLower(q.MissingHeaders) {
+	case "", "error":
+		res.MissingHeaders = httpsignatures.MissingHeadersError
+	case "empty":
+		res.MissingHeaders = httpsignatures.MissingHeadersEmpty
+	case "skip":
+		res.MissingHeaders = httpsignatures.MissingHeadersSkip
+	default:
+		return res, fmt.Errorf("unknown missing headers policy '%s'", q.MissingHeaders)
+	}
+	var err error
+	res.SignatureEncoding, err = signatureEncoding(q.SignatureEncoding)
+	return res, err
+}
+
+func (p Profile) profile() (httpsignatures.Profile, error) {
+	e, err := signatureEncoding(p.SignatureEncoding)
+	if err != nil {
+		return httpsignatures.Profile{}, err
+	}
+	return httpsignatures.Profile{
+		Algorithm:         p.Algorithm,
+		Headers:           p.Headers,
+		DigestAlgorithm:   p.DigestAlgorithm,
+		Header:            p.Header,
+		TTL:               time.Duration(p.TTL),
+		SignatureEncoding: e,
+	}, nil
+}
+
+// signatureEncoding encoding by name, base64 if it's empty
+func signatureEncoding(name string) (httpsignatures.SignatureEncoding, error) {
+	for _, e := range []httpsignatures.SignatureEncoding{
+		httpsignatures.SignatureEncodingBase64,
+		httpsignatures.SignatureEncodingBase64URL,
+		httpsignatures.SignatureEncodingHex,
+		httpsignatures.SignatureEncodingMultibase,
+	} {
+		if strings.EqualFold(name, e.String()) {
+			return e, nil
+		}
+	}
+	if len(name) == 0 {
+		return httpsignatures.SignatureEncodingBase64, nil
+	}
+	return 0, fmt.Errorf("unknown signature encoding '%s'", name)
+}
+
+func parserMode(name string) (httpsignatures.ParserMode, error) {
+	switch strings.ToLower(name) {
+	case "default":
+		return httpsignatures.ParserModeDefault, nil
+	case "strict":
+		return httpsignatures.ParserModeStrict, nil
+	case "lenient":
+		return httpsignatures.ParserModeLenient, nil
+	}
+	return 0, fmt.Errorf("unknown parser mode '%s'", name)
+}
+
+// sortedKeys map keys in order, options are applied in the same order on every build
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]string:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]Quirks:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]Profile:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]Identity:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
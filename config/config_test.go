@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+const testConfig = `{
+	"secrets": [
+		{"keyId": "hmac", "algorithm": "HMAC-SHA256", "privateKey": "secret", "publicKey": "secret"},
+		{"keyId": "ed", "algorithm": "ED25519", "privateKeyFile": "%PRIVATE%", "publicKeyFile": "%PUBLIC%"}
+	],
+	"signatureHeaders": ["(request-target)", "(created)", "host", "digest"],
+	"expiresSeconds": 60,
+	"defaultDigest": "sha256",
+	"parserMode": "strict",
+	"algorithmAliases": {"eddsa": "ED25519"},
+	"policy": {"requiredHeaders": ["(request-target)", "digest"], "maxAge": "5m"},
+	"keyQuirks": {"ed": {"signatureEncoding": "base64url"}},
+	"profiles": {
+		"s2s": {"algorithm": "hs2019", "headers": ["(request-target)", "(created)", "digest"], "ttl": "1m",
+			"signatureEncoding": "base64url"}
+	},
+	"identities": {"partner": {"keyId": "ed", "profile": "s2s"}}
+}`
+
+func testRequest() *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "https://example.com/foo?a=1", strings.NewReader(`{"hello": "world"}`))
+	return r
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ed, err := httpsignatures.GenerateSecret("ed", "ED25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, public := filepath.Join(dir, "ed.key"), filepath.Join(dir, "ed.pub")
+	if err := ioutil.WriteFile(private, []byte(ed.PrivateKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(public, []byte(ed.PublicKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc := strings.NewReplacer("%PRIVATE%", private, "%PUBLIC%", public).Replace(testConfig)
+
+	hs, err := Load(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Header.Get("Digest"), "SHA-256=") || !strings.Contains(r.Header.Get("Signature"),
+		`headers="(request-target) (created) host digest"`) {
+		t.Errorf("wrong headers: Digest %s, Signature %s", r.Header.Get("Digest"), r.Header.Get("Signature"))
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	r = testRequest()
+	if err := hs.SignAs(r, "partner"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Header.Get("Signature"), `keyId="ed",algorithm="hs2019"`) {
+		t.Errorf("wrong signature header: %s", r.Header.Get("Signature"))
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	r = testRequest()
+	if err := hs.SignWith(r, httpsignatures.SignKeyID("hmac"), httpsignatures.SignHeaders("(created)")); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); !errors.Is(err, httpsignatures.ErrPolicyViolation) {
+		t.Errorf("Verify() error = %v, want ErrPolicyViolation", err)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		wantErrMsg string
+	}{
+		{
+			name:       "Unknown field",
+			doc:        `{"signatureHeader": ["host"]}`,
+			wantErrMsg: `error parsing config: json: unknown field "signatureHeader"`,
+		},
+		{
+			name:       "Wrong duration",
+			doc:        `{"policy": {"maxAge": "5 minutes"}}`,
+			wantErrMsg: `error parsing config: time: unknown unit " minutes" in duration "5 minutes"`,
+		},
+		{
+			name:       "Unknown parser mode",
+			doc:        `{"parserMode": "relaxed"}`,
+			wantErrMsg: "unknown parser mode 'relaxed'",
+		},
+		{
+			name:       "Secret without keyId",
+			doc:        `{"secrets": [{"privateKey": "secret"}]}`,
+			wantErrMsg: "secret without keyId",
+		},
+		{
+			name:       "Duplicate secret",
+			doc:        `{"secrets": [{"keyId": "a"}, {"keyId": "a"}]}`,
+			wantErrMsg: "duplicate secret 'a'",
+		},
+		{
+			name:       "Key & key file",
+			doc:        `{"secrets": [{"keyId": "a", "publicKey": "key", "publicKeyFile": "key.pem"}]}`,
+			wantErrMsg: "public key of secret 'a': both key & key file are set",
+		},
+		{
+			name:       "Unknown encoding",
+			doc:        `{"profiles": {"p": {"signatureEncoding": "base32"}}}`,
+			wantErrMsg: "profile 'p': unknown signature encoding 'base32'",
+		},
+		{
+			name:       "Unknown missing headers policy",
+			doc:        `{"quirks": {"missingHeaders": "ignore"}}`,
+			wantErrMsg: "unknown missing headers policy 'ignore'",
+		},
+		{
+			name:       "Unsupported algorithm alias",
+			doc:        `{"algorithmAliases": {"x": "RSA-MD5"}}`,
+			wantErrMsg: "algorithm 'RSA-MD5' not supported",
+		},
+		{
+			name:       "Identity with unknown profile",
+			doc:        `{"identities": {"a": {"keyId": "a", "profile": "p"}}}`,
+			wantErrMsg: "profile 'p' not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.doc))
+			if err == nil || err.Error() != tt.wantErrMsg {
+				t.Errorf("Load() error = %v, wantErrMsg = `%s`", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestBuildOptions(t *testing.T) {
+	c := Config{Profiles: map[string]Profile{"webhooks": {TTL: Duration(5 * time.Minute)}}}
+	ss := httpsignatures.NewSimpleSecretsStorage(map[string]httpsignatures.Secret{
+		"k": {KeyID: "k", PrivateKey: "secret", Algorithm: "HMAC-SHA512"},
+	})
+	hs, err := c.Build(httpsignatures.WithSecretsStorage(ss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := hs.Profile("webhooks"); !ok || p.TTL != 5*time.Minute {
+		t.Errorf("Profile() = %+v, %v, want TTL 5m", p, ok)
+	}
+	if err := hs.SignWithProfile(testRequest(), "webhooks", "k"); err != nil {
+		t.Errorf("SignWithProfile() error = %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+// Environment variables read by FromEnv. Lists are comma or space separated, durations are e.g. "30s".
+const (
+	// EnvKeyID keyId of the default identity
+	EnvKeyID = "HTTPSIG_KEY_ID"
+	// EnvAlgorithm algorithm of the key, derived from the key if empty
+	EnvAlgorithm = "HTTPSIG_ALGORITHM"
+	// EnvPrivateKey inline private key (PEM) or HMAC secret
+	EnvPrivateKey = "HTTPSIG_PRIVATE_KEY"
+	// EnvPrivateKeyFile private key file
+	EnvPrivateKeyFile = "HTTPSIG_PRIVATE_KEY_FILE"
+	// EnvPublicKey inline public key (PEM) or HMAC secret
+	EnvPublicKey = "HTTPSIG_PUBLIC_KEY"
+	// EnvPublicKeyFile public key file
+	EnvPublicKeyFile = "HTTPSIG_PUBLIC_KEY_FILE"
+	// EnvSignatureHeaders default signature headers
+	EnvSignatureHeaders = "HTTPSIG_SIGNATURE_HEADERS"
+	// EnvRequiredHeaders headers which must be signed (Policy.RequiredHeaders)
+	EnvRequiredHeaders = "HTTPSIG_REQUIRED_HEADERS"
+	// EnvMaxAge max signature age (Policy.MaxAge)
+	EnvMaxAge = "HTTPSIG_MAX_AGE"
+	// EnvClockSkew allowed clock skew of created & expires params
+	EnvClockSkew = "HTTPSIG_CLOCK_SKEW"
+	// EnvExpiresSeconds expires param of created signatures, seconds
+	EnvExpiresSeconds = "HTTPSIG_EXPIRES_SECONDS"
+	// EnvDefaultDigest default digest algorithm
+	EnvDefaultDigest = "HTTPSIG_DEFAULT_DIGEST"
+	// EnvParserMode signature header parser mode: "default", "strict" or "lenient"
+	EnvParserMode = "HTTPSIG_PARSER_MODE"
+)
+
+// DefaultIdentity name of the identity of EnvKeyID key, sign with hs.SignAs(r, config.DefaultIdentity)
+const DefaultIdentity = "default"
+
+// NewFromEnv build HTTPSignatures from environment variables (see Env* constants), e.g. for serverless & container
+// deployments without config files. Options are applied after the config.
+func NewFromEnv(opts ...httpsignatures.Option) (*httpsignatures.HTTPSignatures, error) {
+	c, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return c.Build(opts...)
+}
+
+// FromEnv read config from environment variables, unset variables keep defaults
+func FromEnv() (*Config, error) {
+	return fromEnv(os.LookupEnv)
+}
+
+func fromEnv(lookup func(key string) (string, bool)) (*Config, error) {
+	get := func(key string) string {
+		v, _ := lookup(key)
+		return strings.TrimSpace(v)
+	}
+	c := &Config{
+		SignatureHeaders: envList(get(EnvSignatureHeaders)),
+		DefaultDigest:    get(EnvDefaultDigest),
+		ParserMode:       get(EnvParserMode),
+	}
+
+	if v := get(EnvExpiresSeconds); len(v) > 0 {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", EnvExpiresSeconds, err)
+		}
+		e := uint32(n)
+		c.ExpiresSeconds = &e
+	}
+	if err := envDuration(EnvClockSkew, get(EnvClockSkew), &c.ClockSkew); err != nil {
+		return nil, err
+	}
+
+	p := Policy{RequiredHeaders: envList(get(EnvRequiredHeaders))}
+	if err := envDuration(EnvMaxAge, get(EnvMaxAge), &p.MaxAge); err != nil {
+		return nil, err
+	}
+	if len(p.RequiredHeaders) > 0 || p.MaxAge > 0 {
+		c.Policy = &p
+	}
+
+	s := Secret{
+		KeyID:          get(EnvKeyID),
+		Algorithm:      get(EnvAlgorithm),
+		PrivateKey:     get(EnvPrivateKey),
+		PrivateKeyFile: get(EnvPrivateKeyFile),
+		PublicKey:      get(EnvPublicKey),
+		PublicKeyFile:  get(EnvPublicKeyFile),
+	}
+	hasKey := len(s.PrivateKey) > 0 || len(s.PrivateKeyFile) > 0 || len(s.PublicKey) > 0 || len(s.PublicKeyFile) > 0
+	if len(s.KeyID) == 0 && (hasKey || len(s.Algorithm) > 0) {
+		return nil, fmt.Errorf("%s is not set", EnvKeyID)
+	}
+	if len(s.Algorithm) > 0 && !hasKey {
+		return nil, fmt.Errorf("%s is set without key", EnvAlgorithm)
+	}
+	if len(s.KeyID) == 0 {
+		return c, nil
+	}
+	// Without key the keyId is looked up in the secrets storage set by Build options
+	if hasKey {
+		c.Secrets = []Secret{s}
+	}
+	c.Identities = map[string]Identity{DefaultIdentity: {KeyID: s.KeyID}}
+	return c, nil
+}
+
+// envList split comma or space separated list
+func envList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+func envDuration(key string, v string, d *Duration) error {
+	if len(v) == 0 {
+		return nil
+	}
+	if err := d.UnmarshalText([]byte(v)); err != nil {
+		return fmt.Errorf("error parsing %s: %w", key, err)
+	}
+	return nil
+}
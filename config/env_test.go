@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ed, err := httpsignatures.GenerateSecret("ed", "ED25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := filepath.Join(dir, "ed.key")
+	if err := ioutil.WriteFile(private, []byte(ed.PrivateKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		EnvKeyID:            "ed",
+		EnvAlgorithm:        "ED25519",
+		EnvPrivateKeyFile:   private,
+		EnvPublicKey:        ed.PublicKey,
+		EnvSignatureHeaders: "(request-target), (created), digest",
+		EnvRequiredHeaders:  "(request-target) digest",
+		EnvMaxAge:           "5m",
+		EnvClockSkew:        "30s",
+		EnvDefaultDigest:    "SHA-512",
+	}
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Unsetenv(k)
+	}
+
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ClockSkew != Duration(30*time.Second) || c.Policy == nil || c.Policy.MaxAge != Duration(5*time.Minute) {
+		t.Errorf("FromEnv() = %+v, want clock skew 30s & max age 5m", c)
+	}
+
+	hs, err := NewFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testRequest()
+	if err := hs.SignAs(r, DefaultIdentity); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Header.Get("Digest"), "SHA-512=") || !strings.Contains(r.Header.Get("Signature"),
+		`headers="(request-target) (created) digest"`) {
+		t.Errorf("wrong headers: Digest %s, Signature %s", r.Header.Get("Digest"), r.Header.Get("Signature"))
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	r = testRequest()
+	if err := hs.SignWith(r, httpsignatures.SignKeyID("ed"), httpsignatures.SignHeaders("(created)")); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); !errors.Is(err, httpsignatures.ErrPolicyViolation) {
+		t.Errorf("Verify() error = %v, want ErrPolicyViolation", err)
+	}
+}
+
+func TestFromEnvStorageKey(t *testing.T) {
+	c, err := fromEnv(testLookup(map[string]string{EnvKeyID: "k"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := httpsignatures.NewSimpleSecretsStorage(map[string]httpsignatures.Secret{
+		"k": {KeyID: "k", PrivateKey: "secret", Algorithm: "HMAC-SHA256"},
+	})
+	hs, err := c.Build(httpsignatures.WithSecretsStorage(ss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.SignAs(testRequest(), DefaultIdentity); err != nil {
+		t.Errorf("SignAs() error = %v", err)
+	}
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantErrMsg string
+	}{
+		{
+			name:       "Key without keyId",
+			env:        map[string]string{EnvPrivateKey: "secret"},
+			wantErrMsg: "HTTPSIG_KEY_ID is not set",
+		},
+		{
+			name:       "Algorithm without key",
+			env:        map[string]string{EnvKeyID: "k", EnvAlgorithm: "HMAC-SHA256"},
+			wantErrMsg: "HTTPSIG_ALGORITHM is set without key",
+		},
+		{
+			name:       "Wrong clock skew",
+			env:        map[string]string{EnvClockSkew: "30"},
+			wantErrMsg: `error parsing HTTPSIG_CLOCK_SKEW: time: missing unit in duration "30"`,
+		},
+		{
+			name:       "Wrong expires",
+			env:        map[string]string{EnvExpiresSeconds: "-1"},
+			wantErrMsg: `error parsing HTTPSIG_EXPIRES_SECONDS: strconv.ParseUint: parsing "-1": invalid syntax`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fromEnv(testLookup(tt.env))
+			if err == nil || err.Error() != tt.wantErrMsg {
+				t.Errorf("fromEnv() error = %v, wantErrMsg = `%s`", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func testLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
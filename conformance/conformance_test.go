@@ -0,0 +1,51 @@
+package conformance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+type testBrokenAlg struct {
+	httpsignatures.RsaSha256
+}
+
+func (testBrokenAlg) Verify(httpsignatures.Secret, []byte, []byte) error {
+	return errors.New("broken")
+}
+
+func TestRun(t *testing.T) {
+	res := Run(nil)
+	if len(res) != len(Vectors()) {
+		t.Fatalf("got %d results, want %d", len(res), len(Vectors()))
+	}
+	for _, r := range res {
+		if r.Err != nil {
+			t.Errorf("vector '%s': %v", r.Vector.Name, r.Err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(func(hs *httpsignatures.HTTPSignatures) {
+		hs.SetParserMode(httpsignatures.ParserModeStrict)
+	}); err != nil {
+		t.Errorf("Check error = %v", err)
+	}
+
+	err := Check(func(hs *httpsignatures.HTTPSignatures) {
+		hs.SetSignatureHashAlgorithm(testBrokenAlg{})
+	})
+	want := "vector 'Default Test': wrong signature: broken"
+	if err == nil || err.Error() != want {
+		t.Errorf("Check error = %v, want %s", err, want)
+	}
+	if !errors.Is(err, httpsignatures.ErrWrongSignature) {
+		t.Errorf("Check error = %v, want ErrWrongSignature", err)
+	}
+}
+
+func TestRFC9421(t *testing.T) {
+	t.Skip("RFC 9421 examples are not included: RFC 9421 signatures are not supported")
+}
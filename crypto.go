@@ -36,6 +36,7 @@ type DigestHashAlgorithm interface {
 type ErrCrypto struct {
 	Message string
 	Err     error
+	kind    error
 }
 
 // ErrHS error message
@@ -49,6 +50,16 @@ func (e *ErrCrypto) Error() string {
 	return fmt.Sprintf("ErrCrypto: %s", e.Message)
 }
 
+// Unwrap return wrapped error
+func (e *ErrCrypto) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel error (e.g. ErrSignatureExpired)
+func (e *ErrCrypto) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
 // ECDSASignature ECDSA signature
 type ECDSASignature struct {
 	R, S *big.Int
@@ -71,22 +82,23 @@ type ED25519PublicKey struct {
 	PublicKey asn1.BitString
 }
 
-func digestHashAlgorithmVerify(newHash func() hash.Hash, data []byte, digest []byte) error {
-	expected, err := digestHashAlgorithmCreate(newHash, data)
+func digestHashAlgorithmVerify(pool *hashPool, data []byte, digest []byte) error {
+	expected, err := digestHashAlgorithmCreate(pool, data)
 	if err != nil {
 		return err
 	}
 	if subtle.ConstantTimeCompare(digest, expected) != 1 {
-		return &ErrCrypto{"wrong hash", nil}
+		return &ErrCrypto{Message: "wrong hash"}
 	}
 	return nil
 }
 
-func digestHashAlgorithmCreate(newHash func() hash.Hash, data []byte) ([]byte, error) {
-	h := newHash()
+func digestHashAlgorithmCreate(pool *hashPool, data []byte) ([]byte, error) {
+	h := pool.get()
+	defer pool.put(h)
 	_, err := h.Write(data)
 	if err != nil {
-		return nil, &ErrCrypto{"error creating hash", err}
+		return nil, &ErrCrypto{Message: "error creating hash", Err: err}
 	}
 	return h.Sum(nil), nil
 }
@@ -97,26 +109,26 @@ func signatureHashAlgorithmVerify(newHash func() hash.Hash, secret Secret, data
 		return err
 	}
 	if !hmac.Equal(signature, expected) {
-		return &ErrCrypto{"wrong signature", nil}
+		return &ErrCrypto{Message: "wrong signature"}
 	}
 	return nil
 }
 
 func signatureHashAlgorithmCreate(newHash func() hash.Hash, secret Secret, data []byte) ([]byte, error) {
 	if len(secret.PrivateKey) == 0 {
-		return nil, &ErrCrypto{"no private key found", nil}
+		return nil, &ErrCrypto{Message: "no private key found"}
 	}
 	mac := hmac.New(newHash, []byte(secret.PrivateKey))
 	_, err := mac.Write(data)
 	if err != nil {
-		return nil, &ErrCrypto{"error creating signature", err}
+		return nil, &ErrCrypto{Message: "error creating signature", Err: err}
 	}
 	return mac.Sum(nil), nil
 }
 
-func signatureRsaAlgorithmVerify(t string, newHash func() hash.Hash, hash crypto.Hash, secret Secret, data []byte,
+func signatureRsaAlgorithmVerify(t string, pool *hashPool, hash crypto.Hash, secret Secret, data []byte,
 	signature []byte) error {
-	publicKey, err := loadPublicKey(secret.PublicKey)
+	publicKey, err := publicKeys.load(secret.PublicKey, loadPublicKey)
 	if err != nil {
 		return err
 	}
@@ -126,10 +138,11 @@ func signatureRsaAlgorithmVerify(t string, newHash func() hash.Hash, hash crypto
 	case *rsa.PublicKey:
 		publicKeyRsa = publicKey
 	default:
-		return &ErrCrypto{"unknown type of public key", nil}
+		return &ErrCrypto{Message: "unknown type of public key"}
 	}
 
-	h := newHash()
+	h := pool.get()
+	defer pool.put(h)
 	_, _ = h.Write(data)
 
 	switch t {
@@ -140,18 +153,18 @@ func signatureRsaAlgorithmVerify(t string, newHash func() hash.Hash, hash crypto
 		opts.SaltLength = rsa.PSSSaltLengthEqualsHash
 		err = rsa.VerifyPSS(publicKeyRsa, hash, h.Sum(nil), signature, &opts)
 	default:
-		return &ErrCrypto{fmt.Sprintf("unsupported verify algorithm type %s", t), err}
+		return &ErrCrypto{Message: fmt.Sprintf("unsupported verify algorithm type %s", t), Err: err}
 	}
 
 	if err != nil {
-		return &ErrCrypto{"error verify signature", err}
+		return &ErrCrypto{Message: "error verify signature", Err: err}
 	}
 	return nil
 }
 
-func signatureRsaAlgorithmCreate(t string, newHash func() hash.Hash, hash crypto.Hash, secret Secret,
+func signatureRsaAlgorithmCreate(t string, pool *hashPool, hash crypto.Hash, secret Secret,
 	data []byte) ([]byte, error) {
-	privateKey, err := loadPrivateKey(secret.PrivateKey)
+	privateKey, err := privateKeys.load(secret.PrivateKey, loadPrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -161,10 +174,11 @@ func signatureRsaAlgorithmCreate(t string, newHash func() hash.Hash, hash crypto
 	case *rsa.PrivateKey:
 		privateKeyRsa = privateKey
 	default:
-		return nil, &ErrCrypto{"unknown private key type", nil}
+		return nil, &ErrCrypto{Message: "unknown private key type"}
 	}
 
-	h := newHash()
+	h := pool.get()
+	defer pool.put(h)
 	_, _ = h.Write(data)
 
 	switch t {
@@ -175,13 +189,13 @@ func signatureRsaAlgorithmCreate(t string, newHash func() hash.Hash, hash crypto
 		opts.SaltLength = rsa.PSSSaltLengthEqualsHash
 		return rsa.SignPSS(rand.Reader, privateKeyRsa, hash, h.Sum(nil), &opts)
 	default:
-		return nil, &ErrCrypto{fmt.Sprintf("unsupported algorithm type %s", t), err}
+		return nil, &ErrCrypto{Message: fmt.Sprintf("unsupported algorithm type %s", t), Err: err}
 	}
 }
 
-func signatureEcdsaAlgorithmVerify(t string, newHash func() hash.Hash, secret Secret, data []byte,
+func signatureEcdsaAlgorithmVerify(t string, pool *hashPool, secret Secret, data []byte,
 	signature []byte) error {
-	publicKey, err := loadPublicKey(secret.PublicKey)
+	publicKey, err := publicKeys.load(secret.PublicKey, loadPublicKey)
 	if err != nil {
 		return err
 	}
@@ -191,34 +205,35 @@ func signatureEcdsaAlgorithmVerify(t string, newHash func() hash.Hash, secret Se
 	case *ecdsa.PublicKey:
 		publicKeyEcdsa = publicKey
 	default:
-		return &ErrCrypto{"unknown type of public key", nil}
+		return &ErrCrypto{Message: "unknown type of public key"}
 	}
 
 	sig := &ECDSASignature{}
 	_, err = asn1.Unmarshal(signature, sig)
 	if err != nil {
-		return &ErrCrypto{"error Unmarshal signature", err}
+		return &ErrCrypto{Message: "error Unmarshal signature", Err: err}
 	}
 
-	h := newHash()
+	h := pool.get()
+	defer pool.put(h)
 	_, _ = h.Write(data)
 
 	switch t {
 	case algEcdsaSha256, algEcdsaSha512:
 		res := ecdsa.Verify(publicKeyEcdsa, h.Sum(nil), sig.R, sig.S)
 		if !res {
-			return &ErrCrypto{"signature verification error", nil}
+			return &ErrCrypto{Message: "signature verification error"}
 		}
 	default:
-		return &ErrCrypto{fmt.Sprintf("unsupported verify algorithm type %s", t), err}
+		return &ErrCrypto{Message: fmt.Sprintf("unsupported verify algorithm type %s", t), Err: err}
 	}
 
 	return nil
 }
 
-func signatureEcdsaAlgorithmCreate(t string, newHash func() hash.Hash, secret Secret,
+func signatureEcdsaAlgorithmCreate(t string, pool *hashPool, secret Secret,
 	data []byte) ([]byte, error) {
-	privateKey, err := loadPrivateKey(secret.PrivateKey)
+	privateKey, err := privateKeys.load(secret.PrivateKey, loadPrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -228,10 +243,11 @@ func signatureEcdsaAlgorithmCreate(t string, newHash func() hash.Hash, secret Se
 	case *ecdsa.PrivateKey:
 		privateKeyEcdsa = privateKey
 	default:
-		return nil, &ErrCrypto{"unknown private key type", nil}
+		return nil, &ErrCrypto{Message: "unknown private key type"}
 	}
 
-	h := newHash()
+	h := pool.get()
+	defer pool.put(h)
 	_, _ = h.Write(data)
 
 	switch t {
@@ -246,14 +262,14 @@ func signatureEcdsaAlgorithmCreate(t string, newHash func() hash.Hash, secret Se
 		})
 		return sig, nil
 	default:
-		return nil, &ErrCrypto{fmt.Sprintf("unsupported algorithm type %s", t), err}
+		return nil, &ErrCrypto{Message: fmt.Sprintf("unsupported algorithm type %s", t), Err: err}
 	}
 }
 
-func loadPrivateKey(pk string) (crypto.PrivateKey, error) {
+func loadPrivateKey(pk string) (interface{}, error) {
 	block, _ := pem.Decode([]byte(pk))
 	if block == nil {
-		return nil, &ErrCrypto{"no private key found", nil}
+		return nil, &ErrCrypto{Message: "no private key found"}
 	}
 
 	if privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
@@ -261,7 +277,7 @@ func loadPrivateKey(pk string) (crypto.PrivateKey, error) {
 		case *rsa.PrivateKey, *ecdsa.PrivateKey:
 			return privateKey, nil
 		default:
-			return nil, &ErrCrypto{"unknown private key type in PKCS#8", nil}
+			return nil, &ErrCrypto{Message: "unknown private key type in PKCS#8"}
 		}
 	}
 
@@ -273,18 +289,18 @@ func loadPrivateKey(pk string) (crypto.PrivateKey, error) {
 		return privateKey, nil
 	}
 
-	return nil, &ErrCrypto{fmt.Sprintf("unsupported private key type %s", block.Type), nil}
+	return nil, &ErrCrypto{Message: fmt.Sprintf("unsupported private key type %s", block.Type)}
 }
 
-func loadPublicKey(pk string) (crypto.PublicKey, error) {
+func loadPublicKey(pk string) (interface{}, error) {
 	block, _ := pem.Decode([]byte(pk))
 	if block == nil {
-		return nil, &ErrCrypto{"no public key found", nil}
+		return nil, &ErrCrypto{Message: "no public key found"}
 	}
 
 	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
-		return nil, &ErrCrypto{"error ParsePKIXPublicKey", err}
+		return nil, &ErrCrypto{Message: "error ParsePKIXPublicKey", Err: err}
 	}
 	return pub, nil
 }
@@ -0,0 +1,60 @@
+package httpsignatures
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CurlCommand render signed request as equivalent curl command (with Signature, Digest & other headers) to reproduce
+// interop cases. Body is included if it can be read again (GetBody is set), streamed body is replaced with
+// "--data-binary @-" (read from stdin).
+func CurlCommand(r *http.Request) (string, error) {
+	var b strings.Builder
+	b.WriteString("curl -X ")
+	b.WriteString(shellQuote(r.Method))
+	b.WriteString(" ")
+	b.WriteString(shellQuote(r.URL.String()))
+
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(r.Host) > 0 && r.Host != r.URL.Host && len(r.Header.Get(hostHeader)) == 0 {
+		b.WriteString(" \\\n  -H ")
+		b.WriteString(shellQuote(hostHeader + ": " + r.Host))
+	}
+	for _, k := range keys {
+		for _, v := range r.Header[k] {
+			b.WriteString(" \\\n  -H ")
+			b.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+
+	switch {
+	case r.GetBody != nil:
+		rc, err := r.GetBody()
+		if err != nil {
+			return "", &ErrHS{Message: "error getting body", Err: err}
+		}
+		body, err := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return "", &ErrHS{Message: "error reading body", Err: err}
+		}
+		if len(body) > 0 {
+			b.WriteString(" \\\n  --data-binary ")
+			b.WriteString(shellQuote(string(body)))
+		}
+	case r.Body != nil && r.Body != http.NoBody:
+		b.WriteString(" \\\n  --data-binary @-")
+	}
+	return b.String(), nil
+}
+
+// shellQuote quote string for POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,65 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCurlCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    func() *http.Request
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "Request with body",
+			request: func() *http.Request {
+				r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, strings.NewReader(`{"it's": 1}`))
+				r.Header.Set("Digest", "SHA-256=abc")
+				r.Header.Set("Signature", `keyId="Test",signature="abc"`)
+				return r
+			},
+			want: `curl -X 'POST' 'https://example.com/foo?param=value&pet=dog' \` + "\n" +
+				`  -H 'Digest: SHA-256=abc' \` + "\n" +
+				`  -H 'Signature: keyId="Test",signature="abc"' \` + "\n" +
+				`  --data-binary '{"it'\''s": 1}'`,
+		},
+		{
+			name: "Custom host & streamed body",
+			request: func() *http.Request {
+				r := testPipeRequest(testBodyExample)
+				r.Host = "api.example.com"
+				return r
+			},
+			want: `curl -X 'POST' 'https://example.com' \` + "\n" +
+				`  -H 'Host: api.example.com' \` + "\n" +
+				`  --data-binary @-`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CurlCommand(tt.request())
+			assert(t, got, err, testHSErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestCurlCommandSigned(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+	r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := CurlCommand(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []string{digestHeader, signatureHeader} {
+		if !strings.Contains(got, shellQuote(h+": "+r.Header.Get(h))) {
+			t.Errorf("%s header is missing in %s", h, got)
+		}
+	}
+}
@@ -2,8 +2,13 @@ package httpsignatures
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -13,6 +18,7 @@ import (
 type ErrDigest struct {
 	Message string
 	Err     error
+	kind    error
 }
 
 // ErrHS error message
@@ -26,11 +32,56 @@ func (e *ErrDigest) Error() string {
 	return fmt.Sprintf("ErrDigest: %s", e.Message)
 }
 
+// Unwrap return wrapped error
+func (e *ErrDigest) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel error (e.g. ErrSignatureExpired)
+func (e *ErrDigest) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// EmptyBodyDigest how Digest is created & verified for requests without body (GET, HEAD, DELETE)
+type EmptyBodyDigest int
+
+const (
+	// EmptyBodyDigestError return "empty body" error (default)
+	EmptyBodyDigestError EmptyBodyDigest = iota
+	// EmptyBodyDigestHash create & verify digest of empty body
+	EmptyBodyDigestHash
+	// EmptyBodyDigestSkip don't create Digest header & don't sign it, verify digest of empty body if it's signed
+	EmptyBodyDigestSkip
+)
+
+// StreamedBodyDigest how Digest is created for streamed request bodies: unknown length & no GetBody
+// (e.g. io.Pipe), such body can be read once only
+type StreamedBodyDigest int
+
+const (
+	// StreamedBodyDigestBuffer read the body into memory (up to max body size) & replace it with the copy (default)
+	StreamedBodyDigestBuffer StreamedBodyDigest = iota
+	// StreamedBodyDigestTrailer compute digest while the body is sent & send it in the Digest trailer (chunked
+	// transfer encoding). Trailer can't be signed, digest is removed from signed headers.
+	StreamedBodyDigestTrailer
+	// StreamedBodyDigestSignedTrailer compute digest while the body is sent & send Digest & Signature trailers, so
+	// the signature covers the body hash (e.g. multipart.Writer uploads). The receiver verifies the signature after
+	// the body is read (VerifyRequests middleware with the same mode & default digest algorithm).
+	StreamedBodyDigestSignedTrailer
+)
+
 // Digest digest internal struct
 type Digest struct {
 	parsedDigestHeader DigestHeader
 	defaultAlg         string
 	alg                map[string]DigestHashAlgorithm
+	aliases            map[string]string
+	emptyBody          EmptyBodyDigest
+	decoded            bool
+	streamed           StreamedBodyDigest
+	maxBodySize        int64
+	spillThreshold     int64
+	spillDir           string
 }
 
 // NewDigest create new digest
@@ -42,6 +93,10 @@ func NewDigest() *Digest {
 		algSha256: Sha256{},
 		algSha512: Sha512{},
 	}
+	d.aliases = map[string]string{
+		"SHA256": algSha256,
+		"SHA512": algSha512,
+	}
 	return d
 }
 
@@ -52,55 +107,171 @@ func (d *Digest) SetDigestHashAlgorithm(a DigestHashAlgorithm) {
 
 // SetDefaultDigestHashAlgorithm set digest default algorithm options (default from available)
 func (d *Digest) SetDefaultDigestHashAlgorithm(a string) error {
-	_, ok := d.alg[strings.ToUpper(a)]
+	name, _, ok := d.lookup(a)
 	if !ok {
 		return &ErrDigest{
-			fmt.Sprintf("unsupported default digest hash algorithm '%s'", a),
-			nil,
+			Message: fmt.Sprintf("unsupported default digest hash algorithm '%s'", a),
+		}
+	}
+	d.defaultAlg = name
+	return nil
+}
+
+// SetEmptyBodyDigest set digest behaviour for requests without body (EmptyBodyDigestError by default)
+func (d *Digest) SetEmptyBodyDigest(m EmptyBodyDigest) {
+	d.emptyBody = m
+}
+
+// defaultMaxDecodedBodySize limit of decoded body if max body size isn't set (protection from decompression bombs)
+const defaultMaxDecodedBodySize = 64 << 20
+
+// SetDecodedBody compute digest over decoded body (by Content-Encoding: gzip, deflate) instead of raw bytes
+// (default) on both create & verify. Decoded body size is limited by max body size (64 MiB if it's not set).
+func (d *Digest) SetDecodedBody(v bool) {
+	d.decoded = v
+}
+
+// decodeBody decode body by Content-Encoding if digest is computed over decoded body
+func (d *Digest) decodeBody(header http.Header, b []byte) ([]byte, *ErrDigest) {
+	if !d.decoded || len(b) == 0 {
+		return b, nil
+	}
+	limit := d.maxBodySize
+	if limit <= 0 {
+		limit = defaultMaxDecodedBodySize
+	}
+	encodings := strings.Split(header.Get(contentEncodingHeader), ",")
+	// Encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var dec io.ReadCloser
+		var err error
+		switch e := strings.ToLower(strings.TrimSpace(encodings[i])); e {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			dec, err = gzip.NewReader(bytes.NewReader(b))
+		case "deflate":
+			dec, err = zlib.NewReader(bytes.NewReader(b))
+		default:
+			return nil, &ErrDigest{Message: fmt.Sprintf("unsupported content encoding '%s'", e)}
+		}
+		if err == nil {
+			b, err = ioutil.ReadAll(io.LimitReader(dec, limit+1))
+			_ = dec.Close()
+		}
+		if err != nil {
+			return nil, &ErrDigest{Message: "error decoding body", Err: err}
+		}
+		if int64(len(b)) > limit {
+			return nil, &ErrDigest{Message: fmt.Sprintf("decoded body is larger than %d bytes", limit)}
+		}
+	}
+	return b, nil
+}
+
+// SetStreamedBodyDigest set digest creation for streamed request bodies (StreamedBodyDigestBuffer by default).
+// maxBodySize limits bodies read into memory (streamed & server requests bodies), 0 — no limit.
+func (d *Digest) SetStreamedBodyDigest(m StreamedBodyDigest, maxBodySize int64) {
+	d.streamed = m
+	d.maxBodySize = maxBodySize
+}
+
+// SetMaxBodySize limit bodies buffered in memory to create or verify digest, 0 — no limit (default).
+// Larger bodies are rejected, the body stays readable.
+func (d *Digest) SetMaxBodySize(n int64) {
+	d.maxBodySize = n
+}
+
+// isStreamedBody check the body can be read once only
+func isStreamedBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.GetBody == nil && r.ContentLength <= 0
+}
+
+// emptyBodyError error for empty body, nil if empty body is allowed
+func (d *Digest) emptyBodyError() *ErrDigest {
+	if d.emptyBody != EmptyBodyDigestError {
+		return nil
+	}
+	return &ErrDigest{Message: "empty body"}
+}
+
+// SetDigestHashAlgorithmAlias register alias for digest hash algorithm, e.g. "SHA256" for "SHA-256".
+// Names are case-insensitive.
+func (d *Digest) SetDigestHashAlgorithmAlias(alias string, alg string) error {
+	if _, ok := d.alg[strings.ToUpper(alg)]; !ok {
+		return &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
 		}
 	}
-	d.defaultAlg = a
+	d.aliases[strings.ToUpper(alias)] = strings.ToUpper(alg)
 	return nil
 }
 
+// lookup find digest hash algorithm by name or alias, return registered (canonical) name
+func (d *Digest) lookup(alg string) (string, DigestHashAlgorithm, bool) {
+	name := strings.ToUpper(alg)
+	if h, ok := d.alg[name]; ok {
+		return name, h, true
+	}
+	name, ok := d.aliases[name]
+	if !ok {
+		return "", nil, false
+	}
+	h, ok := d.alg[name]
+	return name, h, ok
+}
+
 // Verify verify digest header (compare with real request body hash)
 func (d *Digest) Verify(r *http.Request) error {
+	return d.verify(r.Header, func() ([]byte, *ErrDigest) {
+		return d.readBody(r)
+	})
+}
+
+// verify verify digest header of the message with the body returned by readBody
+func (d *Digest) verify(header http.Header, readBody func() ([]byte, *ErrDigest)) error {
 	var err error
 	var pErr *ErrParser
 	var dErr *ErrDigest
 
-	header := r.Header.Get(digestHeader)
-	p := NewParser()
-	d.parsedDigestHeader, pErr = p.ParseDigestHeader(header)
+	p := getParser()
+	d.parsedDigestHeader, pErr = p.ParseDigestHeader(header.Get(digestHeader))
+	putParser(p)
 	if pErr != nil {
 		return pErr
 	}
 
-	h, ok := d.alg[strings.ToUpper(d.parsedDigestHeader.alg)]
+	_, h, ok := d.lookup(d.parsedDigestHeader.Alg)
 	if !ok {
 		return &ErrDigest{
-			fmt.Sprintf("unsupported digest hash algorithm '%s'", d.parsedDigestHeader.alg),
-			nil,
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", d.parsedDigestHeader.Alg),
+			kind:    ErrUnsupportedAlgorithm,
 		}
 	}
 
-	b, dErr := d.readBody(r)
+	b, dErr := readBody()
+	if dErr != nil {
+		return dErr
+	}
+	b, dErr = d.decodeBody(header, b)
 	if dErr != nil {
 		return dErr
 	}
 
-	digest, err := base64.StdEncoding.DecodeString(d.parsedDigestHeader.digest)
+	digest, err := base64.StdEncoding.DecodeString(d.parsedDigestHeader.Digest)
 	if err != nil {
 		return &ErrDigest{
-			"error decode digest from base64",
-			err,
+			Message: "error decode digest from base64",
+			Err:     err,
 		}
 	}
 	err = h.Verify(b, digest)
 	if err != nil {
 		return &ErrDigest{
-			"wrong digest",
-			err,
+			Message: "wrong digest",
+			Err:     err,
+			kind:    ErrDigestMismatch,
 		}
 	}
 
@@ -110,11 +281,22 @@ func (d *Digest) Verify(r *http.Request) error {
 // Create create digest hash
 func (d *Digest) Create(alg string, r *http.Request) (string, error) {
 	// Does it support digest algorithm
-	h, ok := d.alg[strings.ToUpper(alg)]
-	if !ok {
+	if _, _, ok := d.lookup(alg); !ok {
 		return "", &ErrDigest{
-			fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
-			nil,
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+
+	// Digest of streamed body is sent in trailer
+	if d.streamed == StreamedBodyDigestTrailer && isStreamedBody(r) {
+		return "", d.setTrailer(alg, r, nil)
+	}
+	// Large streamed body is spilled to temporary file
+	if d.spillThreshold > 0 && d.streamed == StreamedBodyDigestBuffer && isStreamedBody(r) {
+		digest, spilled, err := d.createSpilled(alg, r)
+		if spilled {
+			return digest, err
 		}
 	}
 
@@ -123,34 +305,340 @@ func (d *Digest) Create(alg string, r *http.Request) (string, error) {
 	if dErr != nil {
 		return "", dErr
 	}
+	return d.createBody(alg, r.Header, b)
+}
+
+// createBody create digest header value for the message body, decoded by Content-Encoding if required
+func (d *Digest) createBody(alg string, header http.Header, b []byte) (string, error) {
+	if len(b) == 0 && d.emptyBody == EmptyBodyDigestSkip {
+		return "", nil
+	}
+	b, dErr := d.decodeBody(header, b)
+	if dErr != nil {
+		return "", dErr
+	}
+
+	return d.create(alg, b)
+}
+
+// create create digest header value for passed data
+func (d *Digest) create(alg string, b []byte) (string, error) {
+	name, h, ok := d.lookup(alg)
+	if !ok {
+		return "", &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
 
 	// Creat hash
 	hash, err := h.Create(b)
 	if err != nil {
 		return "", &ErrDigest{
-			fmt.Sprintf("error creating digest hash '%s'", alg),
-			err,
+			Message: fmt.Sprintf("error creating digest hash '%s'", alg),
+			Err:     err,
 		}
 	}
 
-	return strings.ToUpper(alg) + "=" + base64.StdEncoding.EncodeToString(hash), nil
+	return name + "=" + base64.StdEncoding.EncodeToString(hash), nil
 }
 
 func (d *Digest) readBody(r *http.Request) ([]byte, *ErrDigest) {
-	if r.ContentLength == 0 {
-		return nil, &ErrDigest{"empty body", nil}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, d.emptyBodyError()
+	}
+
+	// Prefer GetBody: it returns a fresh copy and leaves r.Body untouched, so the request stays sendable
+	if r.GetBody != nil {
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, &ErrDigest{Message: "error getting body", Err: err}
+		}
+		body, _, dErr := d.bufferBody(rc)
+		if dErr != nil {
+			return nil, dErr
+		}
+		if len(body) == 0 {
+			return nil, d.emptyBodyError()
+		}
+		return body, nil
+	}
+
+	body, rc, dErr := d.bufferBody(r.Body)
+	r.Body = rc
+	if dErr != nil {
+		return nil, dErr
+	}
+	d.resetBody(r, body)
+
+	if len(body) == 0 {
+		return nil, d.emptyBodyError()
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
+	return body, nil
+}
+
+// bufferBody read body (up to max body size) into memory. The body is consumed, so the returned replacement
+// must be used instead of it in any case: in-memory copy of the read body, or the read part followed by the rest of
+// the original body on error.
+func (d *Digest) bufferBody(body io.ReadCloser) ([]byte, io.ReadCloser, *ErrDigest) {
+	var rd io.Reader = body
+	if d.maxBodySize > 0 {
+		rd = io.LimitReader(body, d.maxBodySize+1)
+	}
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		rest := readCloser{Reader: io.MultiReader(bytes.NewReader(b), body), Closer: body}
+		return nil, rest, &ErrDigest{Message: "error reading body", Err: err}
+	}
+	if d.maxBodySize > 0 && int64(len(b)) > d.maxBodySize {
+		rest := readCloser{Reader: io.MultiReader(bytes.NewReader(b), body), Closer: body}
+		return nil, rest, &ErrDigest{Message: fmt.Sprintf("body is larger than %d bytes", d.maxBodySize)}
+	}
+	rest := ioutil.NopCloser(bytes.NewReader(b))
+	if err := body.Close(); err != nil {
+		return nil, rest, &ErrDigest{Message: "error closing body", Err: err}
+	}
+	return b, rest, nil
+}
+
+// resetBody replace consumed body with in-memory copy & set GetBody, so the request could be sent or retried
+func (d *Digest) resetBody(r *http.Request, body []byte) {
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// isIdentityEncoding check body is not encoded
+func isIdentityEncoding(encoding string) bool {
+	e := strings.TrimSpace(encoding)
+	return len(e) == 0 || strings.EqualFold(e, "identity")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// setTrailer wrap request body with reader which sets Digest trailer at the end of the body & calls done with
+// the digest (if not nil)
+func (d *Digest) setTrailer(alg string, r *http.Request, done func(digest string) error) error {
+	name, h, ok := d.lookup(alg)
+	if !ok {
+		return &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	if d.decoded && !isIdentityEncoding(r.Header.Get(contentEncodingHeader)) {
+		return &ErrDigest{Message: "digest of decoded body is not supported for trailer"}
+	}
+	if r.Trailer == nil {
+		r.Trailer = http.Header{}
+	}
+	// Trailer keys must be declared before the body is sent
+	r.Trailer[digestHeader] = nil
+
+	w, sum := newHashWriter(h)
+	r.Body = &trailerReader{
+		body: r.Body,
+		r:    io.TeeReader(r.Body, w),
+		done: func() error {
+			hash, err := sum()
+			if err != nil {
+				return &ErrDigest{Message: "error creating digest", Err: err}
+			}
+			digest := name + "=" + base64.StdEncoding.EncodeToString(hash)
+			r.Trailer.Set(digestHeader, digest)
+			if done != nil {
+				return done(digest)
+			}
+			return nil
+		},
+	}
+	r.ContentLength = -1
+	return nil
+}
+
+// newHashWriter writer which hashes data written to it & func which returns the hash
+func newHashWriter(h DigestHashAlgorithm) (io.Writer, func() ([]byte, error)) {
+	if hp, ok := h.(digestHashPool); ok {
+		pool := hp.hashPool()
+		hash := pool.get()
+		return hash, func() ([]byte, error) {
+			defer pool.put(hash)
+			return hash.Sum(nil), nil
+		}
+	}
+	// Custom algorithms hash the whole body
+	buf := new(bytes.Buffer)
+	return buf, func() ([]byte, error) {
+		return h.Create(buf.Bytes())
+	}
+}
+
+// trailerReader body reader which calls done on EOF (to set or verify trailers)
+type trailerReader struct {
+	body io.ReadCloser
+	r    io.Reader
+	done func() error
+	err  error
+}
+
+// Read read body, call done on EOF
+func (tr *trailerReader) Read(p []byte) (int, error) {
+	if tr.err != nil {
+		return 0, tr.err
+	}
+	n, err := tr.r.Read(p)
+	if err == io.EOF && tr.done != nil {
+		if dErr := tr.done(); dErr != nil {
+			tr.err = dErr
+			return n, dErr
+		}
+		tr.done = nil
+	}
+	return n, err
+}
+
+// Close close the body
+func (tr *trailerReader) Close() error {
+	return tr.body.Close()
+}
+
+// digestHashPool optional DigestHashAlgorithm interface to hash the body while it's read
+type digestHashPool interface {
+	hashPool() *hashPool
+}
+
+// newVerifyReader wrap request body with reader which computes digest while the body is read & compares it
+// on EOF. Read returns ErrDigest if digest is wrong, so the body must be read to the end.
+func (d *Digest) newVerifyReader(r *http.Request) (io.ReadCloser, error) {
+	p := getParser()
+	dh, pErr := p.ParseDigestHeader(r.Header.Get(digestHeader))
+	putParser(p)
+	if pErr != nil {
+		return nil, pErr
+	}
+
+	_, h, ok := d.lookup(dh.Alg)
+	if !ok {
+		return nil, &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", dh.Alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	digest, err := base64.StdEncoding.DecodeString(dh.Digest)
 	if err != nil {
-		return nil, &ErrDigest{"error reading body", err}
+		return nil, &ErrDigest{
+			Message: "error decode digest from base64",
+			Err:     err,
+		}
+	}
+	body := r.Body
+	if body == nil || body == http.NoBody {
+		if err := d.emptyBodyError(); err != nil {
+			return nil, err
+		}
+		body = http.NoBody
+	}
+
+	dr := &digestReader{body: body, alg: h, digest: digest, allowEmpty: d.emptyBody != EmptyBodyDigestError}
+	if d.decoded && !isIdentityEncoding(r.Header.Get(contentEncodingHeader)) {
+		// Encoded body is decoded on EOF
+		dr.buf = new(bytes.Buffer)
+		dr.r = io.TeeReader(body, dr.buf)
+		dr.decode = func(b []byte) ([]byte, *ErrDigest) {
+			return d.decodeBody(r.Header, b)
+		}
+	} else if hp, ok := h.(digestHashPool); ok {
+		dr.pool = hp.hashPool()
+		dr.hash = dr.pool.get()
+		dr.r = io.TeeReader(body, dr.hash)
+	} else {
+		// Custom algorithms verify the whole body
+		dr.buf = new(bytes.Buffer)
+		dr.r = io.TeeReader(body, dr.buf)
 	}
+	return dr, nil
+}
+
+// digestReader body reader which verifies digest on EOF
+type digestReader struct {
+	body       io.ReadCloser
+	r          io.Reader
+	alg        DigestHashAlgorithm
+	digest     []byte
+	pool       *hashPool
+	hash       hash.Hash
+	buf        *bytes.Buffer
+	n          int64
+	err        error
+	allowEmpty bool
+	decode     func(b []byte) ([]byte, *ErrDigest)
+}
 
-	err = r.Body.Close()
+// Read read body, on EOF return ErrDigest if digest is wrong
+func (dr *digestReader) Read(p []byte) (int, error) {
+	if dr.err != nil {
+		return 0, dr.err
+	}
+	n, err := dr.r.Read(p)
+	dr.n += int64(n)
+	if err == io.EOF {
+		dr.err = dr.verify()
+		if dr.err != nil {
+			return n, dr.err
+		}
+		dr.err = io.EOF
+	}
+	return n, err
+}
+
+// Close close the body
+func (dr *digestReader) Close() error {
+	dr.release()
+	if dr.err == nil {
+		dr.err = &ErrDigest{Message: "body is closed before digest verification"}
+	}
+	return dr.body.Close()
+}
+
+func (dr *digestReader) verify() error {
+	defer dr.release()
+	if dr.n == 0 && !dr.allowEmpty {
+		return &ErrDigest{Message: "empty body"}
+	}
+	var err error
+	if dr.hash != nil {
+		if subtle.ConstantTimeCompare(dr.hash.Sum(nil), dr.digest) != 1 {
+			err = &ErrCrypto{Message: "wrong hash"}
+		}
+	} else {
+		b := dr.buf.Bytes()
+		if dr.decode != nil {
+			var dErr *ErrDigest
+			if b, dErr = dr.decode(b); dErr != nil {
+				return dErr
+			}
+		}
+		err = dr.alg.Verify(b, dr.digest)
+	}
 	if err != nil {
-		return nil, &ErrDigest{"error closing body", err}
+		return &ErrDigest{
+			Message: "wrong digest",
+			Err:     err,
+			kind:    ErrDigestMismatch,
+		}
 	}
-	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	return nil
+}
 
-	return body, nil
+// release return hash to the pool
+func (dr *digestReader) release() {
+	if dr.hash != nil {
+		dr.pool.put(dr.hash)
+		dr.hash = nil
+	}
 }
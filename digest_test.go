@@ -1,7 +1,17 @@
 package httpsignatures
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -69,7 +79,7 @@ func TestVerifyDigest(t *testing.T) {
 			},
 			want:        false,
 			wantErrType: testErrParserType,
-			wantErrMsg:  "ErrParser: empty digest value",
+			wantErrMsg:  "ErrParser: empty digest value at position 8",
 		},
 		{
 			name: "Unsupported digest hash algorithm",
@@ -205,3 +215,528 @@ func TestDigestSetDigestDefaultHashAlgorithm(t *testing.T) {
 		})
 	}
 }
+
+func TestDigestSetDigestHashAlgorithmAlias(t *testing.T) {
+	type args struct {
+		alias string
+		alg   string
+		use   string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        string
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Default alias",
+			args: args{
+				use: "sha256",
+			},
+			want: "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=",
+		},
+		{
+			name: "Lower case name",
+			args: args{
+				use: "sha-256",
+			},
+			want: "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=",
+		},
+		{
+			name: "Registered alias",
+			args: args{
+				alias: "sha_256",
+				alg:   "sha-256",
+				use:   "SHA_256",
+			},
+			want: "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=",
+		},
+		{
+			name: "Alias for unsupported algorithm",
+			args: args{
+				alias: "sha_256",
+				alg:   "sha256",
+				use:   "SHA-256",
+			},
+			want:        "",
+			wantErrType: testErrDigestType,
+			wantErrMsg:  "ErrDigest: unsupported digest hash algorithm 'sha256'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			if len(tt.args.alias) > 0 {
+				if err := d.SetDigestHashAlgorithmAlias(tt.args.alias, tt.args.alg); err != nil {
+					assert(t, "", err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+					return
+				}
+			}
+			got, err := d.Create(tt.args.use, testGetDigestRequestFunc(testBodyExample, ""))
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+			r := testGetDigestRequestFunc(testBodyExample, strings.Replace(got, algSha256, tt.args.use, 1))
+			if err := d.Verify(r); err != nil {
+				t.Errorf("%s: verify error = %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestDigestReadBody(t *testing.T) {
+	type args struct {
+		r *http.Request
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []byte
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "bytes.Reader body (GetBody)",
+			args: args{
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, testFullHostExample, bytes.NewReader([]byte(testBodyExample)))
+					return r
+				})(),
+			},
+			want: []byte(testBodyExample),
+		},
+		{
+			name: "io.Pipe body (no GetBody)",
+			args: args{
+				r: (func() *http.Request {
+					pr, pw := io.Pipe()
+					go func() {
+						_, _ = pw.Write([]byte(testBodyExample))
+						_ = pw.Close()
+					}()
+					r, _ := http.NewRequest(http.MethodPost, testFullHostExample, pr)
+					return r
+				})(),
+			},
+			want: []byte(testBodyExample),
+		},
+		{
+			name: "GetBody error",
+			args: args{
+				r: (func() *http.Request {
+					r := testGetDigestRequestFunc(testBodyExample, "")
+					r.GetBody = func() (io.ReadCloser, error) {
+						return nil, errors.New("get body error")
+					}
+					return r
+				})(),
+			},
+			want:        nil,
+			wantErrType: testErrDigestType,
+			wantErrMsg:  "ErrDigest: error getting body: get body error",
+		},
+		{
+			name: "Empty io.Pipe body",
+			args: args{
+				r: (func() *http.Request {
+					pr, pw := io.Pipe()
+					_ = pw.Close()
+					r, _ := http.NewRequest(http.MethodPost, testFullHostExample, pr)
+					return r
+				})(),
+			},
+			want:        nil,
+			wantErrType: testErrDigestType,
+			wantErrMsg:  "ErrDigest: empty body",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			got, err := d.readBody(tt.args.r)
+			if err != nil {
+				assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+				return
+			}
+			assert(t, got, nil, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+			// Body must stay readable & replayable after digest calculation
+			b, _ := ioutil.ReadAll(tt.args.r.Body)
+			if !bytes.Equal(b, tt.want) {
+				t.Errorf(tt.name+"\nbody after read = %s, want %s", b, tt.want)
+			}
+			if tt.args.r.GetBody == nil {
+				t.Errorf(tt.name + "\nGetBody is not set")
+				return
+			}
+			rc, _ := tt.args.r.GetBody()
+			b, _ = ioutil.ReadAll(rc)
+			if !bytes.Equal(b, tt.want) {
+				t.Errorf(tt.name+"\nGetBody = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestEmptyBody(t *testing.T) {
+	const emptyDigest = "SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	tests := []struct {
+		name       string
+		mode       EmptyBodyDigest
+		wantCreate string
+		wantErrMsg string
+	}{
+		{
+			name:       "Error",
+			mode:       EmptyBodyDigestError,
+			wantErrMsg: "ErrDigest: empty body",
+		},
+		{
+			name:       "Hash",
+			mode:       EmptyBodyDigestHash,
+			wantCreate: emptyDigest,
+		},
+		{
+			name: "Skip",
+			mode: EmptyBodyDigestSkip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			d.SetEmptyBodyDigest(tt.mode)
+			r, _ := http.NewRequest(http.MethodGet, testFullHostExample, nil)
+			got, err := d.Create(algSha256, r)
+			assert(t, got, err, testErrDigestType, tt.name, tt.wantCreate, tt.wantErrMsg)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+
+			// Signed digest of empty body is verified in all modes except Error
+			for _, digest := range []string{emptyDigest, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="} {
+				r.Header.Set(digestHeader, digest)
+				wantErr := tt.wantErrMsg
+				if len(wantErr) == 0 && digest != emptyDigest {
+					wantErr = "ErrDigest: wrong digest: ErrCrypto: wrong hash"
+				}
+				err = d.Verify(r)
+				if err == nil && len(wantErr) > 0 {
+					t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", wantErr)
+				}
+				assert(t, nil, err, testErrDigestType, tt.name, nil, wantErr)
+
+				body, err := d.newVerifyReader(r)
+				if err == nil {
+					_, err = ioutil.ReadAll(body)
+				}
+				if err == nil && len(wantErr) > 0 {
+					t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", wantErr)
+				}
+				assert(t, nil, err, testErrDigestType, tt.name, nil, wantErr)
+			}
+		})
+	}
+}
+
+func TestSignEmptyBodyDigestSkip(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "digest"})
+	hs.SetEmptyBodyDigest(EmptyBodyDigestSkip)
+	r, _ := http.NewRequest(http.MethodGet, testFullHostExample, nil)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Header.Get(digestHeader) != "" || !strings.Contains(r.Header.Get(signatureHeader), `headers="(request-target)"`) {
+		t.Errorf("digest is signed: %s", r.Header.Get(signatureHeader))
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestDigestDecodedBody(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(testBodyExample))
+	_ = zw.Close()
+	var zl bytes.Buffer
+	zlw := zlib.NewWriter(&zl)
+	_, _ = zlw.Write([]byte(testBodyExample))
+	_ = zlw.Close()
+
+	plain, _ := NewDigest().create(algSha256, []byte(testBodyExample))
+	raw, _ := NewDigest().create(algSha256, gz.Bytes())
+	tests := []struct {
+		name       string
+		decoded    bool
+		encoding   string
+		body       []byte
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name:     "Raw gzip body",
+			encoding: "gzip",
+			body:     gz.Bytes(),
+			want:     raw,
+		},
+		{
+			name:     "Decoded gzip body",
+			decoded:  true,
+			encoding: "gzip",
+			body:     gz.Bytes(),
+			want:     plain,
+		},
+		{
+			name:     "Decoded deflate body",
+			decoded:  true,
+			encoding: "identity, deflate",
+			body:     zl.Bytes(),
+			want:     plain,
+		},
+		{
+			name:    "Decoded not encoded body",
+			decoded: true,
+			body:    []byte(testBodyExample),
+			want:    plain,
+		},
+		{
+			name:       "Unsupported encoding",
+			decoded:    true,
+			encoding:   "br",
+			body:       gz.Bytes(),
+			wantErrMsg: "ErrDigest: unsupported content encoding 'br'",
+		},
+		{
+			name:       "Wrong gzip body",
+			decoded:    true,
+			encoding:   "gzip",
+			body:       []byte(testBodyExample),
+			wantErrMsg: "ErrDigest: error decoding body: gzip: invalid header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			d.SetDecodedBody(tt.decoded)
+			r, _ := http.NewRequest(http.MethodPost, testFullHostExample, bytes.NewReader(tt.body))
+			r.Header.Set(contentEncodingHeader, tt.encoding)
+			got, err := d.Create(algSha256, r)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, got, err, testErrDigestType, tt.name, tt.want, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+
+			r.Header.Set(digestHeader, got)
+			if err = d.Verify(r); err != nil {
+				t.Errorf("Verify: unexpected error: %s", err)
+			}
+			body, err := d.newVerifyReader(r)
+			if err == nil {
+				_, err = ioutil.ReadAll(body)
+			}
+			if err != nil {
+				t.Errorf("newVerifyReader: unexpected error: %s", err)
+			}
+
+			// Digest over other body representation is wrong
+			other := raw
+			if got == raw {
+				other = plain
+			}
+			if tt.encoding != "" {
+				r.Header.Set(digestHeader, other)
+				if err = d.Verify(r); !errors.Is(err, ErrDigestMismatch) {
+					t.Errorf("got error %v, want ErrDigestMismatch", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDigestDecodedBodyBomb(t *testing.T) {
+	// 1 MiB of zeros is compressed to ~1 KiB
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(make([]byte, 1<<20))
+	_ = zw.Close()
+
+	d := NewDigest()
+	d.SetDecodedBody(true)
+	d.SetMaxBodySize(64 << 10)
+	r, _ := http.NewRequest(http.MethodPost, testFullHostExample, bytes.NewReader(gz.Bytes()))
+	r.Header.Set(contentEncodingHeader, "gzip")
+	wantErrMsg := "ErrDigest: decoded body is larger than 65536 bytes"
+	_, err := d.Create(algSha256, r)
+	if err == nil {
+		t.Fatal("Create() error = nil for decompression bomb")
+	}
+	assert(t, nil, err, testErrDigestType, "Create", nil, wantErrMsg)
+
+	r.Header.Set(digestHeader, "SHA-256=RK/0qy18MlBSVnWgjwz6lZEWjP/lF5HF9bvEF8FabDg=")
+	err = d.Verify(r)
+	if err == nil {
+		t.Fatal("Verify() error = nil for decompression bomb")
+	}
+	assert(t, nil, err, testErrDigestType, "Verify", nil, wantErrMsg)
+}
+
+func testPipeRequest(body string) *http.Request {
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte(body))
+		_ = pw.Close()
+	}()
+	r, _ := http.NewRequest(http.MethodPost, testFullHostExample, pr)
+	return r
+}
+
+func TestDigestStreamedBodyBuffer(t *testing.T) {
+	d := NewDigest()
+	d.SetStreamedBodyDigest(StreamedBodyDigestBuffer, int64(len(testBodyExample)))
+	r := testPipeRequest(testBodyExample)
+	if _, err := d.Create(algSha256, r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	d.SetStreamedBodyDigest(StreamedBodyDigestBuffer, int64(len(testBodyExample)-1))
+	r = testPipeRequest(testBodyExample)
+	_, err := d.Create(algSha256, r)
+	assert(t, nil, err, testErrDigestType, "Body size limit", nil,
+		fmt.Sprintf("ErrDigest: body is larger than %d bytes", len(testBodyExample)-1))
+	if err == nil {
+		t.Errorf("no error, want body size limit error")
+	}
+	// Body is still readable
+	b, _ := ioutil.ReadAll(r.Body)
+	if string(b) != testBodyExample {
+		t.Errorf("body after read = %s, want %s", b, testBodyExample)
+	}
+}
+
+func TestDigestStreamedBodyTrailer(t *testing.T) {
+	want, _ := NewDigest().create(algSha256, []byte(testBodyExample))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != testBodyExample || r.Trailer.Get(digestHeader) != want {
+			t.Errorf("got body %s & trailer %s, want %s", b, r.Trailer.Get(digestHeader), want)
+		}
+		if r.Header.Get(signatureHeader) == "" {
+			t.Errorf("request is not signed")
+		}
+	}))
+	defer srv.Close()
+
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "digest"})
+	_ = hs.SetDefaultDigestAlgorithm(algSha256)
+	hs.SetStreamedBodyDigest(StreamedBodyDigestTrailer, 0)
+	r := testPipeRequest(testBodyExample)
+	r.URL, _ = url.Parse(srv.URL)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(r.Header.Get(signatureHeader), `headers="(request-target)"`) {
+		t.Errorf("digest is signed: %s", r.Header.Get(signatureHeader))
+	}
+	resp, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	// Requests with known body are not affected
+	r, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(testBodyExample))
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Header.Get(digestHeader) != want || r.Trailer != nil {
+		t.Errorf("got digest header %s, want %s", r.Header.Get(digestHeader), want)
+	}
+}
+
+func BenchmarkDigestCreate(b *testing.B) {
+	body := []byte(strings.Repeat(testBodyExample, 100))
+	for _, alg := range []string{algMd5, algSha256, algSha512} {
+		b.Run(alg, func(b *testing.B) {
+			d := NewDigest()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := d.create(alg, body); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// testFailingReader return error after the body is read
+type testFailingReader struct {
+	r io.Reader
+}
+
+func (f testFailingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestBodyRestored(t *testing.T) {
+	const digest = "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="
+	body := `{"hello": "world"}`
+	tests := []struct {
+		name       string
+		body       io.Reader
+		digest     string
+		maxSize    int64
+		wantBody   string
+		wantErrMsg string
+	}{
+		{name: "Verified", body: strings.NewReader(body), digest: digest, wantBody: body},
+		{
+			name:       "Wrong digest",
+			body:       strings.NewReader(body),
+			digest:     "SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+			wantBody:   body,
+			wantErrMsg: "ErrDigest: wrong digest: ErrCrypto: wrong hash",
+		},
+		{
+			name:       "Too large",
+			body:       strings.NewReader(body),
+			digest:     digest,
+			maxSize:    10,
+			wantBody:   body,
+			wantErrMsg: "ErrDigest: body is larger than 10 bytes",
+		},
+		{
+			name:       "Read error",
+			body:       testFailingReader{r: strings.NewReader(body)},
+			digest:     digest,
+			wantBody:   body,
+			wantErrMsg: "ErrDigest: error reading body: connection reset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDigest()
+			d.SetMaxBodySize(tt.maxSize)
+			r := httptest.NewRequest(http.MethodPost, testHostExamplePath, tt.body)
+			r.Header.Set(digestHeader, tt.digest)
+			err := d.Verify(r)
+			assert(t, nil, err, testErrDigestType, tt.name, nil, tt.wantErrMsg)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Errorf("no error, want %s", tt.wantErrMsg)
+			}
+			got, _ := ioutil.ReadAll(r.Body)
+			if string(got) != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
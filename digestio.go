@@ -0,0 +1,94 @@
+package httpsignatures
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// CreateFromBytes create digest header value (e.g. "SHA-256=...") of the payload, e.g. to precompute it in
+// a job queue
+func (d *Digest) CreateFromBytes(alg string, b []byte) (string, error) {
+	return d.create(alg, b)
+}
+
+// CreateFromReader create digest header value of the payload read to the end. Payload is hashed while it's read,
+// without buffering (except custom algorithms).
+func (d *Digest) CreateFromReader(alg string, r io.Reader) (string, error) {
+	name, h, ok := d.lookup(alg)
+	if !ok {
+		return "", &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	hash, err := d.hashReader(h, r)
+	if err != nil {
+		return "", err
+	}
+	return name + "=" + base64.StdEncoding.EncodeToString(hash), nil
+}
+
+// VerifyFromBytes verify digest header value (e.g. "SHA-256=...") of the payload
+func (d *Digest) VerifyFromBytes(digest string, b []byte) error {
+	h, sum, err := d.parseDigest(digest)
+	if err != nil {
+		return err
+	}
+	if err := h.Verify(b, sum); err != nil {
+		return &ErrDigest{Message: "wrong digest", Err: err, kind: ErrDigestMismatch}
+	}
+	return nil
+}
+
+// VerifyFromReader verify digest header value of the payload read to the end
+func (d *Digest) VerifyFromReader(digest string, r io.Reader) error {
+	h, sum, err := d.parseDigest(digest)
+	if err != nil {
+		return err
+	}
+	hash, err := d.hashReader(h, r)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(hash, sum) != 1 {
+		return &ErrDigest{Message: "wrong digest", Err: &ErrCrypto{Message: "wrong hash"}, kind: ErrDigestMismatch}
+	}
+	return nil
+}
+
+// parseDigest parse digest header value & return supported algorithm & decoded hash
+func (d *Digest) parseDigest(digest string) (DigestHashAlgorithm, []byte, error) {
+	p := getParser()
+	dh, pErr := p.ParseDigestHeader(digest)
+	putParser(p)
+	if pErr != nil {
+		return nil, nil, pErr
+	}
+	_, h, ok := d.lookup(dh.Alg)
+	if !ok {
+		return nil, nil, &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", dh.Alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	sum, err := base64.StdEncoding.DecodeString(dh.Digest)
+	if err != nil {
+		return nil, nil, &ErrDigest{Message: "error decode digest from base64", Err: err}
+	}
+	return h, sum, nil
+}
+
+// hashReader hash payload read to the end
+func (d *Digest) hashReader(h DigestHashAlgorithm, r io.Reader) ([]byte, error) {
+	w, sum := newHashWriter(h)
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, &ErrDigest{Message: "error reading body", Err: err}
+	}
+	hash, err := sum()
+	if err != nil {
+		return nil, &ErrDigest{Message: "error creating digest", Err: err}
+	}
+	return hash, nil
+}
@@ -0,0 +1,65 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDigestFromBytesReader(t *testing.T) {
+	const (
+		body   = `{"hello": "world"}`
+		sha256 = "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="
+	)
+	tests := []struct {
+		name       string
+		alg        string
+		want       string
+		wantErrMsg string
+	}{
+		{name: "SHA-256", alg: algSha256, want: sha256},
+		{name: "Alias", alg: "sha-256", want: sha256},
+		{
+			name:       "Unsupported",
+			alg:        "SHA-1",
+			wantErrMsg: "ErrDigest: unsupported digest hash algorithm 'SHA-1'",
+		},
+	}
+	d := NewDigest()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.CreateFromBytes(tt.alg, []byte(body))
+			assert(t, got, err, testErrDigestType, tt.name, tt.want, tt.wantErrMsg)
+			got, err = d.CreateFromReader(tt.alg, bytes.NewReader([]byte(body)))
+			assert(t, got, err, testErrDigestType, tt.name, tt.want, tt.wantErrMsg)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Errorf("no error, want %s", tt.wantErrMsg)
+			}
+		})
+	}
+
+	verifyTests := []struct {
+		name    string
+		digest  string
+		body    string
+		wantErr error
+	}{
+		{name: "Valid", digest: sha256, body: body},
+		{name: "Wrong digest", digest: sha256, body: "other", wantErr: ErrDigestMismatch},
+		{name: "Unsupported", digest: "SHA-1=e30=", body: body, wantErr: ErrUnsupportedAlgorithm},
+	}
+	for _, tt := range verifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.VerifyFromBytes(tt.digest, []byte(tt.body)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("bytes: got error %v, want %v", err, tt.wantErr)
+			}
+			if err := d.VerifyFromReader(tt.digest, bytes.NewReader([]byte(tt.body))); !errors.Is(err, tt.wantErr) {
+				t.Errorf("reader: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := d.VerifyFromReader(sha256, testFailingReader{r: bytes.NewReader([]byte(body))}); err == nil {
+		t.Error("no error, want read error")
+	}
+}
@@ -1,9 +1,5 @@
 package httpsignatures
 
-import (
-	"crypto/sha256"
-)
-
 const algEcdsaSha256 = "ECDSA-SHA256"
 
 // EcdsaSha256 ECDSA with SHA256 Algorithm
@@ -16,10 +12,10 @@ func (a EcdsaSha256) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a EcdsaSha256) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureEcdsaAlgorithmCreate(algEcdsaSha256, sha256.New, secret, data)
+	return signatureEcdsaAlgorithmCreate(algEcdsaSha256, sha256Pool, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a EcdsaSha256) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureEcdsaAlgorithmVerify(algEcdsaSha256, sha256.New, secret, data, signature)
+	return signatureEcdsaAlgorithmVerify(algEcdsaSha256, sha256Pool, secret, data, signature)
 }
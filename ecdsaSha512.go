@@ -1,9 +1,5 @@
 package httpsignatures
 
-import (
-	"crypto/sha512"
-)
-
 const algEcdsaSha512 = "ECDSA-SHA512"
 
 // EcdsaSha512 ECDSA with SHA512 Algorithm
@@ -16,10 +12,10 @@ func (a EcdsaSha512) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a EcdsaSha512) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureEcdsaAlgorithmCreate(algEcdsaSha512, sha512.New, secret, data)
+	return signatureEcdsaAlgorithmCreate(algEcdsaSha512, sha512Pool, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a EcdsaSha512) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureEcdsaAlgorithmVerify(algEcdsaSha512, sha512.New, secret, data, signature)
+	return signatureEcdsaAlgorithmVerify(algEcdsaSha512, sha512Pool, secret, data, signature)
 }
@@ -18,46 +18,62 @@ func (a ED25519) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a ED25519) Create(secret Secret, data []byte) ([]byte, error) {
-	block, _ := pem.Decode([]byte(secret.PrivateKey))
+	privateKey, err := ed25519PrivKeys.load(secret.PrivateKey, loadED25519PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return ed25519.Sign(privateKey.(ed25519.PrivateKey), data), nil
+}
+
+// Verify Verify signature using passed publicKey from secret
+func (a ED25519) Verify(secret Secret, data []byte, signature []byte) error {
+	publicKey, err := ed25519PublicKeys.load(secret.PublicKey, loadED25519PublicKey)
+	if err != nil {
+		return err
+	}
+
+	res := ed25519.Verify(publicKey.(ed25519.PublicKey), data, signature)
+	if !res {
+		return &ErrCrypto{Message: "signature verification error"}
+	}
+	return nil
+}
+
+func loadED25519PrivateKey(pk string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(pk))
 	if block == nil {
-		return nil, &ErrCrypto{"no private key found", nil}
+		return nil, &ErrCrypto{Message: "no private key found"}
 	}
 
 	var asn1PrivateKey ED25519PrivateKey
 	_, err := asn1.Unmarshal(block.Bytes, &asn1PrivateKey)
 	if err != nil {
-		return nil, &ErrCrypto{"error unmarshal private key", err}
+		return nil, &ErrCrypto{Message: "error unmarshal private key", Err: err}
 	}
 
 	privateKey := ed25519.NewKeyFromSeed(asn1PrivateKey.PrivateKey[2:])
 	if len(privateKey) != ed25519.PrivateKeySize {
-		return nil, &ErrCrypto{"invalid private key size", nil}
+		return nil, &ErrCrypto{Message: "invalid private key size"}
 	}
-
-	return ed25519.Sign(privateKey, data), nil
+	return privateKey, nil
 }
 
-// Verify Verify signature using passed publicKey from secret
-func (a ED25519) Verify(secret Secret, data []byte, signature []byte) error {
-	block, _ := pem.Decode([]byte(secret.PublicKey))
+func loadED25519PublicKey(pk string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(pk))
 	if block == nil {
-		return &ErrCrypto{"no public key found", nil}
+		return nil, &ErrCrypto{Message: "no public key found"}
 	}
 
 	var asn1PublicKey ED25519PublicKey
 	_, err := asn1.Unmarshal(block.Bytes, &asn1PublicKey)
 	if err != nil {
-		return &ErrCrypto{"error unmarshal public key", err}
+		return nil, &ErrCrypto{Message: "error unmarshal public key", Err: err}
 	}
 
 	publicKey := ed25519.PublicKey(asn1PublicKey.PublicKey.Bytes)
 	if len(publicKey) != ed25519.PublicKeySize {
-		return &ErrCrypto{"invalid public key size", nil}
+		return nil, &ErrCrypto{Message: "invalid public key size"}
 	}
-
-	res := ed25519.Verify(publicKey, data, signature)
-	if !res {
-		return &ErrCrypto{"signature verification error", nil}
-	}
-	return nil
+	return publicKey, nil
 }
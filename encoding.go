@@ -0,0 +1,59 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// SignatureEncoding encoding of the signature param value
+type SignatureEncoding int
+
+const (
+	// SignatureEncodingBase64 standard base64 with padding (default)
+	SignatureEncodingBase64 SignatureEncoding = iota
+	// SignatureEncodingBase64URL URL-safe base64, created without padding, decoded with or without it
+	SignatureEncodingBase64URL
+	// SignatureEncodingHex hex, created in lower case, decoded in any case
+	SignatureEncodingHex
+	// SignatureEncodingMultibase multibase (DID & data integrity ecosystems), created as base58btc ("z" prefix),
+	// decoded from base58btc, base64, base64url or hex
+	SignatureEncodingMultibase
+)
+
+// String encoding name
+func (e SignatureEncoding) String() string {
+	switch e {
+	case SignatureEncodingBase64URL:
+		return "base64url"
+	case SignatureEncodingHex:
+		return "hex"
+	case SignatureEncodingMultibase:
+		return "multibase"
+	}
+	return "base64"
+}
+
+func (e SignatureEncoding) encode(b []byte) string {
+	switch e {
+	case SignatureEncodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(b)
+	case SignatureEncodingHex:
+		return hex.EncodeToString(b)
+	case SignatureEncodingMultibase:
+		return multibaseEncode(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (e SignatureEncoding) decode(s string) ([]byte, error) {
+	switch e {
+	case SignatureEncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	case SignatureEncodingHex:
+		return hex.DecodeString(s)
+	case SignatureEncodingMultibase:
+		return multibaseDecode(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
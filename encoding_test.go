@@ -0,0 +1,83 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSignatureEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding SignatureEncoding
+		alphabet string
+	}{
+		{name: "base64", encoding: SignatureEncodingBase64, alphabet: "+/="},
+		{name: "base64url", encoding: SignatureEncodingBase64URL, alphabet: "-_"},
+		{name: "hex", encoding: SignatureEncodingHex, alphabet: "0123456789abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := testBenchHS()
+			hs.SetQuirks(Quirks{SignatureEncoding: tt.encoding})
+			r := testBenchRequest()
+			if err := hs.Sign("rsa", r); err != nil {
+				t.Fatal(err)
+			}
+			sh, err := ParseSignatureHeader(r.Header.Get("Signature"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.encoding == SignatureEncodingHex && strings.Trim(sh.Signature, tt.alphabet) != "" {
+				t.Errorf("signature %s is not hex", sh.Signature)
+			}
+			if tt.encoding == SignatureEncodingBase64URL && strings.ContainsAny(sh.Signature, "+/=") {
+				t.Errorf("signature %s is not base64url", sh.Signature)
+			}
+			if err := hs.Verify(r); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKeySignatureEncoding(t *testing.T) {
+	hs := testBenchHS()
+	r := testBenchRequest()
+	if err := hs.Sign("ed25519", r); err != nil {
+		t.Fatal(err)
+	}
+	sh, _ := ParseSignatureHeader(r.Header.Get("Signature"))
+	b, _ := base64.StdEncoding.DecodeString(sh.Signature)
+	// Partner sends padded base64url
+	enc := base64.URLEncoding.EncodeToString(b)
+	r.Header.Set("Signature", strings.Replace(r.Header.Get("Signature"), sh.Signature, enc, 1))
+
+	// Signature without '-' & '_' symbols is valid base64 as well
+	if err := hs.Verify(r); err == nil && strings.ContainsAny(enc, "-_") {
+		t.Error("Verify() no error for base64url signature")
+	}
+	hs.SetKeyQuirks("ed25519", Quirks{SignatureEncoding: SignatureEncodingBase64URL})
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestProfileSignatureEncoding(t *testing.T) {
+	hs := testBenchHS()
+	if err := hs.SetProfile("partner", Profile{SignatureEncoding: SignatureEncodingHex}); err != nil {
+		t.Fatal(err)
+	}
+	r := testBenchRequest()
+	if err := hs.SignWithProfile(r, "partner", "hmac"); err != nil {
+		t.Fatal(err)
+	}
+	sh, _ := ParseSignatureHeader(r.Header.Get("Signature"))
+	if len(sh.Signature) != 64 || strings.Trim(sh.Signature, "0123456789abcdef") != "" {
+		t.Errorf("signature %s is not hex HMAC-SHA256", sh.Signature)
+	}
+	hs.SetKeyQuirks("hmac", Quirks{SignatureEncoding: SignatureEncodingHex})
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
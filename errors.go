@@ -0,0 +1,33 @@
+package httpsignatures
+
+import (
+	"errors"
+
+	"github.com/igor-pavlenko/httpsignatures-go/sigparse"
+)
+
+// Sentinel errors to check the reason of failure with errors.Is, e.g. errors.Is(err, ErrSignatureExpired).
+// Error messages are not changed, ErrHS, ErrParser, ErrDigest etc. are returned as before.
+var (
+	ErrSignatureHeaderNotFound = sigparse.ErrSignatureHeaderNotFound
+	ErrSignatureExpired        = errors.New("signature expired")
+	ErrSignatureInFuture       = errors.New("signature in future")
+	ErrUnknownKeyID            = errors.New("unknown keyId")
+	ErrAlgorithmMismatch       = errors.New("algorithm mismatch")
+	ErrUnsupportedAlgorithm    = errors.New("unsupported algorithm")
+	ErrRequiredHeaderNotFound  = errors.New("header required in signature not found")
+	ErrWrongSignature          = errors.New("wrong signature")
+	ErrDigestMismatch          = errors.New("digest mismatch")
+	ErrDuplicateParam          = sigparse.ErrDuplicateParam
+	ErrMissingParam            = sigparse.ErrMissingParam
+	ErrPolicyViolation         = errors.New("signature policy violation")
+	ErrKeyResolutionTimeout    = errors.New("key resolution timeout")
+	ErrSecretExists            = errors.New("secret already exists")
+	ErrUnknownTenant           = errors.New("unknown tenant")
+	ErrKeyPinMismatch          = errors.New("public key doesn't match the pin")
+	ErrInvalidCertificate      = errors.New("invalid certificate")
+	ErrCertificateRevoked      = errors.New("certificate revoked")
+	ErrKeyFetchRateLimited     = errors.New("key fetch rate limited")
+	ErrKeyExpired              = errors.New("key expired")
+	ErrKeyNotYetValid          = errors.New("key is not yet valid")
+)
@@ -0,0 +1,124 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestErrorsIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   error
+	}{
+		{
+			name:   "Signature header not found",
+			header: "",
+			want:   ErrSignatureHeaderNotFound,
+		},
+		{
+			name: "Signature expired",
+			header: `keyId="Test",algorithm="RSA-SHA256",created=1592250204,expires=1592250214,` +
+				`headers="(created) (expires)",signature="c2ln"`,
+			want: ErrSignatureExpired,
+		},
+		{
+			name:   "Signature in future",
+			header: `keyId="Test",algorithm="RSA-SHA256",created=4102444800,headers="(created)",signature="c2ln"`,
+			want:   ErrSignatureInFuture,
+		},
+		{
+			name:   "Unknown keyId",
+			header: `keyId="Unknown",algorithm="RSA-SHA256",signature="c2ln"`,
+			want:   ErrUnknownKeyID,
+		},
+		{
+			name:   "Algorithm mismatch",
+			header: `keyId="Test",algorithm="hmac-sha256",signature="c2ln"`,
+			want:   ErrAlgorithmMismatch,
+		},
+		{
+			name:   "Unsupported algorithm",
+			header: `keyId="NotSupported",algorithm="` + testRsaDummyName + `",signature="c2ln"`,
+			want:   ErrUnsupportedAlgorithm,
+		},
+		{
+			name:   "Required header not found",
+			header: `keyId="Test",algorithm="RSA-SHA256",headers="x-custom",signature="c2ln"`,
+			want:   ErrRequiredHeaderNotFound,
+		},
+		{
+			name:   "Wrong signature",
+			header: `keyId="Test",algorithm="RSA-SHA256",headers="(request-target)",signature="c2ln"`,
+			want:   ErrWrongSignature,
+		},
+		{
+			name:   "Duplicate param",
+			header: `keyId="Test",keyId="Test"`,
+			want:   ErrDuplicateParam,
+		},
+		{
+			name:   "Missing param",
+			header: `keyId="Test"`,
+			want:   ErrMissingParam,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			r := testGetRequest()
+			if len(tt.header) > 0 {
+				r.Header.Set("Signature", tt.header)
+			}
+			err := hs.Verify(r)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsIsDigest(t *testing.T) {
+	d := NewDigest()
+	r := testGetRequest()
+	r.Header.Set("Digest", "MD5=AAAAAAAAAAAAAAAAAAAAAA==")
+	if err := d.Verify(r); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("got error = %v, want %v", err, ErrDigestMismatch)
+	}
+
+	r.Header.Set("Digest", "SHA-1=Sd/dVLAcvNLSq16eXua5uQ==")
+	if err := d.Verify(r); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("got error = %v, want %v", err, ErrUnsupportedAlgorithm)
+	}
+}
+
+func TestErrorsUnwrap(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	r, _ := http.NewRequest(http.MethodGet, testHostExampleFullPath, nil)
+	err := hs.Sign("Unknown", r)
+
+	var secretErr *ErrSecret
+	if !errors.As(err, &secretErr) {
+		t.Fatalf("got error = %v, want wrapped *ErrSecret", err)
+	}
+	if !errors.Is(err, ErrUnknownKeyID) || !errors.Is(secretErr, ErrUnknownKeyID) {
+		t.Errorf("got error = %v, want %v", err, ErrUnknownKeyID)
+	}
+	if errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("got error = %v, should not match %v", err, ErrSignatureExpired)
+	}
+
+	wrapped := errors.New("wrapped")
+	for _, e := range []error{
+		&ErrHS{Err: wrapped},
+		&ErrParser{Err: wrapped},
+		&ErrDigest{Err: wrapped},
+		&ErrCrypto{Err: wrapped},
+		&ErrSecret{Err: wrapped},
+	} {
+		if !errors.Is(e, wrapped) {
+			t.Errorf("%T does not unwrap error", e)
+		}
+	}
+}
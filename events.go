@@ -0,0 +1,45 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"time"
+)
+
+// VerifyEvent request verification event passed to OnVerifySuccess & OnVerifyFailure callbacks
+type VerifyEvent struct {
+	// KeyID keyId of the signature, on failure it's taken from the unverified signature (empty if it can't be parsed)
+	KeyID string
+	// Duration verification time, including key lookup
+	Duration time.Duration
+	// Err verification error, nil on success
+	Err error
+}
+
+// OnVerifySuccess set func called after every successful request verification (Verify* & VerifyRequests
+// middleware), e.g. for custom alerting. Digest verified while the body is read doesn't trigger events.
+func (hs *HTTPSignatures) OnVerifySuccess(f func(r *http.Request, e VerifyEvent)) {
+	hs.onVerifySuccess = f
+}
+
+// OnVerifyFailure set func called after every failed request verification
+func (hs *HTTPSignatures) OnVerifyFailure(f func(r *http.Request, e VerifyEvent)) {
+	hs.onVerifyFailure = f
+}
+
+// verifyEvent call verification event callback
+func (hs *HTTPSignatures) verifyEvent(r *http.Request, secret Secret, d time.Duration, err error) {
+	if err == nil {
+		if hs.onVerifySuccess != nil {
+			hs.onVerifySuccess(r, VerifyEvent{KeyID: secret.KeyID, Duration: d})
+		}
+		return
+	}
+	if hs.onVerifyFailure == nil {
+		return
+	}
+	e := VerifyEvent{Duration: d, Err: err}
+	if info, iErr := hs.Inspect(r); iErr == nil {
+		e.KeyID = info.KeyID
+	}
+	hs.onVerifyFailure(r, e)
+}
@@ -0,0 +1,49 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyEvents(t *testing.T) {
+	var success, failure []VerifyEvent
+	hs, err := New(
+		WithSecretsStorage(testBenchSecrets),
+		WithOnVerifySuccess(func(r *http.Request, e VerifyEvent) { success = append(success, e) }),
+		WithOnVerifyFailure(func(r *http.Request, e VerifyEvent) { failure = append(failure, e) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Fatal(err)
+	}
+	if len(success) != 1 || success[0].KeyID != "hmac" || success[0].Err != nil || success[0].Duration < 0 {
+		t.Errorf("success events = %+v, want one event of keyId 'hmac'", success)
+	}
+
+	r.Header.Set(signatureHeader, strings.Replace(r.Header.Get(signatureHeader), `signature="`, `signature="AA`, 1))
+	if err := hs.Verify(r); err == nil {
+		t.Fatal("no error, want wrong signature")
+	}
+	r = testBenchRequest()
+	if err := hs.Verify(r); err == nil {
+		t.Fatal("no error, want signature header not found")
+	}
+	if len(failure) != 2 || failure[0].KeyID != "hmac" || failure[0].Err == nil || len(failure[1].KeyID) > 0 {
+		t.Errorf("failure events = %+v, want failure of keyId 'hmac' & failure without keyId", failure)
+	}
+
+	h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), testBenchRequest())
+	if len(success) != 1 || len(failure) != 3 {
+		t.Errorf("got %d success & %d failure events, want 1 & 3", len(success), len(failure))
+	}
+}
@@ -1,61 +1,149 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/igor-pavlenko/httpsignatures-go"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"sync"
 )
 
 // To create your own secrets storage implement the httpsignatures.Secrets interface
 // type Secrets interface {
 //	   Get(keyID string) (Secret, error)
 // }
+// To register keys at runtime implement httpsignatures.WritableSecrets (Add, Update & Delete) as well.
 
 const alg = "RSA-SHA512"
 
+var validKeyID = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
 // FileSecretsStorage local files secrets storage
 type FileSecretsStorage struct {
+	mu      sync.RWMutex
 	dir     string
 	storage map[string]httpsignatures.Secret
 }
 
 // Get get secret from local files by KeyID
-func (s FileSecretsStorage) Get(keyID string) (httpsignatures.Secret, error) {
-	if secret, ok := s.storage[keyID]; ok {
+func (s *FileSecretsStorage) Get(keyID string) (httpsignatures.Secret, error) {
+	s.mu.RLock()
+	secret, ok := s.storage[keyID]
+	s.mu.RUnlock()
+	if ok {
 		return secret, nil
 	}
 
-	validKeyID, err := regexp.Match(`[a-zA-Z0-9]+`, []byte(keyID))
-	if !validKeyID {
+	if !validKeyID.MatchString(keyID) {
 		return httpsignatures.Secret{}, &httpsignatures.ErrSecret{Message: "wrong keyID format allowed: [a-zA-Z0-9]+"}
 	}
 
-	publicKeyFile := fmt.Sprintf("%s/%s.pub", s.dir, keyID)
-	publicKey, err := s.readFile(publicKeyFile)
+	publicKey, err := s.readFile(s.publicKeyFile(keyID))
 	if err != nil {
 		return httpsignatures.Secret{}, &httpsignatures.ErrSecret{Message: "public key file not found", Err: err}
 	}
 
-	privateKeyFile := fmt.Sprintf("%s/%s.key", s.dir, keyID)
-	privateKey, err := s.readFile(privateKeyFile)
+	privateKey, err := s.readFile(s.privateKeyFile(keyID))
 	if err != nil {
 		return httpsignatures.Secret{}, &httpsignatures.ErrSecret{Message: "private key file not found", Err: err}
 	}
 
-	fmt.Println(privateKey, publicKey)
-	s.storage[keyID] = httpsignatures.Secret{
+	secret = httpsignatures.Secret{
 		KeyID:      keyID,
 		PublicKey:  publicKey,
 		PrivateKey: privateKey,
 		Algorithm:  alg,
 	}
-	return s.storage[keyID], nil
+	s.mu.Lock()
+	s.storage[keyID] = secret
+	s.mu.Unlock()
+	return secret, nil
+}
+
+// Add write new secret key files, fails if key files exist
+func (s *FileSecretsStorage) Add(secret httpsignatures.Secret) error {
+	if err := s.checkKeyID(secret.KeyID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fileExists(s.publicKeyFile(secret.KeyID)) || s.fileExists(s.privateKeyFile(secret.KeyID)) {
+		return &httpsignatures.ErrSecret{
+			Message: fmt.Sprintf("secret '%s' already exists", secret.KeyID),
+			Err:     httpsignatures.ErrSecretExists,
+		}
+	}
+	return s.write(secret)
+}
+
+// Update overwrite existing secret key files
+func (s *FileSecretsStorage) Update(secret httpsignatures.Secret) error {
+	if err := s.checkKeyID(secret.KeyID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.fileExists(s.publicKeyFile(secret.KeyID)) && !s.fileExists(s.privateKeyFile(secret.KeyID)) {
+		return &httpsignatures.ErrSecret{Message: "secret not found", Err: httpsignatures.ErrUnknownKeyID}
+	}
+	return s.write(secret)
+}
+
+// Delete remove secret key files
+func (s *FileSecretsStorage) Delete(keyID string) error {
+	if err := s.checkKeyID(keyID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.storage, keyID)
+	found := false
+	for _, f := range []string{s.publicKeyFile(keyID), s.privateKeyFile(keyID)} {
+		err := os.Remove(f)
+		if err == nil {
+			found = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return &httpsignatures.ErrSecret{Message: fmt.Sprintf("remove file error: '%s'", f), Err: err}
+		}
+	}
+	if !found {
+		return &httpsignatures.ErrSecret{Message: "secret not found", Err: httpsignatures.ErrUnknownKeyID}
+	}
+	return nil
+}
+
+// write key files & replace cached secret, the lock must be held
+func (s *FileSecretsStorage) write(secret httpsignatures.Secret) error {
+	if err := s.writeFile(s.publicKeyFile(secret.KeyID), secret.PublicKey, 0644); err != nil {
+		return err
+	}
+	if err := s.writeFile(s.privateKeyFile(secret.KeyID), secret.PrivateKey, 0600); err != nil {
+		return err
+	}
+	secret.Algorithm = alg
+	s.storage[secret.KeyID] = secret
+	return nil
+}
+
+func (s *FileSecretsStorage) checkKeyID(keyID string) error {
+	if !validKeyID.MatchString(keyID) {
+		return &httpsignatures.ErrSecret{Message: "wrong keyID format allowed: [a-zA-Z0-9]+"}
+	}
+	return nil
+}
+
+func (s *FileSecretsStorage) publicKeyFile(keyID string) string {
+	return fmt.Sprintf("%s/%s.pub", s.dir, keyID)
+}
+
+func (s *FileSecretsStorage) privateKeyFile(keyID string) string {
+	return fmt.Sprintf("%s/%s.key", s.dir, keyID)
 }
 
 // Get key from file
-func (s FileSecretsStorage) readFile(f string) (string, error) {
+func (s *FileSecretsStorage) readFile(f string) (string, error) {
 	if !s.fileExists(f) {
 		return "", &httpsignatures.ErrSecret{Message: fmt.Sprintf("file '%s' not found", f)}
 	}
@@ -67,17 +155,25 @@ func (s FileSecretsStorage) readFile(f string) (string, error) {
 	return string(key), nil
 }
 
+// Write key to file
+func (s *FileSecretsStorage) writeFile(f string, key string, perm os.FileMode) error {
+	if err := ioutil.WriteFile(f, []byte(key), perm); err != nil {
+		return &httpsignatures.ErrSecret{Message: fmt.Sprintf("write file error: '%s'", f), Err: err}
+	}
+	return nil
+}
+
 // Check if file exists
-func (s FileSecretsStorage) fileExists(f string) bool {
+func (s *FileSecretsStorage) fileExists(f string) bool {
 	i, err := os.Stat(f)
 	if os.IsNotExist(err) {
 		return false
 	}
-	return !i.IsDir()
+	return err == nil && !i.IsDir()
 }
 
 // NewFileSecretsStorage create new storage
-func NewFileSecretsStorage(dir string) httpsignatures.Secrets {
+func NewFileSecretsStorage(dir string) httpsignatures.WritableSecrets {
 	if len(dir) == 0 {
 		return nil
 	}
@@ -88,6 +184,17 @@ func NewFileSecretsStorage(dir string) httpsignatures.Secrets {
 }
 
 func main() {
-	hs := httpsignatures.NewHTTPSignatures(NewFileSecretsStorage("/tmp"))
+	ss := NewFileSecretsStorage("/tmp")
+	hs := httpsignatures.NewHTTPSignatures(ss)
 	hs.SetDefaultExpiresSeconds(10)
+
+	// Register a customer key at runtime
+	secret, err := httpsignatures.GenerateSecret("customer1", alg)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := ss.Add(secret); err != nil && !errors.Is(err, httpsignatures.ErrSecretExists) {
+		fmt.Println(err)
+	}
 }
@@ -0,0 +1,43 @@
+//go:build js && wasm
+// +build js,wasm
+
+package main
+
+import (
+	"fmt"
+	"github.com/igor-pavlenko/httpsignatures-go"
+	"net/http"
+	"strings"
+)
+
+// Build: GOOS=js GOARCH=wasm go build -o sign.wasm ./examples/wasm
+// or: tinygo build -target=wasm -o sign.wasm ./examples/wasm
+func main() {
+	const sKey = "key1"
+	// Don't put keys into code, neither push it in to git repo (this is just for example)
+	secrets := map[string]httpsignatures.Secret{
+		sKey: {
+			KeyID:      sKey,
+			PrivateKey: "secret",
+			PublicKey:  "secret",
+			Algorithm:  "HMAC-SHA256",
+		},
+	}
+	ss := httpsignatures.NewSimpleSecretsStorage(secrets)
+	hs := httpsignatures.NewHTTPSignatures(ss)
+	hs.SetDefaultSignatureHeaders([]string{"(created)", "digest", "(request-target)"})
+	_ = hs.SetDefaultDigestAlgorithm("SHA-256")
+
+	r, _ := http.NewRequest(
+		"POST",
+		"https://example.com/foo?param=value&pet=dog",
+		strings.NewReader(`{"hello": "world"}`),
+	)
+	err := hs.Sign(sKey, r)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(r.Header.Get("Digest"))
+	fmt.Println(r.Header.Get("Signature"))
+}
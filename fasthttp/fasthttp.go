@@ -0,0 +1,128 @@
+// Package fasthttp adapts fasthttp requests & responses to httpsignatures.Message, so they are signed & verified
+// without constructing http.Request. It's a separate module to keep the core module free of dependencies.
+package fasthttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+	"github.com/valyala/fasthttp"
+)
+
+// KeyIDUserValue user value of fasthttp.RequestCtx with keyId of verified signature, set by VerifyRequests
+const KeyIDUserValue = "httpsignatures.keyId"
+
+// RequestMessage fasthttp.Request adapter
+func RequestMessage(r *fasthttp.Request) httpsignatures.Message {
+	return requestMessage{r: r}
+}
+
+type requestMessage struct {
+	r *fasthttp.Request
+}
+
+func (m requestMessage) Method() string {
+	return string(m.r.Header.Method())
+}
+
+func (m requestMessage) Authority() string {
+	return string(m.r.Host())
+}
+
+func (m requestMessage) Path() string {
+	return string(m.r.URI().RequestURI())
+}
+
+func (m requestMessage) HeaderValues(name string) []string {
+	var values []string
+	m.r.Header.VisitAll(func(k, v []byte) {
+		if bytes.EqualFold(k, []byte(name)) {
+			values = append(values, string(v))
+		}
+	})
+	return values
+}
+
+func (m requestMessage) SetHeader(name string, value string) {
+	m.r.Header.Set(name, value)
+}
+
+func (m requestMessage) Body() ([]byte, error) {
+	return m.r.Body(), nil
+}
+
+// ResponseMessage fasthttp.Response adapter (no (request-target))
+func ResponseMessage(resp *fasthttp.Response) httpsignatures.Message {
+	return responseMessage{resp: resp}
+}
+
+type responseMessage struct {
+	resp *fasthttp.Response
+}
+
+func (m responseMessage) Method() string {
+	return ""
+}
+
+func (m responseMessage) Authority() string {
+	return ""
+}
+
+func (m responseMessage) Path() string {
+	return ""
+}
+
+func (m responseMessage) HeaderValues(name string) []string {
+	var values []string
+	m.resp.Header.VisitAll(func(k, v []byte) {
+		if bytes.EqualFold(k, []byte(name)) {
+			values = append(values, string(v))
+		}
+	})
+	return values
+}
+
+func (m responseMessage) SetHeader(name string, value string) {
+	m.resp.Header.Set(name, value)
+}
+
+func (m responseMessage) Body() ([]byte, error) {
+	return m.resp.Body(), nil
+}
+
+// SignRequest add signature (and Digest if it's signed) headers to the request
+func SignRequest(hs *httpsignatures.HTTPSignatures, secretKeyID string, r *fasthttp.Request) error {
+	return hs.SignMessage(context.Background(), secretKeyID, RequestMessage(r))
+}
+
+// VerifyRequest verify request signature & return secret (keyId) which validated it
+func VerifyRequest(hs *httpsignatures.HTTPSignatures, r *fasthttp.Request) (httpsignatures.Secret, error) {
+	return hs.VerifyMessage(context.Background(), RequestMessage(r))
+}
+
+// SignResponse add signature (and Digest if it's signed) headers to the response
+func SignResponse(hs *httpsignatures.HTTPSignatures, secretKeyID string, resp *fasthttp.Response) error {
+	return hs.SignMessage(context.Background(), secretKeyID, ResponseMessage(resp))
+}
+
+// VerifyResponse verify response signature & return secret (keyId) which validated it
+func VerifyResponse(hs *httpsignatures.HTTPSignatures, resp *fasthttp.Response) (httpsignatures.Secret, error) {
+	return hs.VerifyMessage(context.Background(), ResponseMessage(resp))
+}
+
+// VerifyRequests handler wrapper which verifies request signatures (and digest, the body is read by fasthttp)
+// before the next handler. Requests with missing or wrong signature get 401 Unauthorized, keyId of verified
+// signature is stored in KeyIDUserValue.
+func VerifyRequests(hs *httpsignatures.HTTPSignatures, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		secret, err := hs.VerifyMessage(ctx, RequestMessage(&ctx.Request))
+		if err != nil {
+			ctx.Error(http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		ctx.SetUserValue(KeyIDUserValue, secret.KeyID)
+		next(ctx)
+	}
+}
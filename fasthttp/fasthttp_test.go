@@ -0,0 +1,142 @@
+package fasthttp
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+	"github.com/valyala/fasthttp"
+)
+
+func testHS() *httpsignatures.HTTPSignatures {
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(map[string]httpsignatures.Secret{
+		"key1": {KeyID: "key1", PrivateKey: "secret", Algorithm: "HMAC-SHA256"},
+	}))
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "host", "digest"})
+	return hs
+}
+
+func testRequest() *fasthttp.Request {
+	r := &fasthttp.Request{}
+	r.Header.SetMethod(http.MethodPost)
+	r.SetRequestURI("https://example.com/foo?param=value&pet=dog")
+	r.SetBodyString(`{"hello": "world"}`)
+	return r
+}
+
+func TestRequestMessage(t *testing.T) {
+	hs := testHS()
+	tests := []struct {
+		name       string
+		modify     func(r *fasthttp.Request)
+		wantErrMsg string
+	}{
+		{
+			name:   "Valid signature",
+			modify: func(r *fasthttp.Request) {},
+		},
+		{
+			name:       "Body changed",
+			modify:     func(r *fasthttp.Request) { r.SetBodyString(`{"hello": "evil"}`) },
+			wantErrMsg: "ErrDigest: wrong digest: ErrCrypto: wrong hash",
+		},
+		{
+			name:       "Path changed",
+			modify:     func(r *fasthttp.Request) { r.SetRequestURI("https://example.com/bar?param=value&pet=dog") },
+			wantErrMsg: "wrong signature: ErrCrypto: wrong signature",
+		},
+		{
+			name:       "Unsigned",
+			modify:     func(r *fasthttp.Request) { r.Header.Del("Signature") },
+			wantErrMsg: "signature header not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRequest()
+			if err := SignRequest(hs, "key1", r); err != nil {
+				t.Fatalf("SignRequest() error = %v", err)
+			}
+			tt.modify(r)
+			secret, err := VerifyRequest(hs, r)
+			if len(tt.wantErrMsg) > 0 {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("VerifyRequest() error = %v, wantErrMsg %s", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyRequest() error = %v", err)
+			}
+			if secret.KeyID != "key1" {
+				t.Errorf("VerifyRequest() keyId = %s, want key1", secret.KeyID)
+			}
+		})
+	}
+}
+
+func TestResponseMessage(t *testing.T) {
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(map[string]httpsignatures.Secret{
+		"key1": {KeyID: "key1", PrivateKey: "secret", Algorithm: "HMAC-SHA256"},
+	}))
+	hs.SetDefaultResponseSignatureHeaders([]string{"(created)", "digest"})
+
+	resp := &fasthttp.Response{}
+	resp.SetBodyString("ok")
+	if err := SignResponse(hs, "key1", resp); err != nil {
+		t.Fatalf("SignResponse() error = %v", err)
+	}
+	if _, err := VerifyResponse(hs, resp); err != nil {
+		t.Fatalf("VerifyResponse() error = %v", err)
+	}
+	resp.SetBodyString("changed")
+	if _, err := VerifyResponse(hs, resp); err == nil {
+		t.Error("VerifyResponse() expected error for changed body")
+	}
+}
+
+func TestVerifyRequests(t *testing.T) {
+	hs := testHS()
+	tests := []struct {
+		name       string
+		sign       bool
+		wantStatus int
+		wantCalled bool
+	}{
+		{
+			name:       "Signed",
+			sign:       true,
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:       "Unsigned",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRequest()
+			if tt.sign {
+				if err := SignRequest(hs, "key1", r); err != nil {
+					t.Fatalf("SignRequest() error = %v", err)
+				}
+			}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(r, nil, nil)
+			called := false
+			VerifyRequests(hs, func(ctx *fasthttp.RequestCtx) {
+				called = true
+				if ctx.UserValue(KeyIDUserValue) != "key1" {
+					t.Errorf("keyId user value = %v, want key1", ctx.UserValue(KeyIDUserValue))
+				}
+			})(ctx)
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+			if ctx.Response.StatusCode() != tt.wantStatus {
+				t.Errorf("status = %d, want %d", ctx.Response.StatusCode(), tt.wantStatus)
+			}
+		})
+	}
+}
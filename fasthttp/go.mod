@@ -0,0 +1,11 @@
+module github.com/igor-pavlenko/httpsignatures-go/fasthttp
+
+go 1.15
+
+require (
+	github.com/igor-pavlenko/httpsignatures-go v0.0.14
+	github.com/valyala/fasthttp v1.34.0
+)
+
+// Use the core module from this repository
+replace github.com/igor-pavlenko/httpsignatures-go => ../
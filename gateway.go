@@ -0,0 +1,101 @@
+package httpsignatures
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// DefaultOriginalKeyIDHeader header with verified client keyId added by Gateway
+const DefaultOriginalKeyIDHeader = "X-Original-KeyId"
+
+// Gateway re-sign requests passing through a gateway: verify inbound signature with client keys, strip it, sign
+// the outbound request with the gateway key & pass verified client keyId in X-Original-KeyId header.
+// The header sent by clients is always removed.
+type Gateway struct {
+	inbound             *HTTPSignatures
+	outbound            *HTTPSignatures
+	keyID               string
+	originalKeyIDHeader string
+}
+
+// NewGateway create gateway verifying requests with inbound & signing them with outbound keyID secret
+func NewGateway(inbound *HTTPSignatures, outbound *HTTPSignatures, keyID string) *Gateway {
+	return &Gateway{inbound: inbound, outbound: outbound, keyID: keyID, originalKeyIDHeader: DefaultOriginalKeyIDHeader}
+}
+
+// SetOriginalKeyIDHeader set header name of verified client keyId, empty name — don't send it
+func (g *Gateway) SetOriginalKeyIDHeader(name string) {
+	g.originalKeyIDHeader = name
+}
+
+// Handler verify inbound signature & strip it before the next handler, requests with missing or wrong signature are
+// rejected like by VerifyRequests. Use it with httputil.ReverseProxy which sends requests with Transport, so
+// the outbound request is signed after the proxy rewrites it:
+//
+//	proxy := httputil.NewSingleHostReverseProxy(backend)
+//	proxy.Transport = gw.Transport(nil)
+//	http.Handle("/", gw.Handler(proxy))
+func (g *Gateway) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, err := g.verify(r.Context(), r)
+		if err != nil {
+			g.inbound.log.Error("gateway signature verification failed", "method", r.Method, "uri", r.RequestURI,
+				"err", err)
+			g.inbound.reject(w, r, err)
+			return
+		}
+		g.inbound.log.Debug("gateway signature verified", "keyId", secret.KeyID, "method", r.Method,
+			"uri", r.RequestURI)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Transport sign outbound requests with the gateway key, base is http.DefaultTransport if nil
+func (g *Gateway) Transport(base http.RoundTripper) *Transport {
+	return g.outbound.NewTransport(g.keyID, base)
+}
+
+// Resign verify inbound signature, strip it & sign the request with the gateway key in place, for custom proxies
+// which forward the inbound request. Return secret (client keyId) which validated inbound signature.
+func (g *Gateway) Resign(ctx context.Context, r *http.Request) (Secret, error) {
+	secret, err := g.verify(ctx, r)
+	if err != nil {
+		return Secret{}, err
+	}
+	if err := g.outbound.SignCtx(ctx, g.keyID, r); err != nil {
+		return Secret{}, err
+	}
+	return secret, nil
+}
+
+// verify verify inbound signature (digest is verified before forwarding), strip it & set client keyId header
+func (g *Gateway) verify(ctx context.Context, r *http.Request) (Secret, error) {
+	if len(g.originalKeyIDHeader) > 0 {
+		r.Header.Del(g.originalKeyIDHeader)
+	}
+	secret, err := g.inbound.verify(ctx, r, false)
+	if err != nil {
+		return Secret{}, err
+	}
+	stripSignature(r.Header)
+	if len(g.originalKeyIDHeader) > 0 {
+		r.Header.Set(g.originalKeyIDHeader, secret.KeyID)
+	}
+	return secret, nil
+}
+
+// stripSignature remove Signature header & Authorization headers with "Signature" scheme, other credentials are kept
+func stripSignature(h http.Header) {
+	h.Del(signatureHeader)
+	var keep []string
+	for _, v := range h.Values(authorizationHeader) {
+		if !strings.HasPrefix(strings.ToLower(v), strings.ToLower(authorizationScheme)+" ") {
+			keep = append(keep, v)
+		}
+	}
+	h.Del(authorizationHeader)
+	for _, v := range keep {
+		h.Add(authorizationHeader, v)
+	}
+}
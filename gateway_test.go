@@ -0,0 +1,99 @@
+package httpsignatures
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGateway(t *testing.T) {
+	inbound := testBenchHS()
+	outbound := testBenchHS()
+	outbound.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "host", "digest"})
+	gw := NewGateway(inbound, outbound, "ed25519")
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, err := outbound.VerifyAndIdentify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(secret.KeyID + " " + r.Header.Get(DefaultOriginalKeyIDHeader) + " " + string(b)))
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.Transport = gw.Transport(nil)
+	srv := httptest.NewServer(gw.Handler(proxy))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		keyID      string
+		spoof      bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Re-signed", keyID: "hmac", wantStatus: http.StatusOK, wantBody: "ed25519 hmac " + testBodyExample},
+		{name: "Spoofed keyId header", keyID: "hmac", spoof: true, wantStatus: http.StatusOK,
+			wantBody: "ed25519 hmac " + testBodyExample},
+		{name: "Not signed", wantStatus: http.StatusUnauthorized, wantBody: "Unauthorized\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodPost, srv.URL+"/foo", strings.NewReader(testBodyExample))
+			r.Header.Set("Date", testDateExample)
+			r.Header.Set(testContentTypeHeader, testContentTypeJSON)
+			if tt.spoof {
+				r.Header.Set(DefaultOriginalKeyIDHeader, "admin")
+			}
+			if len(tt.keyID) > 0 {
+				if err := inbound.Sign(tt.keyID, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resp, err := http.DefaultClient.Do(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			b, _ := ioutil.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || string(b) != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", resp.StatusCode, b, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGatewayResign(t *testing.T) {
+	hs := testBenchHS()
+	gw := NewGateway(hs, hs, "ed25519")
+	gw.SetOriginalKeyIDHeader("X-Client")
+	r := testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Add("Authorization", "Bearer token")
+	secret, err := gw.Resign(r.Context(), r)
+	if err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+	if secret.KeyID != "hmac" || r.Header.Get("X-Client") != "hmac" {
+		t.Errorf("Resign() keyId = %s, X-Client = %s, want hmac", secret.KeyID, r.Header.Get("X-Client"))
+	}
+	if got := r.Header.Values("Authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Errorf("Authorization = %v, want [Bearer token]", got)
+	}
+	got, err := hs.VerifyAndIdentify(r)
+	if err != nil || got.KeyID != "ed25519" {
+		t.Errorf("VerifyAndIdentify() = %s, %v, want ed25519", got.KeyID, err)
+	}
+
+	_, err = gw.Resign(r.Context(), testBenchRequest())
+	assert(t, nil, err, testHSErrType, "Not signed", nil, "signature header not found")
+}
@@ -1,9 +1,3 @@
 module github.com/igor-pavlenko/httpsignatures-go
 
 go 1.15
-
-require (
-	github.com/aws/aws-sdk-go v1.35.1
-	github.com/go-test/deep v1.0.7
-	github.com/muesli/cache2go v0.0.0-20200423001931-a100c5aac93f
-)
@@ -0,0 +1,129 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// healthCheckKeyID keyId used to probe secrets storage when there are no keys to check
+const healthCheckKeyID = "httpsignatures-health-check"
+
+// HealthCheck check the instance is ready to sign & verify, e.g. for Kubernetes readiness probes. Every key of
+// keyIDs & signer identities is looked up in the secrets storage, then a request signed with it (with default
+// headers & every registered profile, identities with their profile) is verified. Keys without private key are
+// only parsed, signed requests of keys without public key are not verified. Verification policy is not applied.
+// Without keys the storage is probed with unknown keyId which must fail with ErrUnknownKeyID.
+func (hs *HTTPSignatures) HealthCheck(ctx context.Context, keyIDs ...string) error {
+	if len(keyIDs) == 0 && len(hs.identities) == 0 {
+		_, err := hs.getSecret(ctx, healthCheckKeyID)
+		if err != nil && !errors.Is(err, ErrUnknownKeyID) {
+			return &ErrHS{Message: "secrets storage is not reachable", Err: err}
+		}
+		return nil
+	}
+
+	profiles := make([]string, 0, len(hs.profiles))
+	for name := range hs.profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, keyID := range keyIDs {
+		if err := hs.checkKey(ctx, keyID, ""); err != nil {
+			return err
+		}
+		for _, name := range profiles {
+			if err := hs.checkKey(ctx, keyID, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	identities := make([]string, 0, len(hs.identities))
+	for name := range hs.identities {
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+	for _, name := range identities {
+		id := hs.identities[name]
+		if err := hs.identitySigner(id).checkKey(ctx, id.KeyID, id.Profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKey check the key parses & request signed with the key (and profile, if it's set) is verified
+func (hs *HTTPSignatures) checkKey(ctx context.Context, keyID string, profile string) error {
+	err := hs.roundTrip(ctx, keyID, profile)
+	if err == nil {
+		return nil
+	}
+	msg := fmt.Sprintf("health check of keyId '%s' failed", keyID)
+	if len(profile) > 0 {
+		msg = fmt.Sprintf("health check of keyId '%s' with profile '%s' failed", keyID, profile)
+	}
+	return &ErrHS{Message: msg, Err: err}
+}
+
+// roundTrip sign health check request & verify it
+func (hs *HTTPSignatures) roundTrip(ctx context.Context, keyID string, profile string) error {
+	secret, err := hs.getSecret(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if err := hs.checkKeyValidity(secret); err != nil {
+		return err
+	}
+	// Verify-only key
+	if len(secret.PrivateKey) == 0 {
+		if secret, err = hs.certificateKey(ctx, secret); err != nil {
+			return err
+		}
+		_, err = keyType(secret.PublicKey, false)
+		return err
+	}
+
+	r := hs.healthCheckRequest()
+	if len(profile) > 0 {
+		err = hs.SignWithProfileCtx(ctx, r, profile, keyID)
+	} else {
+		err = hs.SignCtx(ctx, keyID, r)
+	}
+	if err != nil {
+		return err
+	}
+	// Sign-only key: private key (not HMAC secret) without public key
+	if _, err := keyType(secret.PrivateKey, true); err == nil && len(secret.PublicKey) == 0 {
+		return nil
+	}
+	if v := r.Header.Get(authorizationHeader); len(r.Header.Get(signatureHeader)) == 0 && len(v) > 0 {
+		r.Header.Set(signatureHeader, strings.TrimPrefix(v, authorizationScheme+" "))
+	}
+	v := *hs
+	v.policy = Policy{}
+	_, err = v.verifyRequest(ctx, r, false)
+	return err
+}
+
+// healthCheckRequest request with body & all headers signed by default or by profiles
+func (hs *HTTPSignatures) healthCheckRequest() *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "https://health-check.invalid/", strings.NewReader("{}"))
+	r.Header.Set("Date", hs.now().UTC().Format(http.TimeFormat))
+	headers := hs.defaultHeaders
+	for _, p := range hs.profiles {
+		headers = append(headers[:len(headers):len(headers)], p.Headers...)
+	}
+	for _, h := range headers {
+		if strings.HasPrefix(h, "(") || strings.EqualFold(h, hostHeader) || strings.EqualFold(h, digestHeader) {
+			continue
+		}
+		if len(r.Header.Get(h)) == 0 {
+			r.Header.Set(h, "health-check")
+		}
+	}
+	return r
+}
@@ -0,0 +1,111 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testUnreachableSecrets struct{}
+
+func (s testUnreachableSecrets) Get(keyID string) (Secret, error) {
+	return Secret{}, errors.New("connection refused")
+}
+
+func TestHealthCheck(t *testing.T) {
+	rsa, err := testBenchSecrets.Get("rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := GenerateSecret("ed", algED25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := map[string]Secret{
+		"verify-only": {KeyID: "verify-only", PublicKey: rsa.PublicKey, Algorithm: rsa.Algorithm},
+		"sign-only":   {KeyID: "sign-only", PrivateKey: rsa.PrivateKey, Algorithm: rsa.Algorithm},
+		"broken":      {KeyID: "broken", PrivateKey: "broken", PublicKey: "broken", Algorithm: algRsaSha256},
+	}
+	for _, keyID := range []string{"hmac", "rsa", "ecdsa", "ed25519"} {
+		if secrets[keyID], err = testBenchSecrets.Get(keyID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name       string
+		ss         Secrets
+		keyIDs     []string
+		identity   *Identity
+		wantErrMsg string
+		wantErr    error
+	}{
+		{name: "Storage probe", ss: NewSimpleSecretsStorage(secrets)},
+		{
+			name:       "Storage unreachable",
+			ss:         testUnreachableSecrets{},
+			wantErrMsg: "secrets storage is not reachable",
+		},
+		{
+			name:   "Keys",
+			ss:     NewSimpleSecretsStorage(secrets),
+			keyIDs: []string{"hmac", "rsa", "ecdsa", "ed25519", "verify-only", "sign-only"},
+		},
+		{
+			name:     "Identity with own secret",
+			ss:       NewSimpleSecretsStorage(secrets),
+			identity: &Identity{KeyID: "partner", Secret: &ed, Profile: "s2s"},
+		},
+		{
+			name:       "Unknown key",
+			ss:         NewSimpleSecretsStorage(secrets),
+			keyIDs:     []string{"hmac", "missing"},
+			wantErrMsg: "health check of keyId 'missing' failed",
+			wantErr:    ErrUnknownKeyID,
+		},
+		{
+			name:       "Broken key",
+			ss:         NewSimpleSecretsStorage(secrets),
+			keyIDs:     []string{"broken"},
+			wantErrMsg: "health check of keyId 'broken' failed",
+		},
+		{
+			name:       "Profile",
+			ss:         NewSimpleSecretsStorage(secrets),
+			identity:   &Identity{KeyID: "broken", Profile: "s2s"},
+			wantErrMsg: "health check of keyId 'broken' with profile 's2s' failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs, err := New(
+				WithSecretsStorage(tt.ss),
+				WithSignatureHeaders("(request-target)", "(created)", "host", "date", "digest"),
+				WithPolicy(Policy{RequiredHeaders: []string{"x-not-signed"}}),
+				WithProfile("s2s", Profile{
+					Algorithm: "hs2019",
+					Headers:   []string{"(request-target)", "(created)", "x-request-id"},
+					Header:    "Authorization",
+				}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.identity != nil {
+				if err := hs.SetIdentity("test", *tt.identity); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = hs.HealthCheck(context.Background(), tt.keyIDs...)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Fatalf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			var hErr *ErrHS
+			if err != nil && (!errors.As(err, &hErr) || hErr.Message != tt.wantErrMsg) {
+				t.Errorf(tt.name+"\nerror = %v, wantErrMsg = `%s`", err, tt.wantErrMsg)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf(tt.name+"\nerror = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,32 +2,53 @@ package httpsignatures
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"net/textproto"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
-	signatureHeader = "Signature"
-	digestHeader    = "Digest"
-	requestTarget   = "(request-target)"
-	created         = "(" + paramCreated + ")"
-	expires         = "(" + paramExpires + ")"
+	signatureHeader       = "Signature"
+	authorizationHeader   = "Authorization"
+	hostHeader            = "Host"
+	digestHeader          = "Digest"
+	contentEncodingHeader = "Content-Encoding"
+	requestTarget         = "(request-target)"
+	created               = "(" + paramCreated + ")"
+	expires               = "(" + paramExpires + ")"
 )
 
+// hs2019 algorithm is derived from the key metadata (secret algorithm)
+const algHs2019 = "HS2019"
+
 // Default expires param value (seconds)
 const defaultExpiresSec = 30
 
+// Estimated size of the signature string line, to grow the buffer once
+const signatureStringLineSize = 64
+
 // Default time gap for created, expires validation (+/- seconds)
 const defaultTimeGap = 10
 
+// Default max number of signed headers
+const defaultMaxSignatureHeaders = 64
+
+// Default max length of signature string (bytes)
+const defaultMaxSignatureStringSize = 1 << 20
+
 // ErrHS errors during validating or creating Signature|Authorization
 type ErrHS struct {
 	Message string
 	Err     error
+	// SignatureString signature string built by verifier, set on signature mismatch in debug mode only
+	SignatureString string
+	kind            error
 }
 
 // ErrHS error message
@@ -41,15 +62,69 @@ func (e *ErrHS) Error() string {
 	return e.Message
 }
 
+// Unwrap return wrapped error
+func (e *ErrHS) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel error (e.g. ErrSignatureExpired)
+func (e *ErrHS) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
 // HTTPSignatures struct
 type HTTPSignatures struct {
-	ss                  Secrets
-	d                   *Digest
-	alg                 map[string]SignatureHashAlgorithm
-	defaultExpiresSec   uint32
-	defaultTimeGap      time.Duration
-	defaultHeaders      []string
-	defaultVerifyDigest bool
+	ss                     Secrets
+	d                      *Digest
+	alg                    map[string]SignatureHashAlgorithm
+	algAliases             map[string]string
+	defaultExpiresSec      uint32
+	defaultTimeGap         time.Duration
+	defaultHeaders         []string
+	defaultResponseHeaders []string
+	defaultVerifyDigest    bool
+	canonicalization       HeaderCanonicalization
+	defaultRealm           string
+	parserMode             ParserMode
+	msTimestamps           bool
+	schemePrefix           bool
+	log                    Logger
+	debug                  bool
+	policy                 Policy
+	now                    func() time.Time
+	fetches                *secretFetches
+	templates              *signatureTemplates
+	quirks                 Quirks
+	keyQuirks              map[string]Quirks
+	algorithmParam         string
+	signAlgorithm          string
+	profiles               map[string]Profile
+	identities             map[string]Identity
+	maxSignatureHeaders    int
+	maxSignatureString     int
+	verified               *verifyCache
+	principal              PrincipalResolver
+	rejections             Rejections
+	reportOnly             bool
+	reporter               func(r *http.Request, res VerificationResult)
+	keyTimeout             time.Duration
+	bothHeaders            bool
+	targetForm             RequestTargetForm
+	targetAuthority        RequestTargetAuthority
+	query                  QueryNormalization
+	trustedProxies         []*net.IPNet
+	keyTypeAlgorithms      map[string]string
+	bufferRequestBody      bool
+	algRanking             []string
+	keyPins                map[string][]byte
+	certRoots              *x509.CertPool
+	certUsages             []x509.ExtKeyUsage
+	revocation             RevocationChecker
+	revocationPolicy       RevocationPolicy
+	uniformErrors          bool
+	onVerifySuccess        func(r *http.Request, e VerifyEvent)
+	onVerifyFailure        func(r *http.Request, e VerifyEvent)
+	expiryWarnings         *keyExpiryWarnings
 }
 
 // NewHTTPSignatures Constructor
@@ -68,10 +143,21 @@ func NewHTTPSignatures(ss Secrets) *HTTPSignatures {
 		algHmacSha512:      HmacSha512{},
 		algED25519:         ED25519{},
 	}
+	hs.algAliases = map[string]string{
+		algHs2019: "",
+	}
 	hs.defaultExpiresSec = defaultExpiresSec
 	hs.defaultTimeGap = defaultTimeGap
 	hs.defaultHeaders = []string{"(created)"}
+	hs.defaultResponseHeaders = []string{"(created)"}
 	hs.defaultVerifyDigest = true
+	hs.canonicalization = defaultHeaderCanonicalization
+	hs.log = nopLogger{}
+	hs.now = time.Now
+	hs.maxSignatureHeaders = defaultMaxSignatureHeaders
+	hs.maxSignatureString = defaultMaxSignatureStringSize
+	hs.fetches = newSecretFetches()
+	hs.templates = newSignatureTemplates()
 	return hs
 }
 
@@ -85,6 +171,39 @@ func (hs *HTTPSignatures) SetDefaultDigestAlgorithm(a string) error {
 	return hs.d.SetDefaultDigestHashAlgorithm(a)
 }
 
+// SetDigestAlgorithmAlias register alias for digest hash algorithm, e.g. "SHA256" for "SHA-256"
+func (hs *HTTPSignatures) SetDigestAlgorithmAlias(alias string, alg string) error {
+	return hs.d.SetDigestHashAlgorithmAlias(alias, alg)
+}
+
+// SetEmptyBodyDigest set digest behaviour for requests without body (EmptyBodyDigestError by default)
+func (hs *HTTPSignatures) SetEmptyBodyDigest(m EmptyBodyDigest) {
+	hs.d.SetEmptyBodyDigest(m)
+}
+
+// SetDigestDecodedBody compute digest over decoded body (by Content-Encoding: gzip, deflate) instead of raw bytes
+// (default) on both create & verify. Decoded body size is limited by max body size (64 MiB if it's not set).
+func (hs *HTTPSignatures) SetDigestDecodedBody(v bool) {
+	hs.d.SetDecodedBody(v)
+}
+
+// SetMaxBodySize limit bodies buffered in memory to create or verify digest, 0 — no limit (default)
+func (hs *HTTPSignatures) SetMaxBodySize(n int64) {
+	hs.d.SetMaxBodySize(n)
+}
+
+// SetStreamedBodyDigest set digest creation for streamed request bodies (unknown length, no GetBody).
+// maxBodySize limits bodies read into memory, 0 — no limit.
+func (hs *HTTPSignatures) SetStreamedBodyDigest(m StreamedBodyDigest, maxBodySize int64) {
+	hs.d.SetStreamedBodyDigest(m, maxBodySize)
+}
+
+// SetStreamedBodySpill spill streamed request bodies larger than threshold bytes to temporary file in dir
+// (os.TempDir() if empty) while digest is created. 0 — disabled (default).
+func (hs *HTTPSignatures) SetStreamedBodySpill(threshold int64, dir string) {
+	hs.d.SetStreamedBodySpill(threshold, dir)
+}
+
 // SetDefaultVerifyDigest set default verify digest or skip verification
 func (hs *HTTPSignatures) SetDefaultVerifyDigest(v bool) {
 	hs.defaultVerifyDigest = v
@@ -95,6 +214,30 @@ func (hs *HTTPSignatures) SetSignatureHashAlgorithm(a SignatureHashAlgorithm) {
 	hs.alg[strings.ToUpper(a.Algorithm())] = a
 }
 
+// SetSignatureAlgorithmAlias register alias for signature hash algorithm, e.g. "RSA-SHA2-256" for "RSA-SHA256".
+// Empty alg means the algorithm is derived from the key (secret algorithm), like "hs2019" (set by default).
+// Names are case-insensitive.
+func (hs *HTTPSignatures) SetSignatureAlgorithmAlias(alias string, alg string) error {
+	if _, ok := hs.alg[strings.ToUpper(alg)]; len(alg) > 0 && !ok {
+		return &ErrHS{
+			Message: fmt.Sprintf("algorithm '%s' not supported", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	hs.algAliases[strings.ToUpper(alias)] = strings.ToUpper(alg)
+	return nil
+}
+
+// resolveAlgorithm return registered algorithm name for name or alias, empty string if algorithm is derived
+// from the key
+func (hs *HTTPSignatures) resolveAlgorithm(name string) string {
+	name = strings.ToUpper(name)
+	if alg, ok := hs.algAliases[name]; ok {
+		return alg
+	}
+	return name
+}
+
 // SetDefaultExpiresSeconds set default expires seconds (while creating signature).
 // If signature never expires just exclude "expires" param from the headers list
 func (hs *HTTPSignatures) SetDefaultExpiresSeconds(e uint32) {
@@ -111,239 +254,725 @@ func (hs *HTTPSignatures) SetDefaultSignatureHeaders(h []string) {
 	hs.defaultHeaders = h
 }
 
+// SetDefaultRealm set realm param added to created signatures (empty realm is omitted)
+func (hs *HTTPSignatures) SetDefaultRealm(realm string) {
+	hs.defaultRealm = realm
+}
+
+// SetHeaderCanonicalization set rules to canonicalize signed header values (both Sign & Verify).
+// By default, values are trimmed & multiple values are joined with ", ".
+func (hs *HTTPSignatures) SetHeaderCanonicalization(c HeaderCanonicalization) {
+	hs.canonicalization = c
+}
+
+// SetDefaultResponseSignatureHeaders set default list of headers to sign responses (ResponseWriter).
+// Responses have no (request-target).
+func (hs *HTTPSignatures) SetDefaultResponseSignatureHeaders(h []string) {
+	hs.defaultResponseHeaders = h
+}
+
+// SetParserMode set Signature header parser mode used by Verify
+func (hs *HTTPSignatures) SetParserMode(m ParserMode) {
+	hs.parserMode = m
+}
+
+// SetMillisecondTimestamps treat too big created & expires values as milliseconds (Verify)
+func (hs *HTTPSignatures) SetMillisecondTimestamps(v bool) {
+	hs.msTimestamps = v
+}
+
+// SetAllowSchemePrefix skip redundant "Signature " keyword in the Signature header (Verify)
+func (hs *HTTPSignatures) SetAllowSchemePrefix(v bool) {
+	hs.schemePrefix = v
+}
+
+// SetBothSignatureHeaders put identical signature to both Signature & Authorization headers of signed requests,
+// for receivers reading only one of them (Sign)
+func (hs *HTTPSignatures) SetBothSignatureHeaders(v bool) {
+	hs.bothHeaders = v
+}
+
+// SetMaxSignatureHeaders set max number of signed headers (64 by default), signatures with longer headers list are
+// rejected & not created. 0 — no limit.
+func (hs *HTTPSignatures) SetMaxSignatureHeaders(n int) {
+	hs.maxSignatureHeaders = n
+}
+
+// SetMaxSignatureStringSize set max length of signature string in bytes (1 MiB by default), building longer string
+// fails on the first header over the limit, so signed headers can't amplify memory usage. 0 — no limit.
+func (hs *HTTPSignatures) SetMaxSignatureStringSize(n int) {
+	hs.maxSignatureString = n
+}
+
+// checkSignatureHeaders check number of signed headers
+func (hs *HTTPSignatures) checkSignatureHeaders(h []string) *ErrHS {
+	if hs.maxSignatureHeaders > 0 && len(h) > hs.maxSignatureHeaders {
+		return &ErrHS{
+			Message: fmt.Sprintf("too many signed headers: %d, max %d", len(h), hs.maxSignatureHeaders),
+			kind:    ErrPolicyViolation,
+		}
+	}
+	return nil
+}
+
+// SetDebug add signature string built by verifier to "wrong signature" error (ErrHS.SignatureString),
+// to compare it with the sender's one. Don't enable it in production: the string contains header values.
+func (hs *HTTPSignatures) SetDebug(debug bool) {
+	hs.debug = debug
+}
+
+// SetLogger set logger for debug messages (signature string, algorithm, key resolution) & failure reasons.
+// Nil logger disables logging (default).
+func (hs *HTTPSignatures) SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	hs.log = l
+}
+
+// SetPolicy set signature verification policy (required signed headers, max signature age)
+func (hs *HTTPSignatures) SetPolicy(p Policy) {
+	hs.policy = p
+}
+
+// SetClock set time source for created/expires (both Sign & Verify), nil resets it to time.Now
+func (hs *HTTPSignatures) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	hs.now = now
+}
+
 // Verify Verify signature
 func (hs *HTTPSignatures) Verify(r *http.Request) error {
+	return hs.VerifyCtx(context.Background(), r)
+}
+
+// VerifyCtx Verify signature, stop verification when ctx is done (ctx is passed to ContextSecrets)
+func (hs *HTTPSignatures) VerifyCtx(ctx context.Context, r *http.Request) error {
+	_, err := hs.VerifyAndIdentifyCtx(ctx, r)
+	return err
+}
+
+// VerifyAndIdentify verify signature & return secret (keyId) which validated it, to authorize the caller
+func (hs *HTTPSignatures) VerifyAndIdentify(r *http.Request) (Secret, error) {
+	return hs.VerifyAndIdentifyCtx(context.Background(), r)
+}
+
+// VerifyAndIdentifyCtx verify signature with context & return secret (keyId) which validated it
+func (hs *HTTPSignatures) VerifyAndIdentifyCtx(ctx context.Context, r *http.Request) (Secret, error) {
+	secret, err := hs.verify(ctx, r, false)
+	if err != nil {
+		hs.log.Error("signature verification failed", "method", r.Method, "uri", r.RequestURI, "err", err)
+		return Secret{}, err
+	}
+	hs.log.Debug("signature verified", "keyId", secret.KeyID, "method", r.Method, "uri", r.RequestURI)
+	return secret, nil
+}
+
+// verify verify signature & return secret of the signature keyId. With streamDigest the body is replaced with
+// reader which verifies digest while it's read, instead of reading the whole body here.
+func (hs *HTTPSignatures) verify(ctx context.Context, r *http.Request, streamDigest bool) (Secret, error) {
+	if hs.onVerifySuccess == nil && hs.onVerifyFailure == nil {
+		return hs.verifyRequest(ctx, r, streamDigest)
+	}
+	start := time.Now()
+	secret, err := hs.verifyRequest(ctx, r, streamDigest)
+	hs.verifyEvent(r, secret, time.Since(start), err)
+	return secret, err
+}
+
+// verifyRequest verify signature of the request without verification events
+func (hs *HTTPSignatures) verifyRequest(ctx context.Context, r *http.Request, streamDigest bool) (Secret, error) {
+	if err := hs.checkContext(ctx); err != nil {
+		return Secret{}, err
+	}
+
+	// Signature in trailer (signed streamed body)
+	if len(r.Header.Get(signatureHeader)) == 0 && hs.isSignedTrailer(r) {
+		return hs.verifyTrailer(ctx, r)
+	}
+
+	verifyDigest := func(h []string) error {
+		if !hs.defaultVerifyDigest {
+			return nil
+		}
+		if streamDigest && hs.hasDigest(h) {
+			body, err := hs.d.newVerifyReader(r)
+			if err != nil {
+				return err
+			}
+			r.Body = body
+			return nil
+		}
+		return hs.verifyDigest(h, r)
+	}
+	return hs.verifyHeader(ctx, r.Header, hs.requestTarget(r), hs.requestHost(r), verifyDigest)
+}
+
+// verifyHeader verify signature of the message headers & return secret of the signature keyId.
+// Empty target means the message has no (request-target), e.g. response.
+func (hs *HTTPSignatures) verifyHeader(ctx context.Context, header http.Header, target string, host string,
+	verifyDigest func(h []string) error) (Secret, error) {
 	// Check signature header
-	h := r.Header.Get(signatureHeader)
+	h := header.Get(signatureHeader)
 	if len(h) == 0 {
-		return &ErrHS{"signature header not found", nil}
+		return Secret{}, &ErrHS{Message: "signature header not found", kind: ErrSignatureHeaderNotFound}
 	}
 
 	// Parse header
-	p := NewParser()
-	sh, pErr := p.ParseSignatureHeader(h)
-	if pErr != nil {
-		return pErr
+	p := hs.getParser()
+	defer putParser(p)
+	sh, err := hs.parseSignature(ctx, p, h, header)
+	if err != nil {
+		return Secret{}, err
 	}
+	hs.log.Debug("signature header parsed", "keyId", sh.KeyID, "algorithm", sh.Algorithm, "headers", sh.Headers)
 
-	// Verify required fields in signature header
-	pErr = p.VerifySignatureFields()
-	if pErr != nil {
-		return pErr
+	// Verify policy
+	if err := hs.checkSignatureHeaders(sh.Headers); err != nil {
+		return Secret{}, err
+	}
+	if err := hs.policy.check(sh, header, hs.now(), hs.defaultTimeGap); err != nil {
+		return Secret{}, err
 	}
 
 	// Verify expires (must be lower than now() +/- time gap)
 	if hs.inHeaders(expires, sh.Headers) {
-		now := time.Now()
+		now := hs.now()
 		max := sh.Expires.Add(hs.defaultTimeGap)
 		if now.After(max) {
-			return &ErrHS{"signature expired", nil}
+			return Secret{}, &ErrHS{Message: "signature expired", kind: ErrSignatureExpired}
 		}
 	}
 
 	// Verify created (can not be in future)
 	if hs.inHeaders(created, sh.Headers) {
-		now := time.Now()
+		now := hs.now()
 		max := now.Add(hs.defaultTimeGap)
 		if sh.Created.After(max) {
-			return &ErrHS{"signature in future", nil}
+			return Secret{}, &ErrHS{Message: "signature in future", kind: ErrSignatureInFuture}
 		}
 	}
 
 	// Verify digest
-	if hs.defaultVerifyDigest {
-		err := hs.verifyDigest(sh.Headers, r)
-		if err != nil {
-			return err
-		}
+	if err := hs.checkContext(ctx); err != nil {
+		return Secret{}, err
+	}
+	if err := verifyDigest(sh.Headers); err != nil {
+		return Secret{}, err
 	}
 
 	// Check keyID & algorithm
-	secret, err := hs.ss.Get(sh.KeyID)
+	if err := hs.checkContext(ctx); err != nil {
+		return Secret{}, err
+	}
+	secret, err := hs.getSecret(ctx, sh.KeyID)
+	if errors.Is(err, ErrKeyResolutionTimeout) {
+		return Secret{}, err
+	}
 	if err != nil {
-		return &ErrHS{fmt.Sprintf("keyID '%s' not found", sh.KeyID), err}
+		return Secret{}, &ErrHS{Message: fmt.Sprintf("keyID '%s' not found", sh.KeyID), Err: err, kind: ErrUnknownKeyID}
 	}
-	if !strings.EqualFold(secret.Algorithm, sh.Algorithm) {
-		return &ErrHS{
-			fmt.Sprintf("wrong algorithm '%s' for keyId '%s'", sh.Algorithm, sh.KeyID),
-			nil,
+	if err := hs.checkKeyValidity(secret); err != nil {
+		return Secret{}, err
+	}
+	if secret, err = hs.certificateKey(ctx, secret); err != nil {
+		return Secret{}, err
+	}
+	if err := hs.checkKeyPin(sh.KeyID, secret); err != nil {
+		return Secret{}, err
+	}
+	if secret.Algorithm, err = hs.secretAlgorithm(secret, false); err != nil {
+		return Secret{}, err
+	}
+	q := hs.quirksFor(sh.KeyID)
+	secretAlg := hs.resolveAlgorithm(secret.Algorithm)
+	// Algorithm param is required (unless quirk allows it), aliases like "hs2019" accept any key algorithm
+	sigAlg := hs.resolveAlgorithm(sh.Algorithm)
+	derived := len(sigAlg) == 0 && (len(sh.Algorithm) > 0 || q.AllowMissingAlgorithm)
+	if !derived && sigAlg != secretAlg {
+		return Secret{}, &ErrHS{
+			Message: fmt.Sprintf("wrong algorithm '%s' for keyId '%s'", sh.Algorithm, sh.KeyID),
+			kind:    ErrAlgorithmMismatch,
 		}
 	}
-	alg, ok := hs.alg[strings.ToUpper(secret.Algorithm)]
+	alg, ok := hs.alg[secretAlg]
+	hs.log.Debug("key resolved", "keyId", sh.KeyID, "algorithm", secret.Algorithm, "supported", ok)
 	if !ok {
-		return &ErrHS{
-			fmt.Sprintf("algorithm '%s' not supported", sh.Algorithm),
-			nil,
+		return Secret{}, &ErrHS{
+			Message: fmt.Sprintf("algorithm '%s' not supported", sh.Algorithm),
+			kind:    ErrUnsupportedAlgorithm,
 		}
 	}
+	verifySecret, err := hs.pinnedSecret(sh.KeyID, secretAlg, secret)
+	if err != nil {
+		return Secret{}, err
+	}
 
 	// Create signature string
-	sigStr, err := hs.buildSignatureString(sh, r)
+	b := getBuffer()
+	defer putBuffer(b)
+	err = hs.writeSignatureStringQuirks(b, sh, header, target, host, q)
 	if err != nil {
-		return &ErrHS{"build signature string error", err}
+		return Secret{}, &ErrHS{Message: "build signature string error", Err: err}
 	}
+	sigStr := b.Bytes()
 	if len(sigStr) == 0 {
-		return &ErrHS{"empty string for signature", nil}
+		return Secret{}, &ErrHS{Message: "empty string for signature"}
 	}
+	hs.log.Debug("signature string built", "headers", sh.Headers, "length", len(sigStr))
 
 	// Verify signature
-	signatureDecoded, err := base64.StdEncoding.DecodeString(sh.Signature)
+	signatureDecoded, err := q.SignatureEncoding.decode(sh.Signature)
 	if err != nil {
-		return &ErrHS{
-			"error decode signature from base64",
-			err,
+		return Secret{}, &ErrHS{
+			Message: "error decode signature from " + q.SignatureEncoding.String(),
+			Err:     err,
+		}
+	}
+	if err := hs.checkContext(ctx); err != nil {
+		return Secret{}, err
+	}
+	var cacheKey string
+	if hs.verified != nil {
+		cacheKey = verifyCacheKey(verifySecret, sh, sigStr)
+		if hs.verified.get(cacheKey, hs.now()) {
+			hs.log.Debug("signature verification cached", "keyId", sh.KeyID)
+			return secret, nil
 		}
 	}
-	err = alg.Verify(secret, sigStr, signatureDecoded)
+	err = alg.Verify(verifySecret, sigStr, signatureDecoded)
 	if err != nil {
-		return &ErrHS{"wrong signature", err}
+		e := &ErrHS{Message: "wrong signature", Err: err, kind: ErrWrongSignature}
+		if hs.debug {
+			e.SignatureString = string(sigStr)
+		}
+		return Secret{}, e
+	}
+	if hs.verified != nil {
+		if exp := hs.verifyCacheExpires(sh, header.Get("Date")); !exp.IsZero() {
+			hs.verified.add(cacheKey, exp)
+		}
 	}
 
-	return nil
+	return secret, nil
+}
+
+// parseSignature parse signature header & verify its required fields. If there are multiple signatures (e.g. of
+// old & new key during key rotation), signatures of keyIds known by the secrets storage are preferred, the first
+// of them is returned or the strongest one if algorithm ranking is set.
+func (hs *HTTPSignatures) parseSignature(ctx context.Context, p *Parser, h string, header http.Header) (Headers,
+	error) {
+	values := header.Values(signatureHeader)
+	if hs.algRanking == nil && len(values) < 2 {
+		sh, err := p.ParseSignatureHeader(h)
+		if err != nil {
+			return Headers{}, err
+		}
+		if err := p.VerifySignatureFields(); err != nil {
+			return Headers{}, err
+		}
+		return sh, nil
+	}
+	signatures, pErr := p.ParseSignatureHeaders(values)
+	if pErr != nil {
+		return Headers{}, pErr
+	}
+	signatures = hs.knownSignatures(ctx, signatures)
+	sh := signatures[0]
+	if hs.algRanking != nil {
+		var err error
+		if sh, err = hs.strongestSignature(signatures); err != nil {
+			return Headers{}, err
+		}
+	}
+	if err := sh.VerifyFields(); err != nil {
+		return Headers{}, err
+	}
+	return sh, nil
+}
+
+// knownSignatures signatures of keyIds known by the secrets storage, all signatures if none is known (verification
+// fails then with the error of the first one)
+func (hs *HTTPSignatures) knownSignatures(ctx context.Context, signatures []Headers) []Headers {
+	if len(signatures) < 2 {
+		return signatures
+	}
+	var known []Headers
+	for _, sh := range signatures {
+		if _, err := hs.getSecret(ctx, sh.KeyID); err == nil {
+			known = append(known, sh)
+		}
+	}
+	if len(known) == 0 {
+		return signatures
+	}
+	return known
 }
 
 // Sign add signature header
 func (hs *HTTPSignatures) Sign(secretKeyID string, r *http.Request) error {
+	return hs.SignCtx(context.Background(), secretKeyID, r)
+}
+
+// SignCtx add signature header, stop signing when ctx is done (ctx is passed to ContextSecrets)
+func (hs *HTTPSignatures) SignCtx(ctx context.Context, secretKeyID string, r *http.Request) error {
+	if hs.d.streamed == StreamedBodyDigestSignedTrailer && isStreamedBody(r) && hs.hasDigest(hs.defaultHeaders) &&
+		len(r.Header.Get(digestHeader)) == 0 {
+		if err := hs.signTrailer(ctx, secretKeyID, r); err != nil {
+			hs.log.Error("signing failed", "keyId", secretKeyID, "method", r.Method, "uri", r.URL.String(), "err", err)
+			return err
+		}
+		hs.log.Debug("request body signed in trailer", "keyId", secretKeyID, "method", r.Method, "uri", r.URL.String())
+		return nil
+	}
+	createDigest := func(h []string) (string, error) {
+		return hs.createDigest(h, r)
+	}
+	err := hs.sign(ctx, secretKeyID, hs.defaultHeaders, r.Header, hs.requestTarget(r), r.Host, createDigest)
+	if err != nil {
+		hs.log.Error("signing failed", "keyId", secretKeyID, "method", r.Method, "uri", r.URL.String(), "err", err)
+		return err
+	}
+	hs.log.Debug("request signed", "keyId", secretKeyID, "method", r.Method, "uri", r.URL.String())
+	return nil
+}
+
+// sign create signature for passed headers list & set Signature (and Digest if required) header.
+// Used to sign requests and responses (responses have no request target & host).
+func (hs *HTTPSignatures) sign(ctx context.Context, secretKeyID string, signatureHeaders []string,
+	header http.Header, target string, host string, createDigest func(h []string) (string, error)) error {
+	if err := hs.checkSignatureHeaders(signatureHeaders); err != nil {
+		return err
+	}
+
 	// Get secret
-	secret, err := hs.ss.Get(secretKeyID)
+	if err := hs.checkContext(ctx); err != nil {
+		return err
+	}
+	secret, err := hs.getSecret(ctx, secretKeyID)
+	if errors.Is(err, ErrKeyResolutionTimeout) {
+		return err
+	}
 	if err != nil {
-		return &ErrHS{fmt.Sprintf("keyId '%s' not found", secretKeyID), err}
+		return &ErrHS{Message: fmt.Sprintf("keyId '%s' not found", secretKeyID), Err: err, kind: ErrUnknownKeyID}
+	}
+	if err := hs.checkKeyValidity(secret); err != nil {
+		return err
 	}
 
-	// Get hash algorithm
-	alg, ok := hs.alg[strings.ToUpper(secret.Algorithm)]
+	// Get hash algorithm, select it by the key type if it's not set
+	if len(hs.signAlgorithm) > 0 {
+		secret.Algorithm = hs.signAlgorithm
+	}
+	if secret.Algorithm, err = hs.secretAlgorithm(secret, true); err != nil {
+		return err
+	}
+	alg, ok := hs.alg[hs.resolveAlgorithm(secret.Algorithm)]
+	hs.log.Debug("key resolved", "keyId", secretKeyID, "algorithm", secret.Algorithm, "supported", ok)
 	if !ok {
 		return &ErrHS{
-			fmt.Sprintf("algorithm '%s' not supported", secret.Algorithm),
-			nil,
+			Message: fmt.Sprintf("algorithm '%s' not supported", secret.Algorithm),
+			kind:    ErrUnsupportedAlgorithm,
 		}
 	}
 
 	// Build signature string
+	// Signatures are created with whole seconds, subsecond precision is used only when passed by the other side
+	now := time.Unix(hs.now().Unix(), 0)
 	headers := Headers{
 		KeyID:     secret.KeyID,
 		Algorithm: secret.Algorithm,
-		Created:   time.Now(),
+		Created:   now,
 		Expires:   time.Time{},
-		Headers:   hs.defaultHeaders,
+		Headers:   signatureHeaders,
+		Realm:     hs.defaultRealm,
+	}
+	// Algorithm param, e.g. "hs2019" instead of the key algorithm
+	if len(hs.algorithmParam) > 0 {
+		if a := hs.resolveAlgorithm(hs.algorithmParam); len(a) > 0 && a != hs.resolveAlgorithm(secret.Algorithm) {
+			return &ErrHS{
+				Message: fmt.Sprintf("wrong algorithm '%s' for keyId '%s'", hs.algorithmParam, secretKeyID),
+				kind:    ErrAlgorithmMismatch,
+			}
+		}
+		headers.Algorithm = strings.ToLower(hs.algorithmParam)
 	}
 	// Expires
 	if hs.defaultExpiresSec != 0 {
-		headers.Expires = time.Now().Add(time.Second * time.Duration(hs.defaultExpiresSec))
+		headers.Expires = now.Add(time.Second * time.Duration(hs.defaultExpiresSec))
 	}
-	// Create digest & set it to request header
+	// Create digest & set it to header
 	// Proceed only if digest header not set
-	digest := r.Header.Get(digestHeader)
+	if err := hs.checkContext(ctx); err != nil {
+		return err
+	}
+	digest := header.Get(digestHeader)
 	if len(digest) == 0 {
-		d, err := hs.createDigest(headers.Headers, r)
+		d, err := createDigest(headers.Headers)
 		if err != nil {
 			return err
 		}
-		r.Header.Set(digestHeader, d)
+		if len(d) > 0 {
+			header.Set(digestHeader, d)
+		} else if hs.hasDigest(headers.Headers) {
+			// Digest of empty body is skipped (EmptyBodyDigestSkip) or sent in trailer, don't sign it
+			headers.Headers = withoutHeader(headers.Headers, digestHeader)
+		}
 	}
 
-	sigStr, err := hs.buildSignatureString(headers, r)
+	b := getBuffer()
+	defer putBuffer(b)
+	err = hs.writeSignatureStringQuirks(b, headers, header, target, host, Quirks{MissingHeaders: hs.quirks.MissingHeaders})
 	if err != nil {
-		return &ErrHS{"build signature string error", err}
+		return &ErrHS{Message: "build signature string error", Err: err}
 	}
+	sigStr := b.Bytes()
+	hs.log.Debug("signature string built", "headers", headers.Headers, "length", len(sigStr))
 
 	// Create signature
+	if err := hs.checkContext(ctx); err != nil {
+		return err
+	}
 	s, err := alg.Create(secret, sigStr)
 	if err != nil {
-		return &ErrHS{"error creating signature", err}
+		return &ErrHS{Message: "error creating signature", Err: err}
 	}
-	headers.Signature = base64.StdEncoding.EncodeToString(s)
+	headers.Signature = hs.quirks.SignatureEncoding.encode(s)
 
 	// Build Signature header
 	sigHeader := hs.buildSignatureHeader(headers)
-	r.Header.Set(signatureHeader, sigHeader)
+	header.Set(signatureHeader, sigHeader)
+	if hs.bothHeaders && len(target) > 0 {
+		header.Set(authorizationHeader, authorizationScheme+" "+sigHeader)
+	}
+
+	return nil
+}
 
+// getSecret get secret with context if storage supports it, within key resolution timeout
+func (hs *HTTPSignatures) getSecret(ctx context.Context, keyID string) (Secret, error) {
+	return hs.getSecretTimeout(ctx, keyID)
+}
+
+// fetchSecret get secret from the storage. Concurrent lookups of the same keyId are collapsed into one storage call.
+func (hs *HTTPSignatures) fetchSecret(ctx context.Context, keyID string) (Secret, error) {
+	return hs.fetches.do(ctx, keyID, func(ctx context.Context) (Secret, error) {
+		if cs, ok := hs.ss.(ContextSecrets); ok {
+			return cs.GetContext(ctx, keyID)
+		}
+		return hs.ss.Get(keyID)
+	})
+}
+
+// checkContext return error if ctx is done (canceled or deadline exceeded)
+func (hs *HTTPSignatures) checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &ErrHS{Message: "context done", Err: err}
+	}
 	return nil
 }
 
+// getParser get parser from the pool configured for signature verification, return it with putParser
+func (hs *HTTPSignatures) getParser() *Parser {
+	p := getParser()
+	p.SetMode(hs.parserMode)
+	p.SetMillisecondTimestamps(hs.msTimestamps)
+	p.SetAllowSchemePrefix(hs.schemePrefix)
+	return p
+}
+
 func (hs *HTTPSignatures) buildSignatureString(sh Headers, r *http.Request) ([]byte, error) {
-	j := len(sh.Headers)
-	headers := r.Header.Clone()
-	var b bytes.Buffer
-	for i, h := range sh.Headers {
+	return hs.buildSignatureStringHeader(sh, r.Header, hs.requestTarget(r))
+}
+
+// buildSignatureStringHeader build signature string from headers. Empty target means the message has no
+// (request-target), e.g. response.
+func (hs *HTTPSignatures) buildSignatureStringHeader(sh Headers, header http.Header, target string) ([]byte, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := hs.writeSignatureString(b, sh, header, target); err != nil {
+		return nil, err
+	}
+	// Buffer is returned to the pool, so copy the result
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+// writeSignatureString write signature string to the buffer without intermediate strings. Empty target means
+// the message has no (request-target), e.g. response.
+func (hs *HTTPSignatures) writeSignatureString(b *bytes.Buffer, sh Headers, header http.Header, target string) error {
+	return hs.writeSignatureStringQuirks(b, sh, header, target, "", Quirks{})
+}
+
+// writeSignatureStringQuirks write signature string with compatibility quirks. Host is used if there's no Host
+// header (server requests & client requests without Host header keep it in http.Request.Host).
+func (hs *HTTPSignatures) writeSignatureStringQuirks(b *bytes.Buffer, sh Headers, header http.Header, target string,
+	host string, q Quirks) error {
+	b.Grow(len(target) + len(sh.Headers)*signatureStringLineSize)
+	var ts [32]byte
+	base := b.Len()
+	for _, h := range sh.Headers {
+		line := b.Len()
+		if line > base {
+			b.WriteByte('\n')
+		}
 		switch h {
 		case requestTarget:
-			b.WriteString(fmt.Sprintf("%s: %s %s", requestTarget, strings.ToLower(r.Method), r.URL.RequestURI()))
+			if len(target) == 0 {
+				return &ErrHS{
+					Message: fmt.Sprintf("param '%s' is not supported for responses", requestTarget),
+				}
+			}
+			b.WriteString(requestTarget + ": ")
+			b.WriteString(target)
 		case created:
 			if sh.Created == time.Unix(0, 0) {
-				return nil, &ErrHS{
-					fmt.Sprintf("param '%s', required in signature, not found", created),
-					nil,
+				return &ErrHS{
+					Message: fmt.Sprintf("param '%s', required in signature, not found", created),
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %d", created, sh.Created.Unix()))
+			b.WriteString(created + ": ")
+			if len(sh.CreatedParam) > 0 {
+				b.WriteString(sh.CreatedParam)
+			} else {
+				b.Write(appendTimestamp(ts[:0], sh.Created))
+			}
 		case expires:
 			if sh.Expires == time.Unix(0, 0) {
-				return nil, &ErrHS{
-					fmt.Sprintf("param '%s', required in signature, not found", expires),
-					nil,
+				return &ErrHS{
+					Message: fmt.Sprintf("param '%s', required in signature, not found", expires),
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %d", expires, sh.Expires.Unix()))
+			b.WriteString(expires + ": ")
+			if len(sh.ExpiresParam) > 0 {
+				b.WriteString(sh.ExpiresParam)
+			} else {
+				b.Write(appendTimestamp(ts[:0], sh.Expires))
+			}
 		default:
-			reqHeader, ok := headers[textproto.CanonicalMIMEHeaderKey(h)]
-			if !ok {
-				return nil, &ErrHS{
-					fmt.Sprintf("header '%s', required in signature, not found", h),
-					nil,
+			reqHeader, ok := headerValues(header, h)
+			if !ok && len(host) > 0 && strings.EqualFold(h, hostHeader) {
+				reqHeader, ok = []string{host}, true
+			}
+			if !ok && q.MissingHeaders == MissingHeadersSkip {
+				b.Truncate(line)
+				continue
+			}
+			if !ok && q.MissingHeaders != MissingHeadersEmpty {
+				return &ErrHS{
+					Message: fmt.Sprintf("header '%s', required in signature, not found", h),
+					kind:    ErrRequiredHeaderNotFound,
 				}
 			}
-			b.WriteString(fmt.Sprintf("%s: %s", strings.ToLower(h), strings.TrimSpace(reqHeader[0])))
+			if q.CaseSensitiveHeaders {
+				b.WriteString(h)
+			} else {
+				writeLower(b, h)
+			}
+			if err := hs.canonicalization.checkValues(h, reqHeader); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			hs.canonicalization.writeTo(b, reqHeader)
 		}
-		if i < j-1 {
-			b.WriteString("\n")
+		if hs.maxSignatureString > 0 && b.Len()-base > hs.maxSignatureString {
+			return &ErrHS{
+				Message: fmt.Sprintf("signature string is longer than %d bytes", hs.maxSignatureString),
+				kind:    ErrPolicyViolation,
+			}
 		}
 	}
-
-	return b.Bytes(), nil
+	return nil
 }
 
-func (hs *HTTPSignatures) buildSignatureHeader(h Headers) string {
-	header := fmt.Sprintf(`%s="%s",`, paramKeyID, h.KeyID)
-	header += fmt.Sprintf(`%s="%s",`, paramAlgorithm, h.Algorithm)
-	if hs.inHeaders(fmt.Sprintf("(%s)", paramCreated), h.Headers) {
-		header += fmt.Sprintf(`%s=%d,`, paramCreated, h.Created.Unix())
+// headerValues find header values by case-insensitive name without allocations
+func headerValues(header http.Header, name string) ([]string, bool) {
+	if v, ok := header[name]; ok {
+		return v, true
 	}
-	if hs.inHeaders(fmt.Sprintf("(%s)", paramExpires), h.Headers) && hs.defaultExpiresSec > 0 {
-		header += fmt.Sprintf(`%s=%d,`, paramExpires, h.Expires.Unix())
+	for k, v := range header {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
 	}
-	if len(h.Headers) > 0 {
-		header += fmt.Sprintf(`%s="%s",`, paramHeaders, strings.Join(h.Headers, " "))
+	return nil, false
+}
+
+// writeLower write ASCII lowercased s to the buffer
+func writeLower(b *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= fromA && c <= toZ {
+			c += froma - fromA
+		}
+		b.WriteByte(c)
 	}
-	header += fmt.Sprintf(`%s="%s"`, paramSignature, h.Signature)
+}
 
-	return header
+// buildSignatureHeader render Signature header from cached static parts (keyId, algorithm, realm, headers),
+// extensions are not rendered
+func (hs *HTTPSignatures) buildSignatureHeader(h Headers) string {
+	if hs.defaultExpiresSec == 0 {
+		h.Expires = time.Time{}
+	}
+	return hs.templates.get(h).build(h.Created, h.Expires, h.Signature)
 }
 
 func (hs *HTTPSignatures) verifyDigest(sh []string, r *http.Request) error {
-	for _, h := range sh {
-		if strings.EqualFold(h, digestHeader) {
-			err := hs.d.Verify(r)
-			if err != nil {
-				return err
-			}
-			break
-		}
+	if hs.hasDigest(sh) {
+		return hs.d.Verify(r)
 	}
 	return nil
 }
 
 func (hs *HTTPSignatures) createDigest(sh []string, r *http.Request) (string, error) {
+	if hs.hasDigest(sh) {
+		return hs.d.Create(hs.d.defaultAlg, r)
+	}
+	return "", nil
+}
+
+// hasDigest check if digest header is in signature headers list
+func (hs *HTTPSignatures) hasDigest(sh []string) bool {
 	for _, h := range sh {
 		if strings.EqualFold(h, digestHeader) {
-			d, err := hs.d.Create(hs.d.defaultAlg, r)
-			if err != nil {
-				return "", err
-			}
-			return d, nil
+			return true
 		}
 	}
-	return "", nil
+	return false
+}
+
+// withoutHeader return copy of headers list without header
+func withoutHeader(h []string, header string) []string {
+	res := make([]string, 0, len(h))
+	for _, v := range h {
+		if !strings.EqualFold(v, header) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// formatTimestamp format (created)/(expires) value as unix time, using decimal notation for subsecond precision
+func formatTimestamp(t time.Time) string {
+	var b [32]byte
+	return string(appendTimestamp(b[:0], t))
+}
+
+// appendTimestamp append formatted (created)/(expires) value to dst
+func appendTimestamp(dst []byte, t time.Time) []byte {
+	dst = strconv.AppendInt(dst, t.Unix(), 10)
+	nsec := t.Nanosecond()
+	if nsec == 0 {
+		return dst
+	}
+	dst = append(dst, dot)
+	for d := 100000000; d > 0 && nsec > 0; d /= 10 {
+		dst = append(dst, byte('0'+nsec/d))
+		nsec %= d
+	}
+	return dst
 }
 
 func (hs *HTTPSignatures) inHeaders(a string, h []string) bool {
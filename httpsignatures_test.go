@@ -1,7 +1,17 @@
 package httpsignatures
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -156,7 +166,8 @@ func TestVerify(t *testing.T) {
 			},
 			want:        false,
 			wantErrType: testErrParserType,
-			wantErrMsg:  "ErrParser: found 'T' — unsupported symbol, expected '\"' or space symbol",
+			wantErrMsg: "ErrParser: found 'T' — unsupported symbol, expected '\"' or space symbol at position 7" +
+				" while reading 'keyId' value",
 		},
 		{
 			name: "Required field not found",
@@ -233,6 +244,7 @@ func TestVerify(t *testing.T) {
 			args: args{
 				r: (func() *http.Request {
 					r := testGetRequest()
+					r.Host = ""
 					r.Header.Set("Signature", `keyId="Test",algorithm="rsa-sha256",headers="`+
 						`host",signature="MTIz"`)
 					return r
@@ -304,6 +316,7 @@ func TestSign(t *testing.T) {
 		defaultDigest     string
 		defaultHeaders    []string
 		defaultExpiresSec uint32
+		defaultRealm      string
 	}
 	tests := []struct {
 		name        string
@@ -415,6 +428,19 @@ func TestSign(t *testing.T) {
 				`cKDYQq+As7GYlb2AYCEhErhyOwsdk4kTfHDO49XFH+qsXg+r71bJlaa6ouQd1GQkIPkDgxJM3RsqQRGbJ94wasWGxJrJGkxV4KaF` +
 				`r6p/i3kMAs4p09q2Sqb/WYbCrpZWUiziA/FUOLfZGWIfDKjLBPuwVmtqZQnc="`,
 		},
+		{
+			name: "Create signature with realm OK",
+			args: args{
+				secretKeyID:    "Test",
+				r:              testGetRequest(),
+				defaultHeaders: []string{requestTarget},
+				defaultRealm:   "example.com",
+			},
+			want: true,
+			wantHeader: `keyId="Test",realm="example.com",algorithm="RSA-SHA256",headers="(request-target)",` +
+				`signature="F4u5JXBc7IPlB0UdNQbHtT3rrKuFFJFmXrndYpHv3ljr/uxnwFr17Qaa4fe6n4zCeHQ9mkQxPNCRyyUr+J1PGcu9n` +
+				`Rg7qucWm+tHV7DrkXknV1ZB7zlY70jAi9EZIcVwQmImC6uOQd9UWcH94cgD+fz48qx4iYyD3LKfQhz26mY="`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -428,6 +454,7 @@ func TestSign(t *testing.T) {
 			if len(tt.args.defaultHeaders) > 0 {
 				hs.SetDefaultSignatureHeaders(tt.args.defaultHeaders)
 			}
+			hs.SetDefaultRealm(tt.args.defaultRealm)
 			err := hs.Sign(tt.args.secretKeyID, tt.args.r)
 			got := err == nil
 			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
@@ -540,6 +567,22 @@ func TestHSBuildSignatureString(t *testing.T) {
 			wantErrType: testHSErrType,
 			wantErrMsg:  "",
 		},
+		{
+			name: "Subsecond created & expires",
+			args: args{
+				ph: Headers{
+					Headers: []string{"(created)", "(expires)"},
+					Created: time.Unix(1402170695, 120000000),
+					Expires: time.Unix(1402170995, 5),
+				},
+				r: (func() *http.Request {
+					r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+					return r
+				})(),
+			},
+			want:        []byte("(created): 1402170695.12\n(expires): 1402170995.000000005"),
+			wantErrType: testHSErrType,
+		},
 		{
 			name: "Has created header with 0 value",
 			args: args{
@@ -773,6 +816,17 @@ func TestHSBuildSignatureHeader(t *testing.T) {
 			want: `keyId="key2",algorithm="alg",created=1591130723,expires=1591130723,headers="(created) (expires)",` +
 				`signature="signature"`,
 		},
+		{
+			name: "Signature string with subsecond created OK",
+			arg: Headers{
+				KeyID:     "key3",
+				Algorithm: "alg",
+				Created:   time.Unix(1591130723, 500000000),
+				Headers:   []string{"(created)"},
+				Signature: "signature",
+			},
+			want: `keyId="key3",algorithm="alg",created=1591130723.5,headers="(created)",signature="signature"`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -813,6 +867,81 @@ func TestHSSetDefaultVerifyDigest(t *testing.T) {
 	}
 }
 
+func TestHSSetMillisecondTimestamps(t *testing.T) {
+	// Signature string is signed with created & expires in milliseconds as sent
+	signatureString := "(request-target): post /foo?param=value&pet=dog\n(created): 1402170695123\n" +
+		"(expires): 4102444800000"
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(signatureString))
+	header := fmt.Sprintf(`keyId="hmac",algorithm="hmac-sha256",created=1402170695123,expires=4102444800000,`+
+		`headers="(request-target) (created) (expires)",signature="%s"`,
+		base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	tests := []struct {
+		name         string
+		msTimestamps bool
+		wantErrMsg   string
+	}{
+		{name: "Milliseconds", msTimestamps: true},
+		{name: "Milliseconds disabled", msTimestamps: false, wantErrMsg: "signature in future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetMillisecondTimestamps(tt.msTimestamps)
+			r := testGetRequest()
+			r.Header.Set(signatureHeader, header)
+			err := hs.Verify(r)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Fatalf("Verify() error = nil, want %s", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestHSSetAllowSchemePrefix(t *testing.T) {
+	tests := []struct {
+		name         string
+		schemePrefix bool
+		wantErrMsg   string
+	}{
+		{name: "Prefix allowed", schemePrefix: true},
+		{
+			name:         "Prefix not allowed",
+			schemePrefix: false,
+			wantErrMsg:   "ErrParser: found 'k' — unsupported symbol, expected '=' or space symbol at position 11",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := testBenchHS()
+			hs.SetAllowSchemePrefix(tt.schemePrefix)
+			r := testBenchRequest()
+			if err := hs.Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			// Signature header sent with Authorization scheme by mistake
+			r.Header.Set(signatureHeader, "Signature "+r.Header.Get(signatureHeader))
+			err := hs.Verify(r)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Fatalf("Verify() error = nil, want %s", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testErrParserType, tt.name, nil, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestHSSetParserMode(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetParserMode(ParserModeStrict)
+	r := testGetRequest()
+	r.Header.Set("Signature", `keyId="Test",foo="bar",signature="c2ln"`)
+	err := hs.Verify(r)
+	wantErrMsg := "ErrParser: unknown param 'foo' at position 22 while reading 'foo' value"
+	assert(t, nil, err, testErrParserType, "Unknown param", nil, wantErrMsg)
+}
+
 func TestHSInHeaders(t *testing.T) {
 	type args struct {
 		h       string
@@ -850,3 +979,581 @@ func TestHSInHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestSignKeepsBody(t *testing.T) {
+	tests := []struct {
+		name string
+		r    func() *http.Request
+	}{
+		{
+			name: "bytes.Reader body",
+			r: func() *http.Request {
+				r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, bytes.NewReader([]byte(testBodyExample)))
+				return r
+			},
+		},
+		{
+			name: "io.Pipe body",
+			r: func() *http.Request {
+				pr, pw := io.Pipe()
+				go func() {
+					_, _ = pw.Write([]byte(testBodyExample))
+					_ = pw.Close()
+				}()
+				r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, pr)
+				return r
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultSignatureHeaders([]string{requestTarget, "digest"})
+			r := tt.r()
+			if err := hs.Sign("Test", r); err != nil {
+				t.Fatalf(tt.name+"\nSign error = %v", err)
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			if string(b) != testBodyExample {
+				t.Errorf(tt.name+"\nbody after Sign = %s, want %s", b, testBodyExample)
+			}
+			// Retry must be possible
+			rc, err := r.GetBody()
+			if err != nil {
+				t.Fatalf(tt.name+"\nGetBody error = %v", err)
+			}
+			r.Body = rc
+			if err := hs.Verify(r); err != nil {
+				t.Errorf(tt.name+"\nVerify error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  time.Time
+		want string
+	}{
+		{
+			name: "Whole seconds",
+			arg:  time.Unix(1402170695, 0),
+			want: "1402170695",
+		},
+		{
+			name: "Milliseconds",
+			arg:  time.Unix(1402170695, 250000000),
+			want: "1402170695.25",
+		},
+		{
+			name: "Nanoseconds",
+			arg:  time.Unix(1402170695, 1),
+			want: "1402170695.000000001",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTimestamp(tt.arg); got != tt.want {
+				t.Errorf(tt.name+"\ngot  = %v,\nwant = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testContextKey struct{}
+
+// testContextSecretsStorage secrets storage which requires a value in context
+type testContextSecretsStorage struct {
+	Secrets
+}
+
+func (s testContextSecretsStorage) GetContext(ctx context.Context, keyID string) (Secret, error) {
+	if ctx.Value(testContextKey{}) == nil {
+		return Secret{}, &ErrSecret{Message: "context value not found"}
+	}
+	return s.Get(keyID)
+}
+
+func TestSignVerifyCtx(t *testing.T) {
+	hs := NewHTTPSignatures(testContextSecretsStorage{testSecretsStorage})
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, "(created)", "digest"})
+	ctx := context.WithValue(context.Background(), testContextKey{}, true)
+
+	r := testGetRequest()
+	if err := hs.SignCtx(ctx, "Test", r); err != nil {
+		t.Fatalf("SignCtx error = %v", err)
+	}
+	if err := hs.VerifyCtx(ctx, r); err != nil {
+		t.Errorf("VerifyCtx error = %v", err)
+	}
+
+	// Context is passed to ContextSecrets
+	err := hs.Verify(r)
+	assert(t, nil, err, testHSErrType, "Context secrets", nil,
+		"keyID 'Test' not found: ErrSecret: context value not found")
+	err = hs.Sign("Test", testGetRequest())
+	assert(t, nil, err, testHSErrType, "Context secrets", nil,
+		"keyId 'Test' not found: ErrSecret: context value not found")
+
+	// Canceled context
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = hs.VerifyCtx(canceled, r)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("VerifyCtx error = %v, want %v", err, context.Canceled)
+	}
+	err = hs.SignCtx(canceled, "Test", testGetRequest())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("SignCtx error = %v, want %v", err, context.Canceled)
+	}
+	assert(t, nil, err, testHSErrType, "Canceled context", nil, "context done: context canceled")
+}
+
+type testLogger struct {
+	debug []string
+	error []string
+}
+
+func (l *testLogger) Debug(msg string, keyvals ...interface{}) {
+	l.debug = append(l.debug, msg)
+}
+
+func (l *testLogger) Error(msg string, keyvals ...interface{}) {
+	l.error = append(l.error, fmt.Sprintf("%s %v", msg, keyvals[len(keyvals)-1]))
+}
+
+func TestHSSetLogger(t *testing.T) {
+	l := &testLogger{}
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetLogger(l)
+
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Fatalf("Verify error = %v", err)
+	}
+	want := []string{
+		"key resolved", "signature string built", "request signed",
+		"signature header parsed", "key resolved", "signature string built", "signature verified",
+	}
+	if !reflect.DeepEqual(l.debug, want) {
+		t.Errorf("Debug messages = %v, want %v", l.debug, want)
+	}
+
+	r.Header.Del("Signature")
+	err := hs.Verify(r)
+	if err == nil || len(l.error) != 1 || l.error[0] != "signature verification failed "+err.Error() {
+		t.Errorf("Error messages = %v, verify error %v", l.error, err)
+	}
+
+	// nil disables logging
+	hs.SetLogger(nil)
+	if err := hs.Sign("Test", testGetRequest()); err != nil {
+		t.Errorf("Sign error = %v", err)
+	}
+}
+
+func TestHSSetDebug(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget})
+
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	r.URL.Path = "/changed"
+
+	var e *ErrHS
+	if err := hs.Verify(r); !errors.As(err, &e) || len(e.SignatureString) != 0 {
+		t.Errorf("Verify error = %v, signature string must be empty", err)
+	}
+
+	hs.SetDebug(true)
+	want := "(request-target): post /changed?param=value&pet=dog"
+	if err := hs.Verify(r); !errors.As(err, &e) || e.SignatureString != want {
+		t.Errorf("Verify error = %v, signature string = %q", err, e.SignatureString)
+	}
+}
+
+func TestHSSetSignatureAlgorithmAlias(t *testing.T) {
+	type args struct {
+		alias     string
+		alg       string
+		algorithm string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        interface{}
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Case insensitive algorithm",
+			args: args{
+				algorithm: "rsa-sha256",
+			},
+		},
+		{
+			name: "hs2019 uses key algorithm",
+			args: args{
+				algorithm: "hs2019",
+			},
+		},
+		{
+			name: "Registered alias",
+			args: args{
+				alias:     "rsa-sha2-256",
+				alg:       "rsa-sha256",
+				algorithm: "RSA-SHA2-256",
+			},
+		},
+		{
+			name: "Alias for another algorithm",
+			args: args{
+				alias:     "rsa-sha2-512",
+				alg:       "rsa-sha512",
+				algorithm: "rsa-sha2-512",
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "wrong algorithm 'rsa-sha2-512' for keyId 'Test'",
+		},
+		{
+			name: "Unknown alias",
+			args: args{
+				algorithm: "rsa-sha2-256",
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "wrong algorithm 'rsa-sha2-256' for keyId 'Test'",
+		},
+		{
+			name: "Alias for unsupported algorithm",
+			args: args{
+				alias: "rsa-sha2-256",
+				alg:   "rsa-sha2",
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "algorithm 'rsa-sha2' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			if len(tt.args.alias) > 0 {
+				if err := hs.SetSignatureAlgorithmAlias(tt.args.alias, tt.args.alg); err != nil {
+					assert(t, nil, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+					return
+				}
+			}
+			r := testGetRequest()
+			if err := hs.Sign("Test", r); err != nil {
+				t.Fatalf("Sign error = %v", err)
+			}
+			r.Header.Set(signatureHeader, strings.Replace(r.Header.Get(signatureHeader),
+				`algorithm="RSA-SHA256"`, `algorithm="`+tt.args.algorithm+`"`, 1))
+			err := hs.Verify(r)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, nil, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifyAndIdentify(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	got, err := hs.VerifyAndIdentify(r)
+	want, _ := testSecretsStorage.Get("Test")
+	assert(t, got, err, testHSErrType, "Valid signature", want, "")
+
+	r.Header.Del(signatureHeader)
+	got, err = hs.VerifyAndIdentify(r)
+	assert(t, got, err, testHSErrType, "No signature", Secret{}, "signature header not found")
+}
+
+var testBenchSecrets = NewSimpleSecretsStorage(map[string]Secret{
+	"hmac": {
+		KeyID:      "hmac",
+		PrivateKey: "secret",
+		PublicKey:  "secret",
+		Algorithm:  algHmacSha256,
+	},
+	"rsa": {
+		KeyID:      "rsa",
+		PrivateKey: testRsaPrivateKey2048,
+		PublicKey:  testRsaPublicKey2048,
+		Algorithm:  algRsaSha256,
+	},
+	"ecdsa": {
+		KeyID:      "ecdsa",
+		PrivateKey: testECDSAPrivateKey,
+		PublicKey:  testECDSAPublicKey,
+		Algorithm:  algEcdsaSha256,
+	},
+	"ed25519": {
+		KeyID:      "ed25519",
+		PrivateKey: testED25519PrivateKey,
+		PublicKey:  testED25519PublicKey,
+		Algorithm:  algED25519,
+	},
+})
+
+var testBenchHeaders = []string{"(request-target)", "(created)", "host", "date", "content-type", "digest"}
+
+func testBenchRequest() *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, strings.NewReader(testBodyExample))
+	r.Header.Set("Host", testHostExample)
+	r.Header.Set("Date", testDateExample)
+	r.Header.Set(testContentTypeHeader, testContentTypeJSON)
+	return r
+}
+
+func testBenchHS() *HTTPSignatures {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders(testBenchHeaders)
+	_ = hs.SetDefaultDigestAlgorithm(algSha256)
+	return hs
+}
+
+func BenchmarkSign(b *testing.B) {
+	for _, keyID := range []string{"hmac", "rsa", "ecdsa", "ed25519"} {
+		b.Run(keyID, func(b *testing.B) {
+			hs := testBenchHS()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := hs.Sign(keyID, testBenchRequest()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	for _, keyID := range []string{"hmac", "rsa", "ecdsa", "ed25519"} {
+		b.Run(keyID, func(b *testing.B) {
+			hs := testBenchHS()
+			hs.SetDefaultExpiresSeconds(0)
+			r := testBenchRequest()
+			if err := hs.Sign(keyID, r); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := hs.Verify(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBuildSignatureString(b *testing.B) {
+	hs := testBenchHS()
+	r := testBenchRequest()
+	r.Header.Set(digestHeader, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	sh := Headers{Created: testValidParsedSignatureHeader.Created, Headers: testBenchHeaders}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := hs.buildSignatureString(sh, r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestHSSetMaxSignatureHeaders(t *testing.T) {
+	headers := []string{"(request-target)", "host", "date"}
+	for i := 0; i < defaultMaxSignatureHeaders; i++ {
+		headers = append(headers, "host")
+	}
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders(headers)
+	r := testBenchRequest()
+	err := hs.Sign("hmac", r)
+	assert(t, nil, err, testHSErrType, "Sign", nil, "too many signed headers: 67, max 64")
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("got error %v, want ErrPolicyViolation", err)
+	}
+
+	hs.SetMaxSignatureHeaders(0)
+	if err = hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err = hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	hs.SetMaxSignatureHeaders(3)
+	err = hs.Verify(r)
+	assert(t, nil, err, testHSErrType, "Verify", nil, "too many signed headers: 67, max 3")
+	if err == nil {
+		t.Errorf("no error, want too many signed headers")
+	}
+}
+
+func TestHSSetMaxSignatureStringSize(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "x-large", "x-large", "x-large"})
+	r := testBenchRequest()
+	r.Header.Set("X-Large", strings.Repeat("a", 512*1024))
+	err := hs.Sign("hmac", r)
+	want := "build signature string error: signature string is longer than 1048576 bytes"
+	assert(t, nil, err, testHSErrType, "Sign", nil, want)
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("got error %v, want ErrPolicyViolation", err)
+	}
+
+	hs.SetMaxSignatureStringSize(0)
+	if err = hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err = hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	hs.SetMaxSignatureStringSize(1024)
+	err = hs.Verify(r)
+	want = "build signature string error: signature string is longer than 1024 bytes"
+	assert(t, nil, err, testHSErrType, "Verify", nil, want)
+	if err == nil {
+		t.Errorf("no error, want signature string is too long")
+	}
+}
+
+func TestSignVerifyRequestHost(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "host"})
+	r, _ := http.NewRequest(http.MethodGet, testHostExampleFullPath, nil)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	// Server requests keep Host header in http.Request.Host
+	r.Header.Del("Host")
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	r.Host = "other.example.com"
+	if err := hs.Verify(r); !errors.Is(err, ErrWrongSignature) {
+		t.Errorf("got error %v, want ErrWrongSignature", err)
+	}
+	// Host header wins over http.Request.Host
+	r.Header.Set("Host", "example.com")
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestSignVerifyRequestHostServer(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "host"})
+	srv := httptest.NewServer(hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("Host")) > 0 {
+			t.Errorf("server request has Host header")
+		}
+	})))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		host       string
+		wantStatus int
+	}{
+		{name: "Signed host", host: "api.example.com", wantStatus: http.StatusOK},
+		{name: "Host changed after signing", host: "other.example.com", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, srv.URL+"/foo", nil)
+			r.Host = "api.example.com"
+			if err := hs.Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			r.Host = tt.host
+			resp, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSignBothHeaders(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetBothSignatureHeaders(true)
+	if err := hs.SetProfile("s2s", Profile{Header: authorizationHeader}); err != nil {
+		t.Fatal(err)
+	}
+	r := testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	sig := r.Header.Get(signatureHeader)
+	if got := r.Header.Get(authorizationHeader); len(sig) == 0 || got != authorizationScheme+" "+sig {
+		t.Errorf("got Authorization %s, want 'Signature %s'", got, sig)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	r = testBenchRequest()
+	if err := hs.SignWithProfile(r, "s2s", "hmac"); err != nil {
+		t.Fatal(err)
+	}
+	sig = r.Header.Get(signatureHeader)
+	if got := r.Header.Get(authorizationHeader); len(sig) == 0 || got != authorizationScheme+" "+sig {
+		t.Errorf("got Authorization %s, want 'Signature %s'", got, sig)
+	}
+
+	hs.SetBothSignatureHeaders(false)
+	r = testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get(authorizationHeader); len(got) > 0 {
+		t.Errorf("got Authorization %s, want empty", got)
+	}
+}
+
+func TestWriteSignatureStringAllocs(t *testing.T) {
+	hs := testBenchHS()
+	r := testBenchRequest()
+	r.Header.Set(digestHeader, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	sh := Headers{Created: time.Unix(1402170695, 500000000), Headers: testBenchHeaders}
+	target := hs.requestTarget(r)
+	allocs := testing.AllocsPerRun(100, func() {
+		b := getBuffer()
+		if err := hs.writeSignatureString(b, sh, r.Header, target); err != nil {
+			t.Fatal(err)
+		}
+		putBuffer(b)
+	})
+	if allocs != 0 {
+		t.Errorf("writeSignatureString allocs = %v, want 0", allocs)
+	}
+}
+
+func BenchmarkWriteSignatureString(b *testing.B) {
+	hs := testBenchHS()
+	r := testBenchRequest()
+	r.Header.Set(digestHeader, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	sh := Headers{Created: testValidParsedSignatureHeader.Created, Headers: testBenchHeaders}
+	target := hs.requestTarget(r)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getBuffer()
+		if err := hs.writeSignatureString(buf, sh, r.Header, target); err != nil {
+			b.Fatal(err)
+		}
+		putBuffer(buf)
+	}
+}
@@ -0,0 +1,72 @@
+package httpsignatures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Identity named signer: keyId, its secret & signing profile, e.g. one per upstream
+type Identity struct {
+	// KeyID keyId of created signatures
+	KeyID string
+	// Secret secret of the identity, looked up in the secrets storage by KeyID if nil
+	Secret *Secret
+	// Profile registered signing profile (see SetProfile), HTTPSignatures defaults if empty
+	Profile string
+}
+
+// SetIdentity register signer identity by name. Profile of the identity must be registered first.
+func (hs *HTTPSignatures) SetIdentity(name string, id Identity) error {
+	if len(id.KeyID) == 0 {
+		return &ErrHS{Message: fmt.Sprintf("keyId of identity '%s' is not set", name)}
+	}
+	if _, ok := hs.profiles[id.Profile]; len(id.Profile) > 0 && !ok {
+		return &ErrHS{Message: fmt.Sprintf("profile '%s' not found", id.Profile)}
+	}
+	if id.Secret != nil {
+		s := *id.Secret
+		s.KeyID = id.KeyID
+		id.Secret = &s
+	}
+	if hs.identities == nil {
+		hs.identities = make(map[string]Identity)
+	}
+	hs.identities[name] = id
+	return nil
+}
+
+// Identity return registered signer identity
+func (hs *HTTPSignatures) Identity(name string) (Identity, bool) {
+	id, ok := hs.identities[name]
+	return id, ok
+}
+
+// SignAs sign request as registered signer identity
+func (hs *HTTPSignatures) SignAs(r *http.Request, identity string) error {
+	return hs.SignAsCtx(context.Background(), r, identity)
+}
+
+// SignAsCtx sign request as registered signer identity, stop signing when ctx is done
+func (hs *HTTPSignatures) SignAsCtx(ctx context.Context, r *http.Request, identity string) error {
+	id, ok := hs.identities[identity]
+	if !ok {
+		return &ErrHS{Message: fmt.Sprintf("identity '%s' not found", identity)}
+	}
+	ps := hs.identitySigner(id)
+	if len(id.Profile) > 0 {
+		return ps.SignWithProfileCtx(ctx, r, id.Profile, id.KeyID)
+	}
+	return ps.SignCtx(ctx, id.KeyID, r)
+}
+
+// identitySigner instance which looks up secret of the identity in its own storage (if it's set)
+func (hs *HTTPSignatures) identitySigner(id Identity) *HTTPSignatures {
+	if id.Secret == nil {
+		return hs
+	}
+	c := *hs
+	c.ss = NewSimpleSecretsStorage(map[string]Secret{id.KeyID: *id.Secret})
+	c.fetches = newSecretFetches()
+	return &c
+}
@@ -0,0 +1,82 @@
+package httpsignatures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         Identity
+		wantErrMsg string
+	}{
+		{name: "OK", id: Identity{KeyID: "hmac"}},
+		{name: "OK with profile", id: Identity{KeyID: "hmac", Profile: "s2s"}},
+		{name: "No keyId", id: Identity{Profile: "s2s"}, wantErrMsg: "keyId of identity 'No keyId' is not set"},
+		{name: "Unknown profile", id: Identity{KeyID: "hmac", Profile: "x"}, wantErrMsg: "profile 'x' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs, err := New(WithSecretsStorage(testBenchSecrets), WithProfile("s2s", Profile{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = hs.SetIdentity(tt.name, tt.id)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Fatalf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if _, ok := hs.Identity(tt.name); ok != (err == nil) {
+				t.Errorf("Identity() ok = %v, want %v", ok, err == nil)
+			}
+		})
+	}
+}
+
+func TestSignAs(t *testing.T) {
+	upstream, err := GenerateSecret("generated", algED25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs, err := New(
+		WithSecretsStorage(testBenchSecrets),
+		WithProfile("s2s", Profile{Headers: []string{"(request-target)", "host"}}),
+		WithIdentity("billing", Identity{KeyID: "hmac"}),
+		WithIdentity("partner", Identity{KeyID: "partner-key", Secret: &upstream, Profile: "s2s"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := testBenchRequest()
+	if err := hs.SignAs(r, "billing"); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get(signatureHeader); !strings.HasPrefix(got, `keyId="hmac",algorithm="HMAC-SHA256"`) {
+		t.Errorf("wrong signature header: %s", got)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	r = testBenchRequest()
+	if err := hs.SignAs(r, "partner"); err != nil {
+		t.Fatal(err)
+	}
+	want := `keyId="partner-key",algorithm="ED25519",headers="(request-target) host",signature="`
+	if got := r.Header.Get(signatureHeader); !strings.HasPrefix(got, want) {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = %v...", got, want)
+	}
+	upstream.KeyID = "partner-key"
+	verifier := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"partner-key": upstream}))
+	if err := verifier.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	err = hs.SignAs(testBenchRequest(), "unknown")
+	assert(t, nil, err, testHSErrType, "Unknown identity", nil, "identity 'unknown' not found")
+	if err == nil {
+		t.Errorf("no error, want identity not found")
+	}
+}
@@ -0,0 +1,35 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"time"
+)
+
+// SignatureInfo signature metadata of the request, not verified
+type SignatureInfo struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string // covered headers
+	Created   time.Time
+	Expires   time.Time
+	Realm     string
+}
+
+// Inspect extract metadata of the request signature (the first one, if there are many) without verifying it, e.g. to
+// pick the tenant or key store before full verification. Nothing is trusted until the signature is verified.
+func (hs *HTTPSignatures) Inspect(r *http.Request) (*SignatureInfo, error) {
+	p := hs.getParser()
+	defer putParser(p)
+	sh, err := p.ParseFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return &SignatureInfo{
+		KeyID:     sh[0].KeyID,
+		Algorithm: sh[0].Algorithm,
+		Headers:   sh[0].Headers,
+		Created:   sh[0].Created,
+		Expires:   sh[0].Expires,
+		Realm:     sh[0].Realm,
+	}, nil
+}
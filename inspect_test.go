@@ -0,0 +1,54 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestInspect(t *testing.T) {
+	signed := testBenchRequest()
+	hs := testBenchHS()
+	if err := hs.Sign("rsa", signed); err != nil {
+		t.Fatal(err)
+	}
+	tampered := testBenchRequest()
+	tampered.Header.Set("Signature", `keyId="other",algorithm="hmac-sha256",created=1591130723,`+
+		`headers="(request-target) (created) host",signature="bm90IGEgc2lnbmF0dXJl"`)
+	noSignature := testBenchRequest()
+	authorization := testBenchRequest()
+	authorization.Header.Set("Authorization", `Signature keyId="auth",signature="c2ln"`)
+
+	tests := []struct {
+		name       string
+		r          *http.Request
+		wantKeyID  string
+		wantAlg    string
+		wantErrMsg string
+	}{
+		{name: "Signed", r: signed, wantKeyID: "rsa", wantAlg: "RSA-SHA256"},
+		{name: "Not verified", r: tampered, wantKeyID: "other", wantAlg: "hmac-sha256"},
+		{name: "Authorization", r: authorization, wantKeyID: "auth"},
+		{name: "No signature", r: noSignature, wantErrMsg: "ErrParser: signature header not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hs.Inspect(tt.r)
+			assert(t, nil, err, testErrParserType, tt.name, nil, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+			if got.KeyID != tt.wantKeyID || got.Algorithm != tt.wantAlg {
+				t.Errorf("Inspect() = %s %s, want %s %s", got.KeyID, got.Algorithm, tt.wantKeyID, tt.wantAlg)
+			}
+		})
+	}
+
+	got, err := hs.Inspect(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Headers) != 3 || !got.Created.Equal(time.Unix(1591130723, 0)) {
+		t.Errorf("Inspect() headers = %v, created = %v", got.Headers, got.Created)
+	}
+}
@@ -0,0 +1,95 @@
+package interop
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+	"github.com/igor-pavlenko/httpsignatures-go/conformance"
+)
+
+func TestRun(t *testing.T) {
+	res := Run(nil)
+	if len(res) != len(Fixtures()) {
+		t.Fatalf("got %d results, want %d", len(res), len(Fixtures()))
+	}
+	for _, r := range res {
+		if r.Err != nil {
+			t.Errorf("%s '%s': %v", r.Fixture.Implementation, r.Fixture.Name, r.Err)
+		}
+	}
+}
+
+func TestReplayTampered(t *testing.T) {
+	h := NewHarness(nil)
+	defer h.Close()
+
+	for _, f := range Fixtures() {
+		f.Header["Date"] = "Mon, 06 Jan 2014 21:31:40 GMT"
+		if err := h.Replay(f); err == nil || err.Error() != "status 401: Unauthorized" {
+			t.Errorf("%s '%s': got error %v, want status 401", f.Implementation, f.Name, err)
+		}
+	}
+
+	f := Fixtures()[1]
+	f.Body = `{"hello": "cat!!"}`
+	if err := h.Replay(f); err == nil || !strings.HasPrefix(err.Error(), "status 400: ErrDigest: wrong digest") {
+		t.Errorf("got error %v, want status 400", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	h := NewHarness(func(hs *httpsignatures.HTTPSignatures) {
+		hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "host", "digest"})
+		_ = hs.SetDefaultDigestAlgorithm("SHA-256")
+	})
+	defer h.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "https://example.com/foo", strings.NewReader(`{"hello": "world"}`))
+	if err := h.RoundTrip(conformance.TestKeyID, r); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	r, _ = http.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+	if err := h.RoundTrip("unknown", r); err == nil {
+		t.Errorf("no error, want unknown keyId")
+	}
+}
+
+// TestFixturesCaptured check fixtures are the requests captured by testdata/capture-node.js
+func TestFixturesCaptured(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/node-http-signature.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var captured struct {
+		Version  string
+		Requests []struct {
+			Method  string
+			Target  string
+			Headers map[string]string
+			Body    string
+		}
+	}
+	if err := json.Unmarshal(b, &captured); err != nil {
+		t.Fatal(err)
+	}
+	fixtures := Fixtures()
+	if len(captured.Requests) != len(fixtures) {
+		t.Fatalf("got %d captured requests, want %d", len(captured.Requests), len(fixtures))
+	}
+	for i, f := range fixtures {
+		c := captured.Requests[i]
+		if f.Implementation != "node http-signature "+captured.Version || f.Method != c.Method ||
+			f.Target != c.Target || f.Host != c.Headers["host"] || f.Body != c.Body {
+			t.Errorf("%s: fixture differs from captured request", f.Name)
+		}
+		for k, v := range f.Header {
+			if c.Headers[strings.ToLower(k)] != v {
+				t.Errorf("%s: header %s = %s, captured %s", f.Name, k, v, c.Headers[strings.ToLower(k)])
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package httpsignatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// Key types of secrets without algorithm, see SetKeyTypeAlgorithm
+const (
+	KeyTypeRSA     = "RSA"
+	KeyTypeECP256  = "EC P-256"
+	KeyTypeECP521  = "EC P-521"
+	KeyTypeEd25519 = "Ed25519"
+)
+
+// defaultKeyTypeAlgorithms algorithms of secrets without algorithm by key type
+var defaultKeyTypeAlgorithms = map[string]string{
+	KeyTypeRSA:     algRsaSsaPssSha512,
+	KeyTypeECP256:  algEcdsaSha256,
+	KeyTypeECP521:  algEcdsaSha512,
+	KeyTypeEd25519: algED25519,
+}
+
+// SetKeyTypeAlgorithm set algorithm of secrets without algorithm by key type (KeyTypeRSA etc.).
+// By default: RSA — RSASSA-PSS-SHA512, EC P-256 — ECDSA-SHA256, EC P-521 — ECDSA-SHA512, Ed25519 — ED25519.
+func (hs *HTTPSignatures) SetKeyTypeAlgorithm(keyType string, alg string) error {
+	if _, ok := defaultKeyTypeAlgorithms[keyType]; !ok {
+		return &ErrHS{Message: fmt.Sprintf("unknown key type '%s'", keyType)}
+	}
+	if _, ok := hs.alg[hs.resolveAlgorithm(alg)]; !ok {
+		return &ErrHS{Message: fmt.Sprintf("algorithm '%s' not supported", alg), kind: ErrUnsupportedAlgorithm}
+	}
+	m := make(map[string]string, len(defaultKeyTypeAlgorithms))
+	for k, v := range defaultKeyTypeAlgorithms {
+		m[k] = v
+	}
+	for k, v := range hs.keyTypeAlgorithms {
+		m[k] = v
+	}
+	m[keyType] = alg
+	hs.keyTypeAlgorithms = m
+	return nil
+}
+
+// secretAlgorithm secret algorithm, selected by the key type if it's not set. Private key is used to sign,
+// public key to verify.
+func (hs *HTTPSignatures) secretAlgorithm(secret Secret, private bool) (string, error) {
+	if len(secret.Algorithm) > 0 {
+		return secret.Algorithm, nil
+	}
+	pk := secret.PublicKey
+	if private {
+		pk = secret.PrivateKey
+	}
+	t, err := keyType(pk, private)
+	if err != nil {
+		return "", &ErrHS{
+			Message: fmt.Sprintf("algorithm of keyId '%s' is not set", secret.KeyID),
+			Err:     err,
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	if alg, ok := hs.keyTypeAlgorithms[t]; ok {
+		return alg, nil
+	}
+	return defaultKeyTypeAlgorithms[t], nil
+}
+
+// keyType type of PEM encoded private (PKCS#8, PKCS#1, SEC 1) or public (PKIX) key
+func keyType(pk string, private bool) (string, error) {
+	block, _ := pem.Decode([]byte(pk))
+	if block == nil {
+		return "", &ErrCrypto{Message: "no PEM encoded key found"}
+	}
+	var key interface{}
+	var err error
+	switch {
+	case !private:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case block.Type == "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case block.Type == "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return "", &ErrCrypto{Message: "error parsing key", Err: err}
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return KeyTypeRSA, nil
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return KeyTypeEd25519, nil
+	case *ecdsa.PrivateKey:
+		return curveKeyType(k.Curve)
+	case *ecdsa.PublicKey:
+		return curveKeyType(k.Curve)
+	}
+	return "", &ErrCrypto{Message: fmt.Sprintf("unsupported key type %T", key)}
+}
+
+func curveKeyType(c elliptic.Curve) (string, error) {
+	switch c {
+	case elliptic.P256():
+		return KeyTypeECP256, nil
+	case elliptic.P521():
+		return KeyTypeECP521, nil
+	}
+	return "", &ErrCrypto{Message: fmt.Sprintf("unsupported curve %s", c.Params().Name)}
+}
@@ -0,0 +1,78 @@
+package httpsignatures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyTypeAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		alg     string
+		setType string
+		setAlg  string
+		want    string
+	}{
+		{name: "RSA", alg: algRsaSha256, want: algRsaSsaPssSha512},
+		{name: "EC P-256", alg: algEcdsaSha256, want: algEcdsaSha256},
+		{name: "EC P-521", alg: algEcdsaSha512, want: algEcdsaSha512},
+		{name: "Ed25519", alg: algED25519, want: algED25519},
+		{name: "RSA configured", alg: algRsaSha256, setType: KeyTypeRSA, setAlg: algRsaSha256, want: algRsaSha256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := GenerateSecret("auto", tt.alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Algorithm = ""
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"auto": s}))
+			if len(tt.setType) > 0 {
+				if err := hs.SetKeyTypeAlgorithm(tt.setType, tt.setAlg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := testBenchRequest()
+			if err := hs.Sign("auto", r); err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if want := `algorithm="` + strings.ToLower(tt.want) + `"`; !strings.Contains(
+				strings.ToLower(r.Header.Get("Signature")), want) {
+				t.Errorf("Signature = %s, want %s", r.Header.Get("Signature"), want)
+			}
+			if err := hs.Verify(r); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSetKeyTypeAlgorithm(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyType    string
+		alg        string
+		wantErrMsg string
+	}{
+		{name: "OK", keyType: KeyTypeECP256, alg: algEcdsaSha512},
+		{name: "Unknown key type", keyType: "DSA", alg: algRsaSha256, wantErrMsg: "unknown key type 'DSA'"},
+		{name: "Unknown algorithm", keyType: KeyTypeRSA, alg: "X", wantErrMsg: "algorithm 'X' not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{}))
+			err := hs.SetKeyTypeAlgorithm(tt.keyType, tt.alg)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestKeyTypeAlgorithmHMAC(t *testing.T) {
+	hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+		"hmac": {KeyID: "hmac", PrivateKey: "secret"},
+	}))
+	err := hs.Sign("hmac", testBenchRequest())
+	if err == nil || !strings.HasPrefix(err.Error(), "algorithm of keyId 'hmac' is not set") {
+		t.Errorf("Sign() error = %v, want algorithm of keyId 'hmac' is not set", err)
+	}
+}
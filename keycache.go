@@ -0,0 +1,62 @@
+package httpsignatures
+
+import "sync"
+
+// maxKeyCacheSize cache is cleared when it's full
+const maxKeyCacheSize = 1024
+
+// keyCache parsed keys by key material (PEM), not by keyId: caches are shared by all HTTPSignatures, so keys
+// returned by one secrets storage are never used for the same keyId of another one. Parsing errors are not cached.
+type keyCache struct {
+	mu   sync.RWMutex
+	keys map[string]interface{}
+}
+
+// Caches per key format, parsed key types differ
+var (
+	publicKeys        = newKeyCache()
+	privateKeys       = newKeyCache()
+	ed25519PublicKeys = newKeyCache()
+	ed25519PrivKeys   = newKeyCache()
+)
+
+func newKeyCache() *keyCache {
+	return &keyCache{keys: make(map[string]interface{})}
+}
+
+// load return cached key parsed from pem or parse pem & cache it
+func (c *keyCache) load(pem string, parse func(pem string) (interface{}, error)) (interface{}, error) {
+	c.mu.RLock()
+	key, ok := c.keys[pem]
+	c.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := parse(pem)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if len(c.keys) >= maxKeyCacheSize {
+		c.keys = make(map[string]interface{})
+	}
+	c.keys[pem] = key
+	c.mu.Unlock()
+	return key, nil
+}
+
+func (c *keyCache) reset() {
+	c.mu.Lock()
+	c.keys = make(map[string]interface{})
+	c.mu.Unlock()
+}
+
+// ResetKeyCache remove all parsed keys from the cache, e.g. after keys rotation.
+// Rotated keys are parsed anyway, as the cache is looked up by key value.
+func ResetKeyCache() {
+	publicKeys.reset()
+	privateKeys.reset()
+	ed25519PublicKeys.reset()
+	ed25519PrivKeys.reset()
+}
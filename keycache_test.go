@@ -0,0 +1,92 @@
+package httpsignatures
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"testing"
+)
+
+func TestKeyCacheLoad(t *testing.T) {
+	c := newKeyCache()
+	parsed := 0
+	parse := func(pem string) (interface{}, error) {
+		parsed++
+		return loadPublicKey(pem)
+	}
+
+	k1, err := c.load(testRsaPublicKey1024, parse)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	k2, err := c.load(testRsaPublicKey1024, parse)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if parsed != 1 || k1.(*rsa.PublicKey) != k2.(*rsa.PublicKey) {
+		t.Errorf("key is not cached, parsed %d times", parsed)
+	}
+
+	k3, err := c.load(testRsaPublicKey2048, parse)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if parsed != 2 || k3.(*rsa.PublicKey).Size() != 256 {
+		t.Errorf("new key value is not parsed")
+	}
+	k1, _ = c.load(testRsaPublicKey1024, parse)
+	if parsed != 2 || k1.(*rsa.PublicKey).Size() != 128 {
+		t.Errorf("key is evicted by another key value, parsed %d times", parsed)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = c.load("wrong key", parse); err == nil {
+			t.Errorf("error expected")
+		}
+	}
+	if parsed != 4 {
+		t.Errorf("parsing error is cached")
+	}
+
+	c.reset()
+	_, _ = c.load(testRsaPublicKey2048, parse)
+	if parsed != 5 {
+		t.Errorf("cache is not reset")
+	}
+}
+
+func TestKeyCacheInstances(t *testing.T) {
+	hs1 := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+		"Test": {KeyID: "Test", PrivateKey: testRsaPrivateKey1024, PublicKey: testRsaPublicKey1024, Algorithm: algRsaSha256},
+	}))
+	hs2 := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+		"Test": {KeyID: "Test", PrivateKey: testRsaPrivateKey2048, PublicKey: testRsaPublicKey2048, Algorithm: algRsaSha256},
+	}))
+
+	for i := 0; i < 2; i++ {
+		r1, _ := http.NewRequest(http.MethodGet, testHostExamplePath, nil)
+		if err := hs1.Sign("Test", r1); err != nil {
+			t.Fatal(err)
+		}
+		r2, _ := http.NewRequest(http.MethodGet, testHostExamplePath, nil)
+		if err := hs2.Sign("Test", r2); err != nil {
+			t.Fatal(err)
+		}
+		if err := hs1.Verify(r1); err != nil {
+			t.Errorf("hs1.Verify() error = %v", err)
+		}
+		if err := hs2.Verify(r2); err != nil {
+			t.Errorf("hs2.Verify() error = %v", err)
+		}
+		if err := hs2.Verify(r1); err == nil {
+			t.Errorf("hs2.Verify() of hs1 signature: no error")
+		}
+	}
+}
+
+func BenchmarkKeyCacheLoad(b *testing.B) {
+	c := newKeyCache()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.load(testRsaPublicKey2048, loadPublicKey)
+	}
+}
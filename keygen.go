@@ -0,0 +1,145 @@
+package httpsignatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Generated RSA key size
+const generatedRsaKeyBits = 2048
+
+// JWK public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwkAlgorithms JWA names of signature algorithms
+var jwkAlgorithms = map[string]string{
+	algRsaSha256:       "RS256",
+	algRsaSha512:       "RS512",
+	algRsaSsaPssSha256: "PS256",
+	algRsaSsaPssSha512: "PS512",
+	algEcdsaSha256:     "ES256",
+	algEcdsaSha512:     "ES512",
+	algED25519:         "EdDSA",
+}
+
+// GenerateSecret generate key for the signature algorithm & return it as Secret with PEM encoded keys, e.g. for
+// tests or onboarding: RSA 2048 bits (PKCS #1 private key), ECDSA P-256 or P-521 (SEC 1 private key),
+// ED25519 (PKCS #8 private key), public keys are PKIX. HMAC secret is 32 or 64 random bytes, base64 encoded.
+func GenerateSecret(keyID string, algorithm string) (Secret, error) {
+	s := Secret{KeyID: keyID, Algorithm: strings.ToUpper(algorithm)}
+	var private, public interface{}
+	switch s.Algorithm {
+	case algHmacSha256, algHmacSha512:
+		size := 32
+		if s.Algorithm == algHmacSha512 {
+			size = 64
+		}
+		b := make([]byte, size)
+		if _, err := rand.Read(b); err != nil {
+			return Secret{}, &ErrCrypto{Message: "error generating key", Err: err}
+		}
+		s.PrivateKey = base64.StdEncoding.EncodeToString(b)
+		s.PublicKey = s.PrivateKey
+		return s, nil
+	case algRsaSha256, algRsaSha512, algRsaSsaPssSha256, algRsaSsaPssSha512:
+		k, err := rsa.GenerateKey(rand.Reader, generatedRsaKeyBits)
+		if err != nil {
+			return Secret{}, &ErrCrypto{Message: "error generating key", Err: err}
+		}
+		s.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}))
+		public = &k.PublicKey
+	case algEcdsaSha256, algEcdsaSha512:
+		curve := elliptic.P256()
+		if s.Algorithm == algEcdsaSha512 {
+			curve = elliptic.P521()
+		}
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return Secret{}, &ErrCrypto{Message: "error generating key", Err: err}
+		}
+		private, public = k, &k.PublicKey
+	case algED25519:
+		pub, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return Secret{}, &ErrCrypto{Message: "error generating key", Err: err}
+		}
+		private, public = k, pub
+	default:
+		return Secret{}, &ErrCrypto{Message: fmt.Sprintf("unsupported algorithm type %s", algorithm)}
+	}
+
+	switch k := private.(type) {
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return Secret{}, &ErrCrypto{Message: "error marshal private key", Err: err}
+		}
+		s.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}))
+	case ed25519.PrivateKey:
+		b, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return Secret{}, &ErrCrypto{Message: "error marshal private key", Err: err}
+		}
+		s.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b}))
+	}
+	b, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return Secret{}, &ErrCrypto{Message: "error marshal public key", Err: err}
+	}
+	s.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}))
+	return s, nil
+}
+
+// PublicJWK export secret public key in JSON Web Key format, HMAC secrets are not exported
+func PublicJWK(s Secret) (JWK, error) {
+	jwk := JWK{Kid: s.KeyID, Use: "sig", Alg: jwkAlgorithms[strings.ToUpper(s.Algorithm)]}
+	var key interface{}
+	var err error
+	if strings.EqualFold(s.Algorithm, algED25519) {
+		key, err = loadED25519PublicKey(s.PublicKey)
+	} else {
+		key, err = loadPublicKey(s.PublicKey)
+	}
+	if err != nil {
+		return JWK{}, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	default:
+		return JWK{}, &ErrCrypto{Message: "unknown public key type"}
+	}
+	return jwk, nil
+}
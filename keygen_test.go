@@ -0,0 +1,104 @@
+package httpsignatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		kty       string
+		crv       string
+		alg       string
+	}{
+		{name: "RSA-SHA256", algorithm: "RSA-SHA256", kty: "RSA", alg: "RS256"},
+		{name: "RSASSA-PSS-SHA512", algorithm: "RSASSA-PSS-SHA512", kty: "RSA", alg: "PS512"},
+		{name: "ECDSA-SHA256", algorithm: "ECDSA-SHA256", kty: "EC", crv: "P-256", alg: "ES256"},
+		{name: "ECDSA-SHA512", algorithm: "ecdsa-sha512", kty: "EC", crv: "P-521", alg: "ES512"},
+		{name: "ED25519", algorithm: "ED25519", kty: "OKP", crv: "Ed25519", alg: "EdDSA"},
+		{name: "HMAC-SHA512", algorithm: "HMAC-SHA512"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := GenerateSecret("key", tt.algorithm)
+			if err != nil {
+				t.Fatalf("GenerateSecret() error = %v", err)
+			}
+
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"key": s}))
+			r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+			r.Header.Set("Date", testDateExample)
+			if err := hs.Sign("key", r); err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if err := hs.Verify(r); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			jwk, err := PublicJWK(s)
+			if len(tt.kty) == 0 {
+				if err == nil {
+					t.Errorf("PublicJWK() expected error for HMAC secret")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PublicJWK() error = %v", err)
+			}
+			if jwk.Kty != tt.kty || jwk.Crv != tt.crv || jwk.Alg != tt.alg || jwk.Kid != "key" {
+				t.Errorf("PublicJWK() = %+v", jwk)
+			}
+			if _, err := json.Marshal(jwk); err != nil {
+				t.Errorf("json.Marshal() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateSecretUnsupported(t *testing.T) {
+	_, err := GenerateSecret("key", "DSA-SHA1")
+	assert(t, nil, err, testErrCryptoType, "GenerateSecret", nil, "ErrCrypto: unsupported algorithm type DSA-SHA1")
+	if err == nil {
+		t.Errorf("GenerateSecret() expected error")
+	}
+}
+
+func TestPublicJWKKeys(t *testing.T) {
+	for _, alg := range []string{"RSA-SHA256", "ECDSA-SHA256", "ED25519"} {
+		s, err := GenerateSecret("key", alg)
+		if err != nil {
+			t.Fatalf("GenerateSecret() error = %v", err)
+		}
+		jwk, _ := PublicJWK(s)
+		var key interface{}
+		if alg == "ED25519" {
+			key, _ = loadED25519PublicKey(s.PublicKey)
+		} else {
+			key, _ = loadPublicKey(s.PublicKey)
+		}
+		x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+			if string(n) != string(k.N.Bytes()) || jwk.E != "AQAB" {
+				t.Errorf("%s: wrong RSA modulus or exponent", alg)
+			}
+		case *ecdsa.PublicKey:
+			if len(x) != 32 || string(x) != string(k.X.FillBytes(make([]byte, 32))) {
+				t.Errorf("%s: wrong EC x coordinate", alg)
+			}
+		case ed25519.PublicKey:
+			if string(x) != string(k) {
+				t.Errorf("%s: wrong Ed25519 key", alg)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ociFingerprint OCI API key fingerprint, e.g. "20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34"
+var ociFingerprint = regexp.MustCompile(`^[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){15}$`)
+
+// KeyIDParts components of structured keyId, for secrets storages & policies
+type KeyIDParts struct {
+	// URL absolute URL keyId (with scheme & host), e.g. "https://example.com/users/alice#main-key", nil otherwise
+	URL *url.URL
+	// Fragment URL fragment, e.g. "main-key"
+	Fragment string
+	// Parts "/" separated parts of not URL keyId, e.g. ["tenant", "key-v2"]
+	Parts []string
+}
+
+// ParseKeyID parse keyId: absolute URL with optional fragment or "/" separated parts (e.g. "tenant/key-v2").
+// Empty parts are not allowed.
+func ParseKeyID(keyID string) (KeyIDParts, error) {
+	if len(keyID) == 0 {
+		return KeyIDParts{}, &ErrHS{Message: "empty keyId"}
+	}
+	if u, err := url.Parse(keyID); err == nil && u.IsAbs() && len(u.Host) > 0 {
+		return KeyIDParts{URL: u, Fragment: u.Fragment}, nil
+	}
+	parts := strings.Split(keyID, "/")
+	for _, p := range parts {
+		if len(p) == 0 {
+			return KeyIDParts{}, &ErrHS{Message: fmt.Sprintf("empty part of keyId '%s'", keyID)}
+		}
+	}
+	return KeyIDParts{Parts: parts}, nil
+}
+
+// Document URL of URL keyId without fragment, e.g. actor or key document "https://example.com/users/alice"
+func (k KeyIDParts) Document() string {
+	if k.URL == nil {
+		return ""
+	}
+	u := *k.URL
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// Tenant first part of keyId with many parts, e.g. "tenant" of "tenant/key-v2"
+func (k KeyIDParts) Tenant() string {
+	if len(k.Parts) < 2 {
+		return ""
+	}
+	return k.Parts[0]
+}
+
+// Key last part of not URL keyId, e.g. "key-v2" of "tenant/key-v2"
+func (k KeyIDParts) Key() string {
+	if len(k.Parts) == 0 {
+		return ""
+	}
+	return k.Parts[len(k.Parts)-1]
+}
+
+// OCIKeyID Oracle Cloud Infrastructure style keyId "tenancy/user/fingerprint"
+type OCIKeyID struct {
+	Tenancy     string
+	User        string
+	Fingerprint string
+}
+
+// ParseOCIKeyID parse "tenancy/user/fingerprint" keyId, fingerprint is MD5 of the key (16 hex pairs separated by ':')
+func ParseOCIKeyID(keyID string) (OCIKeyID, error) {
+	k, err := ParseKeyID(keyID)
+	if err != nil {
+		return OCIKeyID{}, err
+	}
+	if len(k.Parts) != 3 || !ociFingerprint.MatchString(k.Parts[2]) {
+		return OCIKeyID{}, &ErrHS{Message: fmt.Sprintf("keyId '%s' is not tenancy/user/fingerprint", keyID)}
+	}
+	return OCIKeyID{Tenancy: k.Parts[0], User: k.Parts[1], Fingerprint: k.Parts[2]}, nil
+}
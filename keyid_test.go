@@ -0,0 +1,66 @@
+package httpsignatures
+
+import "testing"
+
+func TestParseKeyID(t *testing.T) {
+	tests := []struct {
+		name         string
+		keyID        string
+		wantDocument string
+		wantFragment string
+		wantTenant   string
+		wantKey      string
+		wantErrMsg   string
+	}{
+		{name: "URL with fragment", keyID: "https://example.com/users/alice#main-key",
+			wantDocument: "https://example.com/users/alice", wantFragment: "main-key"},
+		{name: "URL", keyID: "https://example.com/keys/1", wantDocument: "https://example.com/keys/1"},
+		{name: "Tenant & key", keyID: "tenant/key-v2", wantTenant: "tenant", wantKey: "key-v2"},
+		{name: "Plain", keyID: "Test", wantKey: "Test"},
+		{name: "Relative path", keyID: "/keys/1", wantErrMsg: "empty part of keyId '/keys/1'"},
+		{name: "Empty part", keyID: "tenant//key", wantErrMsg: "empty part of keyId 'tenant//key'"},
+		{name: "Empty", keyID: "", wantErrMsg: "empty keyId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyID(tt.keyID)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+			if got.Document() != tt.wantDocument || got.Fragment != tt.wantFragment || got.Tenant() != tt.wantTenant ||
+				got.Key() != tt.wantKey {
+				t.Errorf("ParseKeyID() = %s, %s, %s, %s, want %s, %s, %s, %s", got.Document(), got.Fragment,
+					got.Tenant(), got.Key(), tt.wantDocument, tt.wantFragment, tt.wantTenant, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestParseOCIKeyID(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyID      string
+		want       OCIKeyID
+		wantErrMsg string
+	}{
+		{
+			name:  "Valid",
+			keyID: "ocid1.tenancy.oc1..aaa/ocid1.user.oc1..bbb/20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34",
+			want: OCIKeyID{
+				Tenancy:     "ocid1.tenancy.oc1..aaa",
+				User:        "ocid1.user.oc1..bbb",
+				Fingerprint: "20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34",
+			},
+		},
+		{name: "Wrong fingerprint", keyID: "t/u/20:3b",
+			wantErrMsg: "keyId 't/u/20:3b' is not tenancy/user/fingerprint"},
+		{name: "Two parts", keyID: "tenant/key", wantErrMsg: "keyId 'tenant/key' is not tenancy/user/fingerprint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOCIKeyID(tt.keyID)
+			assert(t, got, err, testHSErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package httpsignatures
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// SetKeyPin pin public key of keyId by SPKI SHA-256 fingerprint (base64, see KeyFingerprint): verification fails
+// if the secrets storage returns another key, e.g. when remote key store is compromised. Empty fingerprint removes
+// the pin.
+func (hs *HTTPSignatures) SetKeyPin(keyID string, fingerprint string) error {
+	pins := make(map[string][]byte, len(hs.keyPins)+1)
+	for k, v := range hs.keyPins {
+		pins[k] = v
+	}
+	if len(fingerprint) == 0 {
+		delete(pins, keyID)
+		hs.keyPins = pins
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(fingerprint)
+	if err != nil || len(b) != sha256.Size {
+		return &ErrHS{Message: fmt.Sprintf("wrong fingerprint of keyId '%s'", keyID), Err: err}
+	}
+	pins[keyID] = b
+	hs.keyPins = pins
+	return nil
+}
+
+// KeyFingerprint SPKI SHA-256 fingerprint (base64) of PEM encoded public key (PKIX), e.g. for SetKeyPin
+func KeyFingerprint(publicKey string) (string, error) {
+	fp, err := keyFingerprint(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(fp), nil
+}
+
+// checkKeyPin check the secret returned for pinned keyId has the same keyId & its public key matches the pin. The pin
+// is looked up by keyId of the signature, not of the secret, which could be set by the compromised storage.
+func (hs *HTTPSignatures) checkKeyPin(keyID string, secret Secret) error {
+	pin, ok := hs.keyPins[keyID]
+	if !ok {
+		return nil
+	}
+	if secret.KeyID != keyID {
+		return &ErrHS{
+			Message: fmt.Sprintf("secrets storage returned keyId '%s' for pinned keyId '%s'", secret.KeyID, keyID),
+			kind:    ErrKeyPinMismatch,
+		}
+	}
+	fp, err := keyFingerprint(secret.PublicKey)
+	if err != nil || subtle.ConstantTimeCompare(fp, pin) != 1 {
+		return &ErrHS{
+			Message: fmt.Sprintf("public key of keyId '%s' doesn't match the pin", keyID),
+			Err:     err,
+			kind:    ErrKeyPinMismatch,
+		}
+	}
+	return nil
+}
+
+// pinnedSecret secret to verify signature of pinned keyId with: only the pinned public key is used. Private key
+// (or HMAC secret) isn't covered by the pin, so it's removed & symmetric algorithms are rejected.
+func (hs *HTTPSignatures) pinnedSecret(keyID string, alg string, secret Secret) (Secret, error) {
+	if _, ok := hs.keyPins[keyID]; !ok {
+		return secret, nil
+	}
+	if alg == algHmacSha256 || alg == algHmacSha512 {
+		return Secret{}, &ErrHS{
+			Message: fmt.Sprintf("symmetric algorithm '%s' for pinned keyId '%s'", alg, keyID),
+			kind:    ErrKeyPinMismatch,
+		}
+	}
+	secret.PrivateKey = ""
+	return secret, nil
+}
+
+func keyFingerprint(publicKey string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, &ErrCrypto{Message: "no PEM encoded key found"}
+	}
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return nil, &ErrCrypto{Message: "error parsing key", Err: err}
+	}
+	fp := sha256.Sum256(block.Bytes)
+	return fp[:], nil
+}
@@ -0,0 +1,97 @@
+package httpsignatures
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyPin(t *testing.T) {
+	rsaPin, edPin := testKeyFingerprint(t, "rsa"), testKeyFingerprint(t, "ed25519")
+	ed, err := testBenchSecrets.Get("ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compromised storage returns another key under another keyId
+	substituted := func(keyID string) Secrets {
+		s := ed
+		s.KeyID = keyID
+		return NewSimpleSecretsStorage(map[string]Secret{"rsa": s})
+	}
+	rsa, err := testBenchSecrets.Get("rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compromised storage returns the pinned public key with attacker's HMAC secret
+	forged := NewSimpleSecretsStorage(map[string]Secret{"rsa": {
+		KeyID:      "rsa",
+		Algorithm:  algHmacSha256,
+		PublicKey:  rsa.PublicKey,
+		PrivateKey: "attacker",
+	}})
+	tests := []struct {
+		name       string
+		keyID      string
+		pin        string
+		storage    Secrets
+		signer     Secrets
+		wantErrMsg string
+	}{
+		{name: "Pinned", keyID: "rsa", pin: rsaPin},
+		{name: "Not pinned", keyID: "ecdsa", pin: ""},
+		{name: "Other key", keyID: "rsa", pin: edPin, wantErrMsg: "public key of keyId 'rsa' doesn't match the pin"},
+		{name: "HMAC", keyID: "hmac", pin: edPin,
+			wantErrMsg: "public key of keyId 'hmac' doesn't match the pin: ErrCrypto: no PEM encoded key found"},
+		{name: "Other keyId", keyID: "rsa", pin: rsaPin, storage: substituted("evil"),
+			wantErrMsg: "secrets storage returned keyId 'evil' for pinned keyId 'rsa'"},
+		{name: "Empty keyId", keyID: "rsa", pin: rsaPin, storage: substituted(""),
+			wantErrMsg: "secrets storage returned keyId '' for pinned keyId 'rsa'"},
+		{name: "HMAC with pinned public key", keyID: "rsa", pin: rsaPin, storage: forged, signer: forged,
+			wantErrMsg: "symmetric algorithm 'HMAC-SHA256' for pinned keyId 'rsa'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, hs := testBenchHS(), testBenchHS()
+			if tt.storage != nil {
+				signer, hs = NewHTTPSignatures(substituted("rsa")), NewHTTPSignatures(tt.storage)
+			}
+			if tt.signer != nil {
+				signer = NewHTTPSignatures(tt.signer)
+			}
+			if err := hs.SetKeyPin(tt.keyID, tt.pin); err != nil {
+				t.Fatal(err)
+			}
+			r := testBenchRequest()
+			if err := signer.Sign(tt.keyID, r); err != nil {
+				t.Fatal(err)
+			}
+			err := hs.Verify(r)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Fatalf("Verify() error = nil, want %s", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if len(tt.wantErrMsg) > 0 && !errors.Is(err, ErrKeyPinMismatch) {
+				t.Errorf("error = %v, want ErrKeyPinMismatch", err)
+			}
+		})
+	}
+}
+
+func TestSetKeyPin(t *testing.T) {
+	hs := testBenchHS()
+	err := hs.SetKeyPin("rsa", "AAAA")
+	assert(t, nil, err, testHSErrType, "Short", nil, "wrong fingerprint of keyId 'rsa'")
+	_, err = KeyFingerprint("key")
+	assert(t, nil, err, testErrCryptoType, "Not PEM", nil, "ErrCrypto: no PEM encoded key found")
+}
+
+func testKeyFingerprint(t *testing.T, keyID string) string {
+	s, err := testBenchSecrets.Get(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, err := KeyFingerprint(s.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fp
+}
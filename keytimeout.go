@@ -0,0 +1,47 @@
+package httpsignatures
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetKeyResolutionTimeout set timeout of secrets storage lookups per sign or verify call, independent of the
+// request context. Lookup which takes longer fails fast with ErrKeyResolutionTimeout (storage without context
+// support keeps running in background). 0 — no timeout (default).
+func (hs *HTTPSignatures) SetKeyResolutionTimeout(d time.Duration) {
+	hs.keyTimeout = d
+}
+
+// getSecretTimeout get secret, fail with ErrKeyResolutionTimeout if lookup takes longer than key resolution timeout
+func (hs *HTTPSignatures) getSecretTimeout(ctx context.Context, keyID string) (Secret, error) {
+	if hs.keyTimeout <= 0 {
+		return hs.fetchSecret(ctx, keyID)
+	}
+	tctx, cancel := context.WithTimeout(ctx, hs.keyTimeout)
+	defer cancel()
+
+	type result struct {
+		secret Secret
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		s, err := hs.fetchSecret(tctx, keyID)
+		ch <- result{secret: s, err: err}
+	}()
+	var res result
+	select {
+	case res = <-ch:
+	case <-tctx.Done():
+		res.err = tctx.Err()
+	}
+	if res.err != nil && ctx.Err() == nil && tctx.Err() == context.DeadlineExceeded {
+		return Secret{}, &ErrHS{
+			Message: fmt.Sprintf("key resolution timeout for keyId '%s'", keyID),
+			Err:     res.err,
+			kind:    ErrKeyResolutionTimeout,
+		}
+	}
+	return res.secret, res.err
+}
@@ -0,0 +1,89 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testBlockingSecrets storage without context support which blocks till release
+type testBlockingSecrets struct {
+	release chan struct{}
+}
+
+func (s testBlockingSecrets) Get(keyID string) (Secret, error) {
+	<-s.release
+	return Secret{KeyID: keyID, Algorithm: algHmacSha256, PublicKey: "secret"}, nil
+}
+
+func TestKeyResolutionTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name    string
+		ss      Secrets
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name:    "Storage without context",
+			ss:      testBlockingSecrets{release: release},
+			ctx:     context.Background(),
+			wantErr: ErrKeyResolutionTimeout,
+		},
+		{
+			name:    "Context storage",
+			ss:      &testSlowSecrets{release: release},
+			ctx:     context.Background(),
+			wantErr: ErrKeyResolutionTimeout,
+		},
+		{
+			name:    "Request context canceled",
+			ss:      &testSlowSecrets{release: release},
+			ctx:     canceled,
+			wantErr: context.Canceled,
+		},
+		{
+			name: "In time",
+			ss:   testSecretsStorage,
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(tt.ss)
+			hs.SetKeyResolutionTimeout(10 * time.Millisecond)
+			start := time.Now()
+			_, err := hs.getSecret(tt.ctx, "Test")
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("getSecret() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(tt.wantErr, ErrKeyResolutionTimeout) && errors.Is(err, ErrUnknownKeyID) {
+				t.Errorf("timeout is reported as unknown keyId")
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("getSecret() took %s", d)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyResolutionTimeout(t *testing.T) {
+	r := testGetRequest()
+	if err := NewHTTPSignatures(testSecretsStorage).Sign("Test", r); err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	hs := NewHTTPSignatures(testBlockingSecrets{release: release})
+	hs.SetKeyResolutionTimeout(10 * time.Millisecond)
+	err := hs.Verify(r)
+	if !errors.Is(err, ErrKeyResolutionTimeout) || errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrKeyResolutionTimeout)
+	}
+	assert(t, nil, err, testHSErrType, "Verify", nil,
+		"key resolution timeout for keyId 'Test': context deadline exceeded")
+}
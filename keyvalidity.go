@@ -0,0 +1,66 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// keyExpiryWarnings keys already reported to the expiry hook, by keyId & NotAfter
+type keyExpiryWarnings struct {
+	mu     sync.Mutex
+	before time.Duration
+	f      func(secret Secret, expiresIn time.Duration)
+	warned map[string]time.Time
+}
+
+// SetKeyExpiryHook set func called when a key used to sign or verify expires (Secret.NotAfter) within before,
+// e.g. to alert on keys which must be rotated soon. It's called once per key & NotAfter value. Nil f removes the hook.
+func (hs *HTTPSignatures) SetKeyExpiryHook(before time.Duration, f func(secret Secret, expiresIn time.Duration)) {
+	if f == nil {
+		hs.expiryWarnings = nil
+		return
+	}
+	hs.expiryWarnings = &keyExpiryWarnings{before: before, f: f, warned: make(map[string]time.Time)}
+}
+
+// checkKeyValidity check the key is used within its validity window (Secret.NotBefore & NotAfter) & call the
+// expiry hook
+func (hs *HTTPSignatures) checkKeyValidity(secret Secret) error {
+	now := hs.now()
+	if !secret.NotBefore.IsZero() && now.Before(secret.NotBefore) {
+		return &ErrHS{
+			Message: fmt.Sprintf("keyId '%s' is not valid before %s", secret.KeyID, secret.NotBefore.UTC().Format(
+				time.RFC3339)),
+			kind: ErrKeyNotYetValid,
+		}
+	}
+	if secret.NotAfter.IsZero() {
+		return nil
+	}
+	if !now.Before(secret.NotAfter) {
+		return &ErrHS{
+			Message: fmt.Sprintf("keyId '%s' expired at %s", secret.KeyID, secret.NotAfter.UTC().Format(time.RFC3339)),
+			kind:    ErrKeyExpired,
+		}
+	}
+	if w := hs.expiryWarnings; w != nil && secret.NotAfter.Sub(now) <= w.before {
+		w.warn(secret, secret.NotAfter.Sub(now))
+	}
+	return nil
+}
+
+// warn call the hook if the key wasn't reported yet
+func (w *keyExpiryWarnings) warn(secret Secret, expiresIn time.Duration) {
+	w.mu.Lock()
+	if t, ok := w.warned[secret.KeyID]; ok && t.Equal(secret.NotAfter) {
+		w.mu.Unlock()
+		return
+	}
+	if len(w.warned) >= maxKeyCacheSize {
+		w.warned = make(map[string]time.Time)
+	}
+	w.warned[secret.KeyID] = secret.NotAfter
+	w.mu.Unlock()
+	w.f(secret, expiresIn)
+}
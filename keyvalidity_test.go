@@ -0,0 +1,79 @@
+package httpsignatures
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyValidity(t *testing.T) {
+	now := time.Unix(1591130723, 0)
+	hmac, err := testBenchSecrets.Get("hmac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		notBefore  time.Time
+		notAfter   time.Time
+		wantErrMsg string
+		wantErr    error
+		wantWarn   bool
+	}{
+		{name: "No validity window"},
+		{name: "Valid", notBefore: now.Add(-time.Hour), notAfter: now.Add(30 * 24 * time.Hour)},
+		{name: "Expires soon", notAfter: now.Add(time.Hour), wantWarn: true},
+		{
+			name:       "Not yet valid",
+			notBefore:  now.Add(time.Hour),
+			wantErrMsg: "keyId 'hmac' is not valid before 2020-06-02T21:45:23Z",
+			wantErr:    ErrKeyNotYetValid,
+		},
+		{
+			name:       "Expired",
+			notAfter:   now,
+			wantErrMsg: "keyId 'hmac' expired at 2020-06-02T20:45:23Z",
+			wantErr:    ErrKeyExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, verifier := hmac, hmac
+			verifier.NotBefore, verifier.NotAfter = tt.notBefore, tt.notAfter
+			var warned []time.Duration
+			hs, err := New(
+				WithSecretsStorage(NewSimpleSecretsStorage(map[string]Secret{"hmac": verifier})),
+				WithClock(func() time.Time { return now }),
+				WithKeyExpiryHook(7*24*time.Hour, func(secret Secret, expiresIn time.Duration) {
+					warned = append(warned, expiresIn)
+				}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = hs.Sign("hmac", testBenchRequest())
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Fatalf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Sign() error = %v, want %v", err, tt.wantErr)
+			}
+
+			signed := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"hmac": signer}))
+			signed.SetClock(func() time.Time { return now })
+			r := testBenchRequest()
+			if err := signed.Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			err = hs.Verify(r)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantWarn != (len(warned) == 1) || tt.wantWarn && warned[0] != time.Hour {
+				t.Errorf("expiry hook calls = %v, want warning: %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
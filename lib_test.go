@@ -2,7 +2,6 @@ package httpsignatures
 
 import (
 	"crypto"
-	"crypto/sha256"
 	"errors"
 	"net/http"
 	"reflect"
@@ -159,12 +158,12 @@ func (a RsaDummy) Algorithm() string {
 
 // Create Create dummy
 func (a RsaDummy) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureRsaAlgorithmCreate(testRsaDummyName, sha256.New, crypto.SHA256, secret, data)
+	return signatureRsaAlgorithmCreate(testRsaDummyName, sha256Pool, crypto.SHA256, secret, data)
 }
 
 // Verify Verify dummy
 func (a RsaDummy) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureRsaAlgorithmVerify(testRsaDummyName, sha256.New, crypto.SHA256, secret, data, signature)
+	return signatureRsaAlgorithmVerify(testRsaDummyName, sha256Pool, crypto.SHA256, secret, data, signature)
 }
 
 // EcdsaDummy ECDSA-DUMMY Algorithm
@@ -177,12 +176,12 @@ func (a EcdsaDummy) Algorithm() string {
 
 // Create Create dummy
 func (a EcdsaDummy) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureEcdsaAlgorithmCreate(testEcdsaDummyName, sha256.New, secret, data)
+	return signatureEcdsaAlgorithmCreate(testEcdsaDummyName, sha256Pool, secret, data)
 }
 
 // Verify Verify dummy
 func (a EcdsaDummy) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureEcdsaAlgorithmVerify(testEcdsaDummyName, sha256.New, secret, data, signature)
+	return signatureEcdsaAlgorithmVerify(testEcdsaDummyName, sha256Pool, secret, data, signature)
 }
 
 // TestRsaErr algorithm with errors
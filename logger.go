@@ -0,0 +1,14 @@
+package httpsignatures
+
+// Logger minimal structured logger, keyvals are key/value pairs: "keyId", "key1", "algorithm", "rsa-sha256".
+// It's easy to adapt zap (SugaredLogger.Debugw), logrus, go-kit log etc.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// nopLogger default logger, discards everything
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -1,9 +1,5 @@
 package httpsignatures
 
-import (
-	"crypto/md5"
-)
-
 const algMd5 = "MD5"
 
 // Md5 MD5 Algorithm
@@ -16,10 +12,15 @@ func (a Md5) Algorithm() string {
 
 // Create Create hash
 func (a Md5) Create(data []byte) ([]byte, error) {
-	return digestHashAlgorithmCreate(md5.New, data)
+	return digestHashAlgorithmCreate(md5Pool, data)
 }
 
 // Verify Verify hash
 func (a Md5) Verify(data []byte, digest []byte) error {
-	return digestHashAlgorithmVerify(md5.New, data, digest)
+	return digestHashAlgorithmVerify(md5Pool, data, digest)
+}
+
+// hashPool hash instances pool to create digest while the body is read
+func (a Md5) hashPool() *hashPool {
+	return md5Pool
 }
@@ -0,0 +1,231 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Message transport-agnostic HTTP message to sign & verify, for stacks other than net/http (e.g. fasthttp) without
+// constructing fake http.Request. Use RequestMessage & ResponseMessage adapters for net/http messages.
+type Message interface {
+	// Method request method, empty for responses (no (request-target))
+	Method() string
+	// Authority request host[:port], used if Host header is signed but not set
+	Authority() string
+	// Path request path with query, e.g. "/foo?param=value"
+	Path() string
+	// HeaderValues header values by case-insensitive name
+	HeaderValues(name string) []string
+	// SetHeader replace header values, used for Signature & Digest headers
+	SetHeader(name string, value string)
+	// Body whole message body, must stay readable for the application after the call
+	Body() ([]byte, error)
+}
+
+// messageHeaders headers read from the message for every signature, in addition to signed headers
+var messageHeaders = []string{signatureHeader, digestHeader, "Date", "Content-Encoding"}
+
+// SignMessage add signature (and Digest if it's signed) headers to the message. Responses (messages without
+// method) are signed with default response signature headers.
+func (hs *HTTPSignatures) SignMessage(ctx context.Context, secretKeyID string, m Message) error {
+	target, signed := hs.messageTarget(m), hs.defaultHeaders
+	if len(target) == 0 {
+		signed = hs.defaultResponseHeaders
+	}
+	header := messageHeader(m, signed)
+	createDigest := func(h []string) (string, error) {
+		if !hs.hasDigest(h) {
+			return "", nil
+		}
+		b, err := hs.d.readMessageBody(m)
+		if err != nil {
+			return "", err
+		}
+		return hs.d.createBody(hs.d.defaultAlg, header, b)
+	}
+	err := hs.sign(ctx, secretKeyID, signed, header, target, m.Authority(), createDigest)
+	if err != nil {
+		hs.log.Error("signing failed", "keyId", secretKeyID, "method", m.Method(), "uri", m.Path(), "err", err)
+		return err
+	}
+	for _, h := range []string{digestHeader, signatureHeader, authorizationHeader} {
+		if v := header.Get(h); len(v) > 0 {
+			m.SetHeader(h, v)
+		}
+	}
+	hs.log.Debug("message signed", "keyId", secretKeyID, "method", m.Method(), "uri", m.Path())
+	return nil
+}
+
+// VerifyMessage verify message signature & return secret (keyId) which validated it
+func (hs *HTTPSignatures) VerifyMessage(ctx context.Context, m Message) (Secret, error) {
+	if err := hs.checkContext(ctx); err != nil {
+		return Secret{}, err
+	}
+	// Signed headers are known from the signature header only
+	var signed []string
+	if h := m.HeaderValues(signatureHeader); len(h) > 0 {
+		p := hs.getParser()
+		if sh, err := p.ParseSignatureHeader(h[0]); err == nil {
+			signed = sh.Headers
+		}
+		putParser(p)
+	}
+	header := messageHeader(m, signed)
+	verifyDigest := func(h []string) error {
+		if !hs.defaultVerifyDigest || !hs.hasDigest(h) {
+			return nil
+		}
+		return hs.d.verify(header, func() ([]byte, *ErrDigest) {
+			return hs.d.readMessageBody(m)
+		})
+	}
+	secret, err := hs.verifyHeader(ctx, header, hs.messageTarget(m), m.Authority(), verifyDigest)
+	if err != nil {
+		hs.log.Error("signature verification failed", "method", m.Method(), "uri", m.Path(), "err", err)
+		return Secret{}, err
+	}
+	hs.log.Debug("signature verified", "keyId", secret.KeyID, "method", m.Method(), "uri", m.Path())
+	return secret, nil
+}
+
+// messageHeader collect message headers required to sign or verify signature of names headers
+func messageHeader(m Message, names []string) http.Header {
+	header := make(http.Header, len(names)+len(messageHeaders))
+	for _, list := range [][]string{messageHeaders, names} {
+		for _, name := range list {
+			if v := m.HeaderValues(name); len(v) > 0 {
+				header[http.CanonicalHeaderKey(name)] = v
+			}
+		}
+	}
+	return header
+}
+
+// messageTarget (request-target) of the message, empty for responses
+func (hs *HTTPSignatures) messageTarget(m Message) string {
+	if len(m.Method()) == 0 {
+		return ""
+	}
+	return strings.ToLower(m.Method()) + " " + hs.query.normalize(m.Path())
+}
+
+// readMessageBody read message body for digest
+func (d *Digest) readMessageBody(m Message) ([]byte, *ErrDigest) {
+	b, err := m.Body()
+	if err != nil {
+		return nil, &ErrDigest{Message: "error reading body", Err: err}
+	}
+	if d.maxBodySize > 0 && int64(len(b)) > d.maxBodySize {
+		return nil, &ErrDigest{Message: fmt.Sprintf("body is larger than %d bytes", d.maxBodySize)}
+	}
+	if len(b) == 0 {
+		return nil, d.emptyBodyError()
+	}
+	return b, nil
+}
+
+// RequestMessage http.Request adapter
+func RequestMessage(r *http.Request) Message {
+	return requestMessage{r: r}
+}
+
+type requestMessage struct {
+	r *http.Request
+}
+
+func (m requestMessage) Method() string {
+	return m.r.Method
+}
+
+func (m requestMessage) Authority() string {
+	if len(m.r.Host) > 0 {
+		return m.r.Host
+	}
+	return m.r.URL.Host
+}
+
+func (m requestMessage) Path() string {
+	return m.r.URL.RequestURI()
+}
+
+func (m requestMessage) HeaderValues(name string) []string {
+	return m.r.Header.Values(name)
+}
+
+func (m requestMessage) SetHeader(name string, value string) {
+	m.r.Header.Set(name, value)
+}
+
+func (m requestMessage) Body() ([]byte, error) {
+	if m.r.GetBody != nil {
+		rc, err := m.r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	b, err := readAndReset(&m.r.Body)
+	if err == nil && b != nil {
+		m.r.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+	}
+	return b, err
+}
+
+// ResponseMessage http.Response adapter (no (request-target))
+func ResponseMessage(resp *http.Response) Message {
+	return responseMessage{resp: resp}
+}
+
+type responseMessage struct {
+	resp *http.Response
+}
+
+func (m responseMessage) Method() string {
+	return ""
+}
+
+func (m responseMessage) Authority() string {
+	return ""
+}
+
+func (m responseMessage) Path() string {
+	return ""
+}
+
+func (m responseMessage) HeaderValues(name string) []string {
+	return m.resp.Header.Values(name)
+}
+
+func (m responseMessage) SetHeader(name string, value string) {
+	m.resp.Header.Set(name, value)
+}
+
+func (m responseMessage) Body() ([]byte, error) {
+	return readAndReset(&m.resp.Body)
+}
+
+// readAndReset read the whole body & replace it with in-memory copy (or the read part & the rest on error)
+func readAndReset(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	if err != nil {
+		*body = readCloser{Reader: io.MultiReader(bytes.NewReader(b), *body), Closer: *body}
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
@@ -0,0 +1,136 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testMessage message of a non net/http stack
+type testMessage struct {
+	method, authority, path string
+	header                  map[string][]string
+	body                    []byte
+}
+
+func (m *testMessage) Method() string    { return m.method }
+func (m *testMessage) Authority() string { return m.authority }
+func (m *testMessage) Path() string      { return m.path }
+func (m *testMessage) HeaderValues(name string) []string {
+	return m.header[strings.ToLower(name)]
+}
+func (m *testMessage) SetHeader(name string, value string) {
+	m.header[strings.ToLower(name)] = []string{value}
+}
+func (m *testMessage) Body() ([]byte, error) { return m.body, nil }
+
+func testNewMessage() *testMessage {
+	return &testMessage{
+		method:    http.MethodPost,
+		authority: "example.com",
+		path:      "/foo?param=value",
+		header: map[string][]string{
+			"date":         {testDateExample},
+			"content-type": {testContentTypeJSON},
+		},
+		body: []byte(testBodyExample),
+	}
+}
+
+func TestSignVerifyMessage(t *testing.T) {
+	hs := testBenchHS()
+	ctx := context.Background()
+
+	m := testNewMessage()
+	if err := hs.SignMessage(ctx, "hmac", m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.HeaderValues(digestHeader)) == 0 || len(m.HeaderValues(signatureHeader)) == 0 {
+		t.Fatalf("got headers %v, want digest & signature", m.header)
+	}
+	secret, err := hs.VerifyMessage(ctx, m)
+	if err != nil || secret.KeyID != "hmac" {
+		t.Errorf("got %s, %v, want keyId hmac", secret.KeyID, err)
+	}
+
+	// Same signature string as net/http request
+	r := testBenchRequest()
+	r.URL.RawQuery = "param=value"
+	r.Header.Set(digestHeader, m.HeaderValues(digestHeader)[0])
+	r.Header.Set(signatureHeader, m.HeaderValues(signatureHeader)[0])
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("request verification error: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(m *testMessage)
+		want   error
+	}{
+		{name: "Body", tamper: func(m *testMessage) { m.body = []byte("other") }, want: ErrDigestMismatch},
+		{name: "Path", tamper: func(m *testMessage) { m.path = "/bar" }, want: ErrWrongSignature},
+		{name: "Authority", tamper: func(m *testMessage) { m.authority = "other.com" }, want: ErrWrongSignature},
+		{name: "Signature", tamper: func(m *testMessage) { delete(m.header, "signature") },
+			want: ErrSignatureHeaderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := *m
+			tm.header = make(map[string][]string)
+			for k, v := range m.header {
+				tm.header[k] = v
+			}
+			tt.tamper(&tm)
+			if _, err := hs.VerifyMessage(ctx, &tm); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestMessage(t *testing.T) {
+	hs := testBenchHS()
+	ctx := context.Background()
+	r := testBenchRequest()
+	if err := hs.SignMessage(ctx, "hmac", RequestMessage(r)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hs.VerifyMessage(ctx, RequestMessage(r)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if b, _ := ioutil.ReadAll(r.Body); string(b) != testBodyExample {
+		t.Errorf("got body %s, want %s", b, testBodyExample)
+	}
+}
+
+func TestResponseMessage(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultResponseSignatureHeaders([]string{"(created)", "digest"})
+	ctx := context.Background()
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(testBodyExample))),
+	}
+	if err := hs.SignMessage(ctx, "hmac", ResponseMessage(resp)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hs.VerifyMessage(ctx, ResponseMessage(resp)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != testBodyExample {
+		t.Errorf("got body %s, want %s", b, testBodyExample)
+	}
+
+	resp.Body = ioutil.NopCloser(strings.NewReader("other"))
+	if _, err := hs.VerifyMessage(ctx, ResponseMessage(resp)); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("got error %v, want ErrDigestMismatch", err)
+	}
+}
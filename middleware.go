@@ -0,0 +1,54 @@
+package httpsignatures
+
+import "net/http"
+
+const wwwAuthenticateHeader = "WWW-Authenticate"
+
+// VerifyRequests handler wrapper which verifies request signatures before the next handler.
+// Requests with missing or wrong signature get 401 Unauthorized (with WWW-Authenticate challenge if Policy.Challenge
+// is set), statuses & response are set by SetRejections.
+// If digest is signed, it's verified while the next handler reads the body (no double read): body Read returns
+// ErrDigest (errors.Is(err, ErrDigestMismatch)) at the end of the body if digest is wrong, so the handler must read
+// the body to the end & check the error before acting on it (or use SetBufferRequestBody).
+// Signatures sent in trailers (StreamedBodyDigestSignedTrailer) are verified before the next handler, the body is
+// read into memory (up to max body size).
+// Principal of the verified keyId is stored in the request context if principal resolver is set.
+// In report-only mode (SetReportOnly) requests are never rejected.
+func (hs *HTTPSignatures) VerifyRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var secret Secret
+		var err error
+		if hs.bufferRequestBody {
+			r, err = hs.bufferRequest(r)
+		}
+		if err == nil {
+			secret, err = hs.verify(r.Context(), r, !hs.bufferRequestBody)
+		}
+		ctx := r.Context()
+		if err == nil {
+			ctx, err = hs.withPrincipal(ctx, secret)
+		}
+		if ctx != r.Context() {
+			r = r.WithContext(ctx)
+		}
+		r = hs.reportResult(r, &VerificationResult{KeyID: secret.KeyID, Err: err})
+		switch {
+		case err != nil && hs.reportOnly:
+			hs.log.Error("signature verification failed (report-only)", "method", r.Method, "uri", r.RequestURI,
+				"err", err)
+		case err != nil:
+			hs.log.Error("signature verification failed", "method", r.Method, "uri", r.RequestURI, "err", err)
+			hs.reject(w, r, err)
+			return
+		default:
+			hs.log.Debug("signature verified", "keyId", secret.KeyID, "method", r.Method, "uri", r.RequestURI)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Challenge WWW-Authenticate header value describing signature required by the policy, e.g.
+// Signature realm="api",headers="(request-target) date digest"
+func (hs *HTTPSignatures) Challenge() string {
+	return hs.policy.challenge(hs.defaultHeaders)
+}
@@ -0,0 +1,236 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testDigestSha256 SHA-256 without hash pool, the body is buffered to verify digest
+type testDigestSha256 struct{}
+
+func (testDigestSha256) Algorithm() string {
+	return algSha256
+}
+
+func (testDigestSha256) Create(data []byte) ([]byte, error) {
+	return Sha256{}.Create(data)
+}
+
+func (testDigestSha256) Verify(data []byte, digest []byte) error {
+	return Sha256{}.Verify(data, digest)
+}
+
+func TestVerifyRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		digestAlg  DigestHashAlgorithm
+		body       string
+		sign       bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Valid signature & digest",
+			body:       testBodyExample,
+			sign:       true,
+			wantStatus: http.StatusOK,
+			wantBody:   testBodyExample,
+		},
+		{
+			name:       "Valid signature & digest, custom digest algorithm",
+			digestAlg:  testDigestSha256{},
+			body:       testBodyExample,
+			sign:       true,
+			wantStatus: http.StatusOK,
+			wantBody:   testBodyExample,
+		},
+		{
+			name:       "Wrong digest",
+			body:       `{"hello": "world!"}`,
+			sign:       true,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "ErrDigest: wrong digest: ErrCrypto: wrong hash",
+		},
+		{
+			name:       "Wrong digest, custom digest algorithm",
+			digestAlg:  testDigestSha256{},
+			body:       `{"hello": "world!"}`,
+			sign:       true,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "ErrDigest: wrong digest: ErrCrypto: wrong hash",
+		},
+		{
+			name:       "No signature",
+			body:       testBodyExample,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+			if tt.digestAlg != nil {
+				hs.SetDigestAlgorithm(tt.digestAlg)
+				_ = hs.SetDefaultDigestAlgorithm(algSha256)
+			}
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					if !errors.Is(err, ErrDigestMismatch) {
+						t.Errorf(tt.name+"\ngot error = %v, want %v", err, ErrDigestMismatch)
+					}
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(err.Error()))
+					return
+				}
+				_, _ = w.Write(b)
+			}))
+
+			r := testGetRequest()
+			if tt.sign {
+				if err := hs.Sign("Test", r); err != nil {
+					t.Fatalf("Sign error = %v", err)
+				}
+			}
+			sr := httptest.NewRequest(r.Method, r.URL.String(), strings.NewReader(tt.body))
+			sr.Header = r.Header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, sr)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf(tt.name+"\ngot status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf(tt.name+"\ngot body = %s, want %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDigestReaderClose(t *testing.T) {
+	r := testGetDigestRequestFunc(testBodyExample, "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	body, err := NewDigest().newVerifyReader(r)
+	if err != nil {
+		t.Fatalf("newVerifyReader error = %v", err)
+	}
+	if err := body.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	_, err = ioutil.ReadAll(body)
+	if err == nil {
+		t.Fatal("Read after close: no error")
+	}
+	assert(t, nil, err, testErrDigestType, "Read after close", nil,
+		"ErrDigest: body is closed before digest verification")
+
+	r = testGetDigestRequestFunc("", "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	_, err = NewDigest().newVerifyReader(r)
+	if err == nil {
+		t.Fatal("Empty body: no error")
+	}
+	assert(t, nil, err, testErrDigestType, "Empty body", nil, "ErrDigest: empty body")
+}
+
+func TestVerifyRequestsChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   string
+	}{
+		{
+			name:   "No challenge",
+			policy: Policy{RequiredHeaders: []string{"date"}},
+			want:   "",
+		},
+		{
+			name:   "Realm & required headers",
+			policy: Policy{RequiredHeaders: []string{requestTarget, "Date", "digest"}, Challenge: true, Realm: "api"},
+			want:   `Signature realm="api",headers="(request-target) date digest"`,
+		},
+		{
+			name:   "Default headers",
+			policy: Policy{Challenge: true},
+			want:   `Signature headers="(request-target) (created) digest"`,
+		},
+		{
+			name:   "Quoted realm",
+			policy: Policy{Challenge: true, Realm: `my "api"`, RequiredHeaders: []string{"date"}},
+			want:   `Signature realm="my \"api\"",headers="date"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+			hs.SetPolicy(tt.policy)
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testHostExamplePath, nil))
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf(tt.name+"\ngot status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if got := rec.Header().Get(wwwAuthenticateHeader); got != tt.want {
+				t.Errorf(tt.name+"\ngot challenge = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestsPrincipal(t *testing.T) {
+	tests := []struct {
+		name          string
+		resolver      PrincipalResolver
+		wantStatus    int
+		wantPrincipal interface{}
+	}{
+		{
+			name: "Principal resolved",
+			resolver: func(ctx context.Context, secret Secret) (interface{}, error) {
+				return "tenant-" + secret.KeyID, nil
+			},
+			wantStatus:    http.StatusOK,
+			wantPrincipal: "tenant-Test",
+		},
+		{
+			name: "Unknown principal",
+			resolver: func(ctx context.Context, secret Secret) (interface{}, error) {
+				return nil, errors.New("no tenant")
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "No resolver",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetPrincipalResolver(tt.resolver)
+			var got interface{}
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = PrincipalFromContext(r.Context())
+			}))
+
+			r := testGetRequest()
+			if err := hs.Sign("Test", r); err != nil {
+				t.Fatalf("Sign error = %v", err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus || got != tt.wantPrincipal {
+				t.Errorf(tt.name+"\ngot status = %d, principal = %v, want %d, %v", rec.Code, got, tt.wantStatus,
+					tt.wantPrincipal)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/igor-pavlenko/httpsignatures-go/mldsa
+
+go 1.27
+
+require github.com/igor-pavlenko/httpsignatures-go v0.0.14
+
+// Use the core module from this repository
+replace github.com/igor-pavlenko/httpsignatures-go => ../
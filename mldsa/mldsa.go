@@ -0,0 +1,87 @@
+// Package mldsa experimental ML-DSA-65 (FIPS 204, post-quantum) signature algorithm for HTTP signatures.
+// It's opt-in & not interoperable with other implementations yet: the algorithm name is not registered
+// for HTTP signatures. Requires Go 1.27 (crypto/mldsa).
+package mldsa
+
+import (
+	"crypto/mldsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+// Algorithm signature algorithm name
+const Algorithm = "ML-DSA-65"
+
+// MLDSA65 ML-DSA-65 signature algorithm. Keys are PEM encoded PKCS #8 private key ("PRIVATE KEY", seed form)
+// & PKIX public key ("PUBLIC KEY").
+type MLDSA65 struct{}
+
+// Algorithm return algorithm name
+func (a MLDSA65) Algorithm() string {
+	return Algorithm
+}
+
+// SecurityBits security strength in bits (NIST category 3), see httpsignatures.AlgorithmSecurity
+func (a MLDSA65) SecurityBits() int {
+	return 192
+}
+
+// Create create signature using passed private key from secret
+func (a MLDSA65) Create(secret httpsignatures.Secret, data []byte) ([]byte, error) {
+	block, _ := pem.Decode([]byte(secret.PrivateKey))
+	if block == nil {
+		return nil, errors.New("no private key found")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	pk, ok := k.(*mldsa.PrivateKey)
+	if !ok || pk.PublicKey().Parameters() != mldsa.MLDSA65() {
+		return nil, errors.New("private key is not ML-DSA-65 key")
+	}
+	return pk.Sign(nil, data, &mldsa.Options{})
+}
+
+// Verify verify signature using passed public key from secret
+func (a MLDSA65) Verify(secret httpsignatures.Secret, data []byte, signature []byte) error {
+	block, _ := pem.Decode([]byte(secret.PublicKey))
+	if block == nil {
+		return errors.New("no public key found")
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("error parsing public key: %w", err)
+	}
+	pub, ok := k.(*mldsa.PublicKey)
+	if !ok || pub.Parameters() != mldsa.MLDSA65() {
+		return errors.New("public key is not ML-DSA-65 key")
+	}
+	return mldsa.Verify(pub, data, signature, &mldsa.Options{})
+}
+
+// GenerateSecret generate ML-DSA-65 key pair & return it as Secret with PEM encoded keys
+func GenerateSecret(keyID string) (httpsignatures.Secret, error) {
+	k, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		return httpsignatures.Secret{}, err
+	}
+	priv, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		return httpsignatures.Secret{}, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(k.PublicKey())
+	if err != nil {
+		return httpsignatures.Secret{}, err
+	}
+	return httpsignatures.Secret{
+		KeyID:      keyID,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})),
+		Algorithm:  Algorithm,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package mldsa
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret, err := GenerateSecret("pq-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := GenerateSecret("pq-key")
+	ed, _ := httpsignatures.GenerateSecret("pq-key", "ED25519")
+	tests := []struct {
+		name    string
+		verify  httpsignatures.Secret
+		wantErr error
+	}{
+		{
+			name:   "Valid signature",
+			verify: secret,
+		},
+		{
+			name:    "Other key",
+			verify:  other,
+			wantErr: httpsignatures.ErrWrongSignature,
+		},
+		{
+			name:    "Not ML-DSA key",
+			verify:  httpsignatures.Secret{KeyID: "pq-key", PublicKey: ed.PublicKey, Algorithm: Algorithm},
+			wantErr: httpsignatures.ErrWrongSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(
+				map[string]httpsignatures.Secret{"pq-key": secret}))
+			signer.SetSignatureHashAlgorithm(MLDSA65{})
+			signer.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "digest"})
+			r, _ := http.NewRequest(http.MethodPost, "https://example.com/foo", strings.NewReader(`{"hello": "world"}`))
+			if err := signer.Sign("pq-key", r); err != nil {
+				t.Fatal(err)
+			}
+
+			verifier := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(
+				map[string]httpsignatures.Secret{"pq-key": tt.verify}))
+			verifier.SetSignatureHashAlgorithm(MLDSA65{})
+			err := verifier.Verify(r)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateWrongKey(t *testing.T) {
+	ed, _ := httpsignatures.GenerateSecret("key", "ED25519")
+	_, err := MLDSA65{}.Create(ed, []byte("data"))
+	if err == nil || err.Error() != "private key is not ML-DSA-65 key" {
+		t.Errorf("Create() error = %v", err)
+	}
+}
+
+func TestAlgorithms(t *testing.T) {
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(nil))
+	hs.SetSignatureHashAlgorithm(MLDSA65{})
+	for _, a := range hs.Algorithms() {
+		if a.Name == Algorithm && a.SecurityBits == 192 && a.Create && a.Verify {
+			return
+		}
+	}
+	t.Errorf("Algorithms() = %+v, want %s with 192 security bits", hs.Algorithms(), Algorithm)
+}
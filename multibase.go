@@ -0,0 +1,178 @@
+package httpsignatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Multicodec codes of public keys
+const (
+	multicodecEd25519Pub = 0xed
+	multicodecP256Pub    = 0x1200
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// maxBase58Size max length of base58 value to decode: decoding time grows quadratically & values come from
+// signature headers. Fits ML-DSA-87 signatures (4627 bytes, ~6.3K base58 symbols).
+const maxBase58Size = 8 << 10
+
+// multibaseEncode encode as base58btc multibase ("z" prefix)
+func multibaseEncode(b []byte) string {
+	return "z" + base58Encode(b)
+}
+
+// multibaseDecode decode multibase value: base58btc ("z"), base64 ("m", "M"), base64url ("u", "U") or hex ("f", "F")
+func multibaseDecode(s string) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, &ErrCrypto{Message: "empty multibase value"}
+	}
+	v := s[1:]
+	switch s[0] {
+	case 'z':
+		return base58Decode(v)
+	case 'm', 'M':
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "="))
+	case 'u', 'U':
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+	case 'f', 'F':
+		return hex.DecodeString(v)
+	}
+	return nil, &ErrCrypto{Message: fmt.Sprintf("unsupported multibase encoding '%c'", s[0])}
+}
+
+func base58Encode(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+	// log(256) / log(58) ≈ 1.37
+	digits := make([]byte, 0, len(b)*138/100+1)
+	for _, c := range b[zeros:] {
+		carry := int(c)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+	res := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		res[i] = base58Alphabet[0]
+	}
+	for i, d := range digits {
+		res[len(res)-1-i] = base58Alphabet[d]
+	}
+	return string(res)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	if len(s) > maxBase58Size {
+		return nil, &ErrCrypto{Message: fmt.Sprintf("base58 value is longer than %d bytes", maxBase58Size)}
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	digits := make([]byte, 0, len(s)*733/1000+1)
+	for i := zeros; i < len(s); i++ {
+		carry := strings.IndexByte(base58Alphabet, s[i])
+		if carry < 0 {
+			return nil, &ErrCrypto{Message: fmt.Sprintf("illegal base58 data at input byte %d", i)}
+		}
+		for j := range digits {
+			carry += int(digits[j]) * 58
+			digits[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry))
+			carry >>= 8
+		}
+	}
+	res := make([]byte, zeros+len(digits))
+	for i, b := range digits {
+		res[len(res)-1-i] = b
+	}
+	return res, nil
+}
+
+// MultibaseKeyPEM convert multibase multicodec public key (e.g. Multikey publicKeyMultibase "z6Mk...") or did:key
+// (e.g. "did:key:z6Mk...#z6Mk...") to PEM encoded public key (PKIX) for Secret.PublicKey.
+// Ed25519 & P-256 (compressed) keys are supported.
+func MultibaseKeyPEM(key string) (string, error) {
+	key = strings.TrimPrefix(key, "did:key:")
+	if i := strings.IndexByte(key, '#'); i >= 0 {
+		key = key[:i]
+	}
+	b, err := multibaseDecode(key)
+	if err != nil {
+		return "", err
+	}
+	code, n := binary.Uvarint(b)
+	if n <= 0 {
+		return "", &ErrCrypto{Message: "wrong multicodec prefix"}
+	}
+	b = b[n:]
+	var pub interface{}
+	switch code {
+	case multicodecEd25519Pub:
+		if len(b) != ed25519.PublicKeySize {
+			return "", &ErrCrypto{Message: fmt.Sprintf("wrong Ed25519 public key size %d", len(b))}
+		}
+		pub = ed25519.PublicKey(b)
+	case multicodecP256Pub:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), b)
+		if x == nil {
+			return "", &ErrCrypto{Message: "wrong P-256 public key"}
+		}
+		pub = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	default:
+		return "", &ErrCrypto{Message: fmt.Sprintf("unsupported multicodec key type 0x%x", code)}
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", &ErrCrypto{Message: "error marshal public key", Err: err}
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// MultibaseKey convert PEM encoded public key (PKIX) to multibase (base58btc) multicodec key, e.g. "z6Mk..."
+func MultibaseKey(publicKey string) (string, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return "", &ErrCrypto{Message: "no PEM encoded key found"}
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", &ErrCrypto{Message: "error parsing key", Err: err}
+	}
+	var code uint64
+	var raw []byte
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		code, raw = multicodecEd25519Pub, k
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", &ErrCrypto{Message: fmt.Sprintf("unsupported curve %s", k.Curve.Params().Name)}
+		}
+		code, raw = multicodecP256Pub, elliptic.MarshalCompressed(k.Curve, k.X, k.Y)
+	default:
+		return "", &ErrCrypto{Message: fmt.Sprintf("unsupported key type %T", pub)}
+	}
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, code)
+	return multibaseEncode(append(prefix[:n], raw...)), nil
+}
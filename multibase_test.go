@@ -0,0 +1,111 @@
+package httpsignatures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		encoded string
+	}{
+		{name: "Empty", data: "", encoded: ""},
+		{name: "Text", data: "Hello World!", encoded: "2NEpo7TZRRrLZSi2U"},
+		{name: "Leading zeros", data: "\x00\x00\x01", encoded: "112"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base58Encode([]byte(tt.data)); got != tt.encoded {
+				t.Errorf("base58Encode() = %s, want %s", got, tt.encoded)
+			}
+			got, err := base58Decode(tt.encoded)
+			assert(t, string(got), err, testErrCryptoType, tt.name, tt.data, "")
+		})
+	}
+	_, err := base58Decode("10")
+	assert(t, nil, err, testErrCryptoType, "Illegal", nil, "ErrCrypto: illegal base58 data at input byte 1")
+
+	// ML-DSA-87 signature size is within the limit
+	sig := strings.Repeat("\xff", 4627)
+	got, err := base58Decode(base58Encode([]byte(sig)))
+	assert(t, string(got), err, testErrCryptoType, "ML-DSA-87 signature", sig, "")
+	_, err = base58Decode(strings.Repeat("2", maxBase58Size+1))
+	if err == nil {
+		t.Fatal("base58Decode() error = nil for too long value")
+	}
+	assert(t, nil, err, testErrCryptoType, "Too long", nil, "ErrCrypto: base58 value is longer than 8192 bytes")
+}
+
+func TestMultibaseDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		want       []byte
+		wantErrMsg string
+	}{
+		{name: "base58btc", value: "z2NEpo7TZRRrLZSi2U", want: []byte("Hello World!")},
+		{name: "base64", value: "mSGVsbG8gV29ybGQh", want: []byte("Hello World!")},
+		{name: "base64url", value: "uSGVsbG8gV29ybGQh", want: []byte("Hello World!")},
+		{name: "hex", value: "f48656c6c6f20576f726c6421", want: []byte("Hello World!")},
+		{name: "Unsupported", value: "bnbswy3dp", wantErrMsg: "ErrCrypto: unsupported multibase encoding 'b'"},
+		{name: "Empty", value: "", wantErrMsg: "ErrCrypto: empty multibase value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := multibaseDecode(tt.value)
+			assert(t, got, err, testErrCryptoType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestMultibaseKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		alg    string
+		prefix string
+	}{
+		{name: "Ed25519", alg: algED25519, prefix: "z6Mk"},
+		{name: "P-256", alg: algEcdsaSha256, prefix: "zDn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := GenerateSecret("did", tt.alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := MultibaseKey(s.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(key, tt.prefix) {
+				t.Errorf("MultibaseKey() = %s, want prefix %s", key, tt.prefix)
+			}
+			got, err := MultibaseKeyPEM("did:key:" + key + "#" + key)
+			assert(t, got, err, testErrCryptoType, tt.name, s.PublicKey, "")
+		})
+	}
+
+	_, err := MultibaseKeyPEM("z" + base58Encode([]byte{0xe7, 0x01, 0x02}))
+	assert(t, nil, err, testErrCryptoType, "secp256k1", nil, "ErrCrypto: unsupported multicodec key type 0xe7")
+	rsa, _ := testBenchSecrets.Get("rsa")
+	_, err = MultibaseKey(rsa.PublicKey)
+	assert(t, nil, err, testErrCryptoType, "RSA", nil, "ErrCrypto: unsupported key type *rsa.PublicKey")
+}
+
+func TestMultibaseSignature(t *testing.T) {
+	hs := testBenchHS()
+	hs.SetQuirks(Quirks{SignatureEncoding: SignatureEncodingMultibase})
+	r := testBenchRequest()
+	if err := hs.Sign("ed25519", r); err != nil {
+		t.Fatal(err)
+	}
+	sh, _ := ParseSignatureHeader(r.Header.Get("Signature"))
+	if !strings.HasPrefix(sh.Signature, "z") {
+		t.Errorf("signature %s is not base58btc multibase", sh.Signature)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package httpsignatures
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// harEntry HAR 1.2 entry (request part only)
+type harEntry struct {
+	StartedDateTime time.Time  `json:"startedDateTime"`
+	Request         harRequest `json:"request"`
+}
+
+type harRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Headers  []harHeader `json:"headers"`
+	PostData *struct {
+		Text     string `json:"text"`
+		Encoding string `json:"encoding"`
+	} `json:"postData"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ReadRawRequest parse raw HTTP/1.1 request dump (request line, headers & body) for offline verification
+func ReadRawRequest(raw []byte) (*http.Request, error) {
+	r, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return nil, &ErrHS{Message: "error parsing raw request", Err: err}
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, &ErrHS{Message: "error reading raw request body", Err: err}
+	}
+	setOfflineBody(r, body)
+	return r, nil
+}
+
+// ReadHARRequest parse HAR entry (object of the log.entries array) request for offline verification.
+// HTTP/2 pseudo-headers are skipped (":authority" is used as host).
+func ReadHARRequest(entry []byte) (*http.Request, error) {
+	var e harEntry
+	if err := json.Unmarshal(entry, &e); err != nil {
+		return nil, &ErrHS{Message: "error parsing HAR entry", Err: err}
+	}
+	return e.request()
+}
+
+func (e harEntry) request() (*http.Request, error) {
+	var body []byte
+	if e.Request.PostData != nil {
+		body = []byte(e.Request.PostData.Text)
+		if e.Request.PostData.Encoding == "base64" {
+			b, err := base64.StdEncoding.DecodeString(e.Request.PostData.Text)
+			if err != nil {
+				return nil, &ErrHS{Message: "error decoding HAR request body", Err: err}
+			}
+			body = b
+		}
+	}
+	r, err := http.NewRequest(e.Request.Method, e.Request.URL, nil)
+	if err != nil {
+		return nil, &ErrHS{Message: "error parsing HAR request", Err: err}
+	}
+	for _, h := range e.Request.Headers {
+		switch {
+		case strings.EqualFold(h.Name, ":authority") || strings.EqualFold(h.Name, hostHeader):
+			r.Host = h.Value
+		case strings.HasPrefix(h.Name, ":"):
+		default:
+			r.Header.Add(h.Name, h.Value)
+		}
+	}
+	setOfflineBody(r, body)
+	return r, nil
+}
+
+// setOfflineBody set in-memory body, so the request can be verified several times
+func setOfflineBody(r *http.Request, body []byte) {
+	r.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		r.Body, r.GetBody = http.NoBody, nil
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// VerifyRaw verify signature of raw HTTP/1.1 request dump at the capture time (now if zero) & return secret
+// which validated it
+func (hs *HTTPSignatures) VerifyRaw(raw []byte, at time.Time) (Secret, error) {
+	r, err := ReadRawRequest(raw)
+	if err != nil {
+		return Secret{}, err
+	}
+	return hs.verifyAt(r, at)
+}
+
+// VerifyHAR verify signature of HAR entry request at the capture time (entry startedDateTime if zero) & return
+// secret which validated it
+func (hs *HTTPSignatures) VerifyHAR(entry []byte, at time.Time) (Secret, error) {
+	var e harEntry
+	if err := json.Unmarshal(entry, &e); err != nil {
+		return Secret{}, &ErrHS{Message: "error parsing HAR entry", Err: err}
+	}
+	r, err := e.request()
+	if err != nil {
+		return Secret{}, err
+	}
+	if at.IsZero() {
+		at = e.StartedDateTime
+	}
+	return hs.verifyAt(r, at)
+}
+
+// verifyAt verify request with clock fixed at the passed time (now if zero)
+func (hs *HTTPSignatures) verifyAt(r *http.Request, at time.Time) (Secret, error) {
+	v := *hs
+	if !at.IsZero() {
+		v.now = func() time.Time { return at }
+	}
+	return v.VerifyAndIdentifyCtx(context.Background(), r)
+}
@@ -0,0 +1,78 @@
+package httpsignatures
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOfflineRequest(t *testing.T, capturedAt time.Time) *http.Request {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, "host", created, expires, "digest"})
+	r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, strings.NewReader(testBodyExample))
+	if err := hs.SignAt("rsa", r, capturedAt, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestVerifyRaw(t *testing.T) {
+	capturedAt := time.Unix(1600000000, 0)
+	raw, err := httputil.DumpRequestOut(testOfflineRequest(t, capturedAt), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := NewHTTPSignatures(testBenchSecrets)
+
+	secret, err := hs.VerifyRaw(raw, capturedAt.Add(time.Second))
+	if err != nil || secret.KeyID != "rsa" {
+		t.Errorf("VerifyRaw() = %s, error %v", secret.KeyID, err)
+	}
+	if _, err := hs.VerifyRaw(raw, time.Time{}); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("VerifyRaw() now: error = %v, want %v", err, ErrSignatureExpired)
+	}
+	tampered := []byte(strings.Replace(string(raw), "pet=dog", "pet=cat", 1))
+	if _, err := hs.VerifyRaw(tampered, capturedAt); !errors.Is(err, ErrWrongSignature) {
+		t.Errorf("VerifyRaw() tampered: error = %v, want %v", err, ErrWrongSignature)
+	}
+	_, err = hs.VerifyRaw([]byte("not a request"), capturedAt)
+	if err == nil || !strings.HasPrefix(err.Error(), "error parsing raw request") {
+		t.Errorf("VerifyRaw() wrong request: error = %v", err)
+	}
+}
+
+func TestVerifyHAR(t *testing.T) {
+	capturedAt := time.Unix(1600000000, 0)
+	r := testOfflineRequest(t, capturedAt)
+	entry := map[string]interface{}{
+		"startedDateTime": capturedAt.Format(time.RFC3339),
+		"request": map[string]interface{}{
+			"method": r.Method,
+			"url":    r.URL.String(),
+			"headers": []map[string]string{
+				{"name": ":authority", "value": "example.com"},
+				{"name": "digest", "value": r.Header.Get(digestHeader)},
+				{"name": "signature", "value": r.Header.Get(signatureHeader)},
+			},
+			"postData": map[string]string{"mimeType": "application/json", "text": testBodyExample},
+		},
+	}
+	b, _ := json.Marshal(entry)
+	hs := NewHTTPSignatures(testBenchSecrets)
+
+	secret, err := hs.VerifyHAR(b, time.Time{})
+	if err != nil || secret.KeyID != "rsa" {
+		t.Errorf("VerifyHAR() = %s, error %v", secret.KeyID, err)
+	}
+	if _, err := hs.VerifyHAR(b, capturedAt.Add(time.Hour)); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("VerifyHAR() later: error = %v, want %v", err, ErrSignatureExpired)
+	}
+	hr, err := ReadHARRequest(b)
+	if err != nil || hr.Host != "example.com" || len(hr.Header.Get(":authority")) > 0 {
+		t.Errorf("ReadHARRequest() host = %s, error %v", hr.Host, err)
+	}
+}
@@ -0,0 +1,363 @@
+package httpsignatures
+
+import (
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+// Option HTTPSignatures configuration option for New. Options copy their slice arguments, so []Option is
+// a configuration snapshot: New(opts...) creates independent instances with the same configuration, e.g. per test.
+type Option func(hs *HTTPSignatures) error
+
+// New create HTTPSignatures configured with options, e.g.
+// New(WithSecretsStorage(ss), WithDefaultDigest("SHA-256"), WithPolicy(Policy{RequiredHeaders: []string{"digest"}})).
+// Options are applied in order. Secrets storage is empty if not set.
+func New(opts ...Option) (*HTTPSignatures, error) {
+	hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{}))
+	for _, opt := range opts {
+		if err := opt(hs); err != nil {
+			return nil, err
+		}
+	}
+	return hs, nil
+}
+
+// WithSecretsStorage set secrets storage
+func WithSecretsStorage(ss Secrets) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.ss = ss
+		return nil
+	}
+}
+
+// WithAlgorithms add custom signature hash algorithms
+func WithAlgorithms(a ...SignatureHashAlgorithm) Option {
+	return func(hs *HTTPSignatures) error {
+		for _, alg := range a {
+			hs.SetSignatureHashAlgorithm(alg)
+		}
+		return nil
+	}
+}
+
+// WithDigestAlgorithms add custom digest hash algorithms
+func WithDigestAlgorithms(a ...DigestHashAlgorithm) Option {
+	return func(hs *HTTPSignatures) error {
+		for _, alg := range a {
+			hs.SetDigestAlgorithm(alg)
+		}
+		return nil
+	}
+}
+
+// WithDefaultDigest set default digest hash algorithm (must be supported)
+func WithDefaultDigest(alg string) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetDefaultDigestAlgorithm(alg)
+	}
+}
+
+// WithVerifyDigest enable/disable Digest header verification
+func WithVerifyDigest(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetDefaultVerifyDigest(v)
+		return nil
+	}
+}
+
+// WithSignatureHeaders set default list of headers to sign requests
+func WithSignatureHeaders(h ...string) Option {
+	h = append([]string(nil), h...)
+	return func(hs *HTTPSignatures) error {
+		hs.SetDefaultSignatureHeaders(h)
+		return nil
+	}
+}
+
+// WithExpiresSeconds set expires seconds for created signatures
+func WithExpiresSeconds(e uint32) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetDefaultExpiresSeconds(e)
+		return nil
+	}
+}
+
+// WithParserMode set Signature header parser mode
+func WithParserMode(m ParserMode) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetParserMode(m)
+		return nil
+	}
+}
+
+// WithPolicy set signature verification policy
+func WithPolicy(p Policy) Option {
+	p.RequiredHeaders = append([]string(nil), p.RequiredHeaders...)
+	return func(hs *HTTPSignatures) error {
+		hs.SetPolicy(p)
+		return nil
+	}
+}
+
+// WithClock set time source for created/expires
+func WithClock(now func() time.Time) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetClock(now)
+		return nil
+	}
+}
+
+// WithLogger set logger
+func WithLogger(l Logger) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetLogger(l)
+		return nil
+	}
+}
+
+// WithQuirks set compatibility quirks for all keys
+func WithQuirks(q Quirks) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetQuirks(q)
+		return nil
+	}
+}
+
+// WithKeyQuirks set compatibility quirks for keyId
+func WithKeyQuirks(keyID string, q Quirks) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetKeyQuirks(keyID, q)
+		return nil
+	}
+}
+
+// WithProfile register signing profile by name
+func WithProfile(name string, p Profile) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetProfile(name, p)
+	}
+}
+
+// WithIdentity register signer identity by name
+func WithIdentity(name string, id Identity) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetIdentity(name, id)
+	}
+}
+
+// WithMaxSignatureHeaders set max number of signed headers, 0 — no limit
+func WithMaxSignatureHeaders(n int) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetMaxSignatureHeaders(n)
+		return nil
+	}
+}
+
+// WithMaxSignatureStringSize set max length of signature string in bytes, 0 — no limit
+func WithMaxSignatureStringSize(n int) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetMaxSignatureStringSize(n)
+		return nil
+	}
+}
+
+// WithEmptyBodyDigest set digest behaviour for requests without body
+func WithEmptyBodyDigest(m EmptyBodyDigest) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetEmptyBodyDigest(m)
+		return nil
+	}
+}
+
+// WithDigestDecodedBody compute digest over decoded body (by Content-Encoding) instead of raw bytes
+func WithDigestDecodedBody(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetDigestDecodedBody(v)
+		return nil
+	}
+}
+
+// WithStreamedBodyDigest set digest creation for streamed request bodies & max body size read into memory
+func WithStreamedBodyDigest(m StreamedBodyDigest, maxBodySize int64) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetStreamedBodyDigest(m, maxBodySize)
+		return nil
+	}
+}
+
+// WithStreamedBodySpill spill streamed request bodies larger than threshold bytes to temporary file in dir
+func WithStreamedBodySpill(threshold int64, dir string) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetStreamedBodySpill(threshold, dir)
+		return nil
+	}
+}
+
+// WithVerifyCache cache up to size successful verifications (till the signature validity window ends)
+func WithVerifyCache(size int) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetVerifyCache(size)
+		return nil
+	}
+}
+
+// WithPrincipalResolver set resolver of verified keyId to principal stored in the request context by middleware
+func WithPrincipalResolver(f PrincipalResolver) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetPrincipalResolver(f)
+		return nil
+	}
+}
+
+// WithRejections set middleware rejection statuses & response renderer
+func WithRejections(r Rejections) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetRejections(r)
+		return nil
+	}
+}
+
+// WithReportOnly enable report-only mode: middleware verifies signatures but never rejects requests
+func WithReportOnly(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetReportOnly(v)
+		return nil
+	}
+}
+
+// WithVerificationReporter set func called with the result of every middleware verification
+func WithVerificationReporter(f func(r *http.Request, res VerificationResult)) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetVerificationReporter(f)
+		return nil
+	}
+}
+
+// WithKeyResolutionTimeout set timeout of secrets storage lookups per sign or verify call
+func WithKeyResolutionTimeout(d time.Duration) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetKeyResolutionTimeout(d)
+		return nil
+	}
+}
+
+// WithBothSignatureHeaders put signature to both Signature & Authorization headers
+func WithBothSignatureHeaders(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetBothSignatureHeaders(v)
+		return nil
+	}
+}
+
+// WithRequestTargetForm set form of the request URI in (request-target) & authority source
+func WithRequestTargetForm(f RequestTargetForm, a RequestTargetAuthority) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetRequestTargetForm(f, a)
+		return nil
+	}
+}
+
+// WithTrustedProxies set CIDRs of trusted proxies (X-Forwarded-Host & X-Forwarded-Proto are used)
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetTrustedProxies(cidrs...)
+	}
+}
+
+// WithMaxBodySize limit bodies buffered in memory to create or verify digest
+func WithMaxBodySize(n int64) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetMaxBodySize(n)
+		return nil
+	}
+}
+
+// WithKeyTypeAlgorithm set algorithm of secrets without algorithm by key type
+func WithKeyTypeAlgorithm(keyType string, alg string) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetKeyTypeAlgorithm(keyType, alg)
+	}
+}
+
+// WithBufferRequestBody read the whole request body in VerifyRequests middleware & store it in the request context
+func WithBufferRequestBody(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetBufferRequestBody(v)
+		return nil
+	}
+}
+
+// WithAlgorithmRanking verify only the strongest acceptable of multiple signatures
+func WithAlgorithmRanking(ranking []string) Option {
+	if ranking != nil {
+		ranking = append(make([]string, 0, len(ranking)), ranking...)
+	}
+	return func(hs *HTTPSignatures) error {
+		return hs.SetAlgorithmRanking(ranking)
+	}
+}
+
+// WithKeyPin pin public key of keyId by SPKI SHA-256 fingerprint
+func WithKeyPin(keyID string, fingerprint string) Option {
+	return func(hs *HTTPSignatures) error {
+		return hs.SetKeyPin(keyID, fingerprint)
+	}
+}
+
+// WithCertificateRoots set root pool to validate X.509 certificates of secrets
+func WithCertificateRoots(roots *x509.CertPool, usages ...x509.ExtKeyUsage) Option {
+	usages = append([]x509.ExtKeyUsage(nil), usages...)
+	return func(hs *HTTPSignatures) error {
+		hs.SetCertificateRoots(roots, usages...)
+		return nil
+	}
+}
+
+// WithRevocationChecker check revocation status of certificate chains of secrets
+func WithRevocationChecker(c RevocationChecker, p RevocationPolicy) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetRevocationChecker(c, p)
+		return nil
+	}
+}
+
+// WithUniformErrors reject all requests by VerifyRequests middleware with the same status & reason
+func WithUniformErrors(v bool) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetUniformErrors(v)
+		return nil
+	}
+}
+
+// WithOnVerifySuccess set func called after every successful request verification
+func WithOnVerifySuccess(f func(r *http.Request, e VerifyEvent)) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.OnVerifySuccess(f)
+		return nil
+	}
+}
+
+// WithOnVerifyFailure set func called after every failed request verification
+func WithOnVerifyFailure(f func(r *http.Request, e VerifyEvent)) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.OnVerifyFailure(f)
+		return nil
+	}
+}
+
+// WithQueryNormalization set canonicalization of the query in (request-target)
+func WithQueryNormalization(q QueryNormalization) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetQueryNormalization(q)
+		return nil
+	}
+}
+
+// WithKeyExpiryHook set func called when a key used to sign or verify expires soon
+func WithKeyExpiryHook(before time.Duration, f func(secret Secret, expiresIn time.Duration)) Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetKeyExpiryHook(before, f)
+		return nil
+	}
+}
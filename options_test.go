@@ -0,0 +1,127 @@
+package httpsignatures
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Without options",
+		},
+		{
+			name: "All options",
+			opts: []Option{
+				WithSecretsStorage(testSecretsStorage),
+				WithAlgorithms(RsaDummy{}),
+				WithDigestAlgorithms(testAlg{}),
+				WithDefaultDigest(testAlgName),
+				WithVerifyDigest(false),
+				WithSignatureHeaders("(request-target)", "(created)"),
+				WithExpiresSeconds(60),
+				WithParserMode(ParserModeStrict),
+				WithPolicy(Policy{RequiredHeaders: []string{"(created)"}}),
+				WithClock(time.Now),
+				WithLogger(nil),
+			},
+		},
+		{
+			name:        "Unsupported default digest",
+			opts:        []Option{WithDefaultDigest("MD4")},
+			wantErrType: testErrDigestType,
+			wantErrMsg:  "ErrDigest: unsupported default digest hash algorithm 'MD4'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.opts...)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			if err != nil {
+				assert(t, nil, err, tt.wantErrType, tt.name, nil, tt.wantErrMsg)
+				return
+			}
+			if got == nil {
+				t.Errorf("%s: HTTPSignatures is nil", tt.name)
+			}
+		})
+	}
+}
+
+func TestNewSignVerify(t *testing.T) {
+	now := time.Unix(1592250027, 0)
+	hs, err := New(
+		WithSecretsStorage(testSecretsStorage),
+		WithSignatureHeaders("(request-target)", "(created)", "(expires)"),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("New error = %v", err)
+	}
+
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := hs.Verify(r); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("Verify error = %v, want %v", err, ErrSignatureExpired)
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	now := time.Unix(1592250027, 0)
+	headers := []string{"(request-target)", "(created)"}
+	required := []string{"(request-target)"}
+	opts := []Option{
+		WithSecretsStorage(testSecretsStorage),
+		WithSignatureHeaders(headers...),
+		WithPolicy(Policy{RequiredHeaders: required}),
+		WithClock(func() time.Time { return now }),
+	}
+	headers[0], required[0] = "host", "host"
+
+	signer, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New error = %v", err)
+	}
+	verifier, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New error = %v", err)
+	}
+	signer.SetDefaultSignatureHeaders([]string{"(created)"})
+
+	r := testGetRequest()
+	if err := verifier.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	parsed, err := ParseSignatureHeader(r.Header.Get(signatureHeader))
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	if len(parsed.Headers) != 2 || parsed.Headers[0] != "(request-target)" {
+		t.Errorf("signed headers = %v, want [(request-target) (created)]", parsed.Headers)
+	}
+	if err := verifier.Verify(r); err != nil {
+		t.Errorf("Verify error = %v", err)
+	}
+	r = testGetRequest()
+	if err := signer.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	if err := verifier.Verify(r); err == nil {
+		t.Errorf("Verify without required (request-target): no error")
+	}
+}
@@ -0,0 +1,95 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Policy signature verification policy
+type Policy struct {
+	// RequiredHeaders headers (and pseudo-headers like "(request-target)") which must be signed
+	RequiredHeaders []string
+	// MaxAge max signature age by (created) param, 0 — no limit. Signatures without (created) are not checked,
+	// add "(created)" to RequiredHeaders to require it
+	MaxAge time.Duration
+	// MaxDateAge max age of the signed Date header, 0 — no limit. Used by peers which sign Date instead of
+	// (created), e.g. Mastodon
+	MaxDateAge time.Duration
+	// Challenge add WWW-Authenticate challenge describing required signature to 401 responses of VerifyRequests
+	Challenge bool
+	// Realm realm param of the challenge (omitted if empty)
+	Realm string
+}
+
+// challenge render WWW-Authenticate header value, e.g. Signature realm="api",headers="(request-target) date digest"
+// Headers are RequiredHeaders or default signature headers if policy doesn't require any.
+func (p Policy) challenge(defaultHeaders []string) string {
+	headers := p.RequiredHeaders
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+	var b strings.Builder
+	b.WriteString(authorizationScheme)
+	sep := " "
+	if len(p.Realm) > 0 {
+		b.WriteString(fmt.Sprintf(` %s="%s"`, paramRealm, quotedString(p.Realm)))
+		sep = ","
+	}
+	if len(headers) > 0 {
+		b.WriteString(fmt.Sprintf(`%s%s="%s"`, sep, paramHeaders, strings.ToLower(strings.Join(headers, " "))))
+	}
+	return b.String()
+}
+
+// check verify parsed signature header & message headers satisfy policy
+func (p Policy) check(sh Headers, header http.Header, now time.Time, gap time.Duration) *ErrHS {
+	for _, h := range p.RequiredHeaders {
+		found := false
+		for _, s := range sh.Headers {
+			if strings.EqualFold(h, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ErrHS{
+				Message: fmt.Sprintf("header '%s' is not signed", strings.ToLower(h)),
+				kind:    ErrPolicyViolation,
+			}
+		}
+	}
+
+	if p.MaxAge > 0 && !sh.Created.IsZero() && sh.Created != time.Unix(0, 0) {
+		for _, s := range sh.Headers {
+			if s == created && now.Sub(sh.Created) > p.MaxAge+gap {
+				return &ErrHS{Message: "signature expired", kind: ErrSignatureExpired}
+			}
+		}
+	}
+
+	if p.MaxDateAge > 0 {
+		for _, s := range sh.Headers {
+			if strings.EqualFold(s, "date") {
+				return p.checkDate(header.Get("Date"), now, gap)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDate verify Date header is not older than MaxDateAge & not in future
+func (p Policy) checkDate(v string, now time.Time, gap time.Duration) *ErrHS {
+	date, err := http.ParseTime(v)
+	if err != nil {
+		return &ErrHS{Message: "wrong date header", Err: err, kind: ErrPolicyViolation}
+	}
+	if now.Sub(date) > p.MaxDateAge+gap {
+		return &ErrHS{Message: "signature expired", kind: ErrSignatureExpired}
+	}
+	if date.After(now.Add(gap)) {
+		return &ErrHS{Message: "signature in future", kind: ErrSignatureInFuture}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	now := time.Unix(1592250027, 0)
+	type args struct {
+		policy Policy
+		sh     Headers
+		date   string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Empty policy",
+			args: args{
+				sh: Headers{Headers: []string{"(created)"}},
+			},
+		},
+		{
+			name: "Required headers signed",
+			args: args{
+				policy: Policy{RequiredHeaders: []string{"(request-target)", "Digest"}},
+				sh:     Headers{Headers: []string{"(request-target)", "(created)", "digest"}},
+			},
+		},
+		{
+			name: "Required header not signed",
+			args: args{
+				policy: Policy{RequiredHeaders: []string{"(request-target)", "Digest"}},
+				sh:     Headers{Headers: []string{"(request-target)", "(created)"}},
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "header 'digest' is not signed",
+		},
+		{
+			name: "Max age OK",
+			args: args{
+				policy: Policy{MaxAge: time.Minute},
+				sh:     Headers{Headers: []string{"(created)"}, Created: now.Add(-time.Minute)},
+			},
+		},
+		{
+			name: "Max age exceeded",
+			args: args{
+				policy: Policy{MaxAge: time.Minute},
+				sh:     Headers{Headers: []string{"(created)"}, Created: now.Add(-time.Minute - time.Second)},
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "signature expired",
+		},
+		{
+			name: "Max age without created",
+			args: args{
+				policy: Policy{MaxAge: time.Minute},
+				sh:     Headers{Headers: []string{"date"}, Created: now.Add(-time.Hour)},
+			},
+		},
+		{
+			name: "Max date age OK",
+			args: args{
+				policy: Policy{MaxDateAge: time.Hour},
+				sh:     Headers{Headers: []string{"Date"}},
+				date:   now.Add(-time.Hour).UTC().Format(http.TimeFormat),
+			},
+		},
+		{
+			name: "Max date age exceeded",
+			args: args{
+				policy: Policy{MaxDateAge: time.Hour},
+				sh:     Headers{Headers: []string{"date"}},
+				date:   now.Add(-time.Hour - time.Second).UTC().Format(http.TimeFormat),
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "signature expired",
+		},
+		{
+			name: "Date in future",
+			args: args{
+				policy: Policy{MaxDateAge: time.Hour},
+				sh:     Headers{Headers: []string{"date"}},
+				date:   now.Add(time.Second).UTC().Format(http.TimeFormat),
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg:  "signature in future",
+		},
+		{
+			name: "Wrong date",
+			args: args{
+				policy: Policy{MaxDateAge: time.Hour},
+				sh:     Headers{Headers: []string{"date"}},
+				date:   "yesterday",
+			},
+			wantErrType: testHSErrType,
+			wantErrMsg: "wrong date header: parsing time \"yesterday\" as \"Mon Jan _2 15:04:05 2006\": " +
+				"cannot parse \"yesterday\" as \"Mon\"",
+		},
+		{
+			name: "Max date age, date is not signed",
+			args: args{
+				policy: Policy{MaxDateAge: time.Hour},
+				sh:     Headers{Headers: []string{"(created)"}},
+				date:   "yesterday",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Date", tt.args.date)
+			err := tt.args.policy.check(tt.args.sh, header, now, 0)
+			if err == nil {
+				if len(tt.wantErrMsg) > 0 {
+					t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+				}
+				return
+			}
+			assert(t, nil, err, tt.wantErrType, tt.name, nil, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestHSSetPolicy(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+
+	hs.SetPolicy(Policy{RequiredHeaders: []string{"digest"}})
+	err := hs.Verify(r)
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Verify error = %v, want %v", err, ErrPolicyViolation)
+	}
+
+	hs.SetPolicy(Policy{})
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"sync"
+
+	"github.com/igor-pavlenko/httpsignatures-go/sigparse"
+)
+
+// maxPooledBufferSize buffers grown bigger (e.g. by huge headers) are not returned to the pool
+const maxPooledBufferSize = 64 << 10
+
+// hashPool reuse hash.Hash instances of one algorithm
+type hashPool struct {
+	p sync.Pool
+}
+
+func newHashPool(newHash func() hash.Hash) *hashPool {
+	return &hashPool{p: sync.Pool{New: func() interface{} { return newHash() }}}
+}
+
+func (p *hashPool) get() hash.Hash {
+	return p.p.Get().(hash.Hash)
+}
+
+func (p *hashPool) put(h hash.Hash) {
+	h.Reset()
+	p.p.Put(h)
+}
+
+var (
+	md5Pool    = newHashPool(md5.New)
+	sha256Pool = newHashPool(sha256.New)
+	sha512Pool = newHashPool(sha512.New)
+)
+
+// bufferPool reuse buffers to build signature strings
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// getParser get parser with default settings from the pool
+func getParser() *Parser {
+	return sigparse.GetParser()
+}
+
+// putParser return parser to the pool. Parsed headers don't share memory with the parser, so they are
+// still valid.
+func putParser(p *Parser) {
+	sigparse.PutParser(p)
+}
@@ -0,0 +1,61 @@
+package httpsignatures
+
+import "time"
+
+const (
+	// mastodonKeyIDFragment fragment of the actor's public key id
+	mastodonKeyIDFragment = "#main-key"
+	// mastodonMaxDateAge Mastodon rejects requests with older Date header
+	mastodonMaxDateAge = 12 * time.Hour
+	// mastodonClockSkew allowed clock difference between servers
+	mastodonClockSkew = time.Hour
+)
+
+// WithHS2019 configure Mastodon preset (WithMastodon) for "hs2019" signatures: created signatures have "hs2019"
+// algorithm param (RSA-SHA256 key signature), verification derives algorithm from the key for "hs2019" & missing
+// algorithm param. The order of the headers param is used, e.g. headers in alphabetical order.
+func WithHS2019() Option {
+	return func(hs *HTTPSignatures) error {
+		if err := WithMastodon()(hs); err != nil {
+			return err
+		}
+		if err := hs.SetSignatureAlgorithmAlias("", ""); err != nil {
+			return err
+		}
+		hs.SetQuirks(Quirks{AllowMissingAlgorithm: true})
+		hs.algorithmParam = algHs2019
+		return nil
+	}
+}
+
+// WithMastodon configure signing & verification compatible with Mastodon (and other Fediverse servers):
+// signed headers "(request-target) host date digest" (without digest for requests without body), SHA-256 Digest,
+// no (created) & (expires).
+// Verification requires signed (request-target), host & date, checks Date header age, treats missing algorithm
+// as RSA-SHA256, accepts "hs2019" & malformed params.
+// Store secrets with "rsa-sha256" algorithm, Mastodon compares it case-sensitively.
+func WithMastodon() Option {
+	return func(hs *HTTPSignatures) error {
+		hs.SetDefaultSignatureHeaders([]string{"(request-target)", "host", "date", "digest"})
+		if err := hs.SetDefaultDigestAlgorithm(algSha256); err != nil {
+			return err
+		}
+		if err := hs.SetSignatureAlgorithmAlias("", algRsaSha256); err != nil {
+			return err
+		}
+		hs.SetDefaultExpiresSeconds(0)
+		hs.SetEmptyBodyDigest(EmptyBodyDigestSkip)
+		hs.SetParserMode(ParserModeLenient)
+		hs.SetPolicy(Policy{
+			RequiredHeaders: []string{"(request-target)", "host", "date"},
+			MaxDateAge:      mastodonMaxDateAge,
+		})
+		hs.defaultTimeGap = mastodonClockSkew
+		return nil
+	}
+}
+
+// MastodonKeyID return keyId of the actor's public key, e.g. "https://example.com/users/alice#main-key"
+func MastodonKeyID(actorURL string) string {
+	return actorURL + mastodonKeyIDFragment
+}
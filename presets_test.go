@@ -0,0 +1,172 @@
+package httpsignatures
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMastodonHS(t *testing.T, opts ...Option) *HTTPSignatures {
+	keyID := MastodonKeyID("https://example.com/users/alice")
+	ss := NewSimpleSecretsStorage(map[string]Secret{
+		keyID: {
+			KeyID:      keyID,
+			PublicKey:  testRsaPublicKey2048,
+			PrivateKey: testRsaPrivateKey2048,
+			Algorithm:  "rsa-sha256",
+		},
+	})
+	now := time.Unix(1591130723, 0)
+	opts = append([]Option{WithSecretsStorage(ss), WithMastodon(), WithClock(func() time.Time { return now })}, opts...)
+	hs, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hs
+}
+
+func testMastodonRequest(method string, body string, date time.Time) *http.Request {
+	r, _ := http.NewRequest(method, "https://example.com/users/bob/inbox", strings.NewReader(body))
+	r.Header.Set("Host", "example.com")
+	r.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+	return r
+}
+
+func TestWithMastodon(t *testing.T) {
+	hs := testMastodonHS(t)
+	now := hs.now()
+	keyID := "https://example.com/users/alice#main-key"
+
+	r := testMastodonRequest(http.MethodPost, testBodyExample, now)
+	if err := hs.Sign(keyID, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Header.Get(digestHeader), "SHA-256=") {
+		t.Errorf("wrong digest header: %s", r.Header.Get(digestHeader))
+	}
+	wantPrefix := `keyId="` + keyID + `",algorithm="rsa-sha256",headers="(request-target) host date digest",signature="`
+	if !strings.HasPrefix(r.Header.Get(signatureHeader), wantPrefix) {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = %v...", r.Header.Get(signatureHeader), wantPrefix)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// Mastodon may omit algorithm or send hs2019
+	for _, alg := range []string{"", `algorithm="hs2019",`} {
+		v := r.Header.Get(signatureHeader)
+		v = strings.Replace(v, `algorithm="rsa-sha256",`, alg, 1)
+		r.Header.Set(signatureHeader, v)
+		if err := hs.Verify(r); err != nil {
+			t.Errorf("algorithm '%s': unexpected error: %s", alg, err)
+		}
+	}
+
+	// Old Date header
+	r = testMastodonRequest(http.MethodPost, testBodyExample, now.Add(-14*time.Hour))
+	if err := hs.Sign(keyID, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("got error %v, want ErrSignatureExpired", err)
+	}
+
+	// Date isn't signed
+	r = testMastodonRequest(http.MethodPost, testBodyExample, now)
+	r.Header.Set(signatureHeader, `keyId="`+keyID+`",headers="(request-target) host",signature="e30="`)
+	if err := hs.Verify(r); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("got error %v, want ErrPolicyViolation", err)
+	}
+
+	// GET requests have no body to digest
+	r, _ = http.NewRequest(http.MethodGet, "https://example.com/users/bob", nil)
+	r.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	if err := hs.Sign(keyID, r); err != nil {
+		t.Fatal(err)
+	}
+	wantPrefix = `keyId="` + keyID + `",algorithm="rsa-sha256",headers="(request-target) host date",signature="`
+	if !strings.HasPrefix(r.Header.Get(signatureHeader), wantPrefix) || r.Header.Get(digestHeader) != "" {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = %v...", r.Header.Get(signatureHeader), wantPrefix)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+// hs2019 signatures (headers in alphabetical & default order) signed with testRsaPrivateKey2048
+const (
+	testFediverseBody   = `{"type":"Follow"}`
+	testFediverseDigest = "SHA-256=GYwYnH3BiO6aICFt0ThC5bUIJ4byvqdpWtR8m5fNkww="
+	testAlphabeticalSig = "DGqVaMGTW9V6ZBC9fivChsGa/ZEXRIXVLh7S/8qrQEZJaH37QbhCWJN5F4r3FVlsuh3WFpfMl+vTMaDCFVZptIeh1" +
+		"gF18nQ/o8OlHjBHBXScazPXrkHnyXYQQzclVxoXYNpThM8RL7/s/smM8OzTGpoHXs5w0oazkJmrJgxnRMpVdjhM6K3H3ozKHUCKZJxcp" +
+		"sH64HaFY2/rTIvpC7nrUEDE9iVduOd/V4jFAXLyidsojkHDLYeb+lfFndugPgqikmExmjjixL/tu0qa6tzo9oh4TC/0me1xhUtHgTp/" +
+		"XYdHMgN0Nbfe2u92GIwK3X++576IseE4pE+xe5dtoMnMHw=="
+	testHS2019Sig = "Yta2xjhCnupxOml2LXIAyxzC9RuNknrdlqPvho0zlKUgrg4voNSUfJIucZ7Unmvdtw7hnc6I5UqjAN0ugJknASafXd" +
+		"Y1tnt/xJF+GtTdFWR5H00MdAFOiWnCIPy9MD9mgV0H0wZifej3UqXNZ0+DbIDTmxhM8OKKCerDW7vRc77tl/mVROXJTPsMhREyGXWph" +
+		"6cHTK8qocV8K75LmZAH2DA/cg553rFbaQV16wDcpJJz4VwoaX3NvPNF/KHvRgTzLpl00dAvGix2eQv7/IhqT4VLrmQuTp8GehVvX5AF" +
+		"6e5Zd2kCJ0S19df9u1TZeX1UYB1F+IvzU+yHawPCrAkI7Q=="
+)
+
+func TestWithHS2019(t *testing.T) {
+	const keyID = "https://example.com/users/alice#main-key"
+	tests := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{
+			name: "hs2019, headers in alphabetical order",
+			header: `keyId="` + keyID + `",algorithm="hs2019",headers="(request-target) content-length date digest ` +
+				`host",signature="` + testAlphabeticalSig + `"`,
+		},
+		{
+			name: "hs2019",
+			header: `keyId="` + keyID + `",algorithm="hs2019",headers="(request-target) host date digest",` +
+				`signature="` + testHS2019Sig + `"`,
+		},
+		{
+			name:   "Without algorithm",
+			header: `keyId="` + keyID + `",headers="(request-target) host date digest",signature="` + testHS2019Sig + `"`,
+		},
+		{
+			name: "Wrong algorithm",
+			header: `keyId="` + keyID + `",algorithm="rsa-sha512",headers="(request-target) host date digest",` +
+				`signature="` + testHS2019Sig + `"`,
+			wantErr: ErrAlgorithmMismatch,
+		},
+		{
+			name: "Wrong headers order",
+			header: `keyId="` + keyID + `",algorithm="hs2019",headers="(request-target) date host digest",` +
+				`signature="` + testHS2019Sig + `"`,
+			wantErr: ErrWrongSignature,
+		},
+	}
+	hs := testMastodonHS(t, WithHS2019())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testMastodonRequest(http.MethodPost, testFediverseBody, hs.now())
+			r.Header.Set("Content-Length", "17")
+			r.Header.Set(digestHeader, testFediverseDigest)
+			r.Header.Set(signatureHeader, tt.header)
+			err := hs.Verify(r)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	r := testMastodonRequest(http.MethodPost, testFediverseBody, hs.now())
+	if err := hs.Sign(keyID, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `keyId="` + keyID + `",algorithm="hs2019",headers="(request-target) host date digest",signature="` +
+		testHS2019Sig + `"`
+	if got := r.Header.Get(signatureHeader); got != want {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = %v", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+package httpsignatures
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SignAt sign request with explicit created time & expires (if "(expires)" is signed) instead of now, e.g. for
+// requests queued for later delivery. Zero expires uses default expires seconds.
+func (hs *HTTPSignatures) SignAt(secretKeyID string, r *http.Request, created time.Time, expires time.Time) error {
+	return hs.SignAtCtx(context.Background(), secretKeyID, r, created, expires)
+}
+
+// SignAtCtx sign request with explicit created & expires time, stop signing when ctx is done
+func (hs *HTTPSignatures) SignAtCtx(ctx context.Context, secretKeyID string, r *http.Request, created time.Time,
+	expires time.Time) error {
+	created = time.Unix(created.Unix(), 0)
+	ps := *hs
+	ps.now = func() time.Time { return created }
+	if !expires.IsZero() {
+		sec := expires.Unix() - created.Unix()
+		if sec <= 0 {
+			return &ErrHS{Message: "expires must be after created"}
+		}
+		ps.defaultExpiresSec = uint32(sec)
+	}
+	return ps.SignCtx(ctx, secretKeyID, r)
+}
@@ -0,0 +1,54 @@
+package httpsignatures
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAt(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)", "(expires)"})
+	now := time.Now()
+	created := now.Add(time.Hour)
+
+	r := testBenchRequest()
+	if err := hs.SignAt("hmac", r, created, created.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	want := `created=` + formatTimestamp(time.Unix(created.Unix(), 0)) + `,expires=` +
+		formatTimestamp(time.Unix(created.Unix()+600, 0)) + `,`
+	if !strings.Contains(r.Header.Get(signatureHeader), want) {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = ...%v...", r.Header.Get(signatureHeader), want)
+	}
+	// Not valid yet
+	if err := hs.Verify(r); !errors.Is(err, ErrSignatureInFuture) {
+		t.Errorf("got error %v, want ErrSignatureInFuture", err)
+	}
+	// Valid when delivered
+	hs.SetClock(func() time.Time { return created.Add(5 * time.Minute) })
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	hs.SetClock(func() time.Time { return created.Add(11 * time.Minute) })
+	if err := hs.Verify(r); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("got error %v, want ErrSignatureExpired", err)
+	}
+
+	// Default expires
+	r = testBenchRequest()
+	if err := hs.SignAt("hmac", r, created, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	want = `expires=` + formatTimestamp(time.Unix(created.Unix()+defaultExpiresSec, 0)) + `,`
+	if !strings.Contains(r.Header.Get(signatureHeader), want) {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = ...%v...", r.Header.Get(signatureHeader), want)
+	}
+
+	err := hs.SignAt("hmac", testBenchRequest(), created, created)
+	assert(t, nil, err, testHSErrType, "Expires before created", nil, "expires must be after created")
+	if err == nil {
+		t.Errorf("no error, want expires must be after created")
+	}
+}
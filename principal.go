@@ -0,0 +1,34 @@
+package httpsignatures
+
+import "context"
+
+// PrincipalResolver map verified secret (keyId) to application principal/tenant, e.g. user or client account.
+// Error rejects the request.
+type PrincipalResolver func(ctx context.Context, secret Secret) (interface{}, error)
+
+type principalContextKey struct{}
+
+// SetPrincipalResolver set resolver of verified keyId to principal, VerifyRequests middleware stores the principal
+// in the request context (PrincipalFromContext). Signatures sent in trailers are verified before the handler too,
+// so their principal is stored the same way.
+func (hs *HTTPSignatures) SetPrincipalResolver(f PrincipalResolver) {
+	hs.principal = f
+}
+
+// PrincipalFromContext return principal stored by VerifyRequests middleware
+func PrincipalFromContext(ctx context.Context) (interface{}, bool) {
+	p := ctx.Value(principalContextKey{})
+	return p, p != nil
+}
+
+// withPrincipal resolve principal of verified secret & store it in the context
+func (hs *HTTPSignatures) withPrincipal(ctx context.Context, secret Secret) (context.Context, error) {
+	if hs.principal == nil || len(secret.KeyID) == 0 {
+		return ctx, nil
+	}
+	p, err := hs.principal(ctx, secret)
+	if err != nil {
+		return ctx, &ErrHS{Message: "principal not found", Err: err, kind: ErrUnknownKeyID}
+	}
+	return context.WithValue(ctx, principalContextKey{}, p), nil
+}
@@ -0,0 +1,113 @@
+package httpsignatures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Profile named signing configuration, e.g. "webhooks" or "s2s". Empty fields use HTTPSignatures defaults.
+type Profile struct {
+	// Algorithm algorithm param of created signatures (e.g. "hs2019"), key algorithm if empty
+	Algorithm string
+	// Headers headers to sign
+	Headers []string
+	// DigestAlgorithm Digest header hash algorithm
+	DigestAlgorithm string
+	// Header header to put signature to: "Signature" or "Authorization" ("Signature" scheme)
+	Header string
+	// TTL signature expires time, rounded to seconds
+	TTL time.Duration
+	// SignatureEncoding encoding of the signature param, base64 means HTTPSignatures default (see Quirks)
+	SignatureEncoding SignatureEncoding
+}
+
+// SetProfile register signing profile by name, algorithms & header are validated
+func (hs *HTTPSignatures) SetProfile(name string, p Profile) error {
+	if len(p.Algorithm) > 0 {
+		_, isAlias := hs.algAliases[strings.ToUpper(p.Algorithm)]
+		if _, ok := hs.alg[hs.resolveAlgorithm(p.Algorithm)]; !ok && !isAlias {
+			return &ErrHS{
+				Message: fmt.Sprintf("algorithm '%s' not supported", p.Algorithm),
+				kind:    ErrUnsupportedAlgorithm,
+			}
+		}
+	}
+	if len(p.DigestAlgorithm) > 0 {
+		if _, _, ok := hs.d.lookup(p.DigestAlgorithm); !ok {
+			return &ErrHS{
+				Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", p.DigestAlgorithm),
+				kind:    ErrUnsupportedAlgorithm,
+			}
+		}
+	}
+	if len(p.Header) > 0 && !strings.EqualFold(p.Header, signatureHeader) &&
+		!strings.EqualFold(p.Header, authorizationHeader) {
+		return &ErrHS{Message: fmt.Sprintf("header '%s' not supported", p.Header)}
+	}
+	if hs.profiles == nil {
+		hs.profiles = make(map[string]Profile)
+	}
+	p.Headers = append([]string(nil), p.Headers...)
+	hs.profiles[name] = p
+	return nil
+}
+
+// Profile return registered signing profile
+func (hs *HTTPSignatures) Profile(name string) (Profile, bool) {
+	p, ok := hs.profiles[name]
+	return p, ok
+}
+
+// SignWithProfile sign request with registered profile
+func (hs *HTTPSignatures) SignWithProfile(r *http.Request, profile string, secretKeyID string) error {
+	return hs.SignWithProfileCtx(context.Background(), r, profile, secretKeyID)
+}
+
+// SignWithProfileCtx sign request with registered profile, stop signing when ctx is done
+func (hs *HTTPSignatures) SignWithProfileCtx(ctx context.Context, r *http.Request, profile string,
+	secretKeyID string) error {
+	p, ok := hs.profiles[profile]
+	if !ok {
+		return &ErrHS{Message: fmt.Sprintf("profile '%s' not found", profile)}
+	}
+
+	ps := *hs
+	if len(p.Algorithm) > 0 {
+		ps.algorithmParam = p.Algorithm
+	}
+	if len(p.Headers) > 0 {
+		ps.defaultHeaders = p.Headers
+	}
+	if p.TTL > 0 {
+		ps.defaultExpiresSec = uint32(p.TTL / time.Second)
+	}
+	if p.SignatureEncoding != SignatureEncodingBase64 {
+		ps.quirks.SignatureEncoding = p.SignatureEncoding
+	}
+	digestAlg := hs.d.defaultAlg
+	if len(p.DigestAlgorithm) > 0 {
+		digestAlg = p.DigestAlgorithm
+	}
+
+	createDigest := func(h []string) (string, error) {
+		if ps.hasDigest(h) {
+			return hs.d.Create(digestAlg, r)
+		}
+		return "", nil
+	}
+	err := ps.sign(ctx, secretKeyID, ps.defaultHeaders, r.Header, hs.requestTarget(r), r.Host, createDigest)
+	if err != nil {
+		hs.log.Error("signing failed", "keyId", secretKeyID, "profile", profile, "uri", r.URL.String(), "err", err)
+		return err
+	}
+	if strings.EqualFold(p.Header, authorizationHeader) && !hs.bothHeaders {
+		v := r.Header.Get(signatureHeader)
+		r.Header.Del(signatureHeader)
+		r.Header.Set(authorizationHeader, authorizationScheme+" "+v)
+	}
+	hs.log.Debug("request signed", "keyId", secretKeyID, "profile", profile, "uri", r.URL.String())
+	return nil
+}
@@ -0,0 +1,117 @@
+package httpsignatures
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHSSetProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    Profile
+		wantErrMsg string
+	}{
+		{
+			name:    "Empty profile OK",
+			profile: Profile{},
+		},
+		{
+			name: "Profile OK",
+			profile: Profile{
+				Algorithm:       "hs2019",
+				Headers:         []string{"(request-target)", "digest"},
+				DigestAlgorithm: "sha256",
+				Header:          "authorization",
+				TTL:             time.Minute,
+			},
+		},
+		{
+			name:       "Unsupported algorithm",
+			profile:    Profile{Algorithm: "rsa-md5"},
+			wantErrMsg: "algorithm 'rsa-md5' not supported",
+		},
+		{
+			name:       "Unsupported digest algorithm",
+			profile:    Profile{DigestAlgorithm: "SHA-1"},
+			wantErrMsg: "unsupported digest hash algorithm 'SHA-1'",
+		},
+		{
+			name:       "Unsupported header",
+			profile:    Profile{Header: "X-Signature"},
+			wantErrMsg: "header 'X-Signature' not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			err := hs.SetProfile("test", tt.profile)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestSignWithProfile(t *testing.T) {
+	now := time.Unix(1591130723, 0)
+	hs, err := New(
+		WithSecretsStorage(testBenchSecrets),
+		WithClock(func() time.Time { return now }),
+		WithProfile("webhooks", Profile{
+			Headers:         []string{"(request-target)", "(created)", "(expires)", "digest"},
+			DigestAlgorithm: "SHA-512",
+			TTL:             5 * time.Minute,
+		}),
+		WithProfile("s2s", Profile{
+			Algorithm: "hs2019",
+			Headers:   []string{"(request-target)", "host"},
+			Header:    "Authorization",
+		}),
+		WithProfile("rsa", Profile{Algorithm: "rsa-sha256"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := testBenchRequest()
+	if err := hs.SignWithProfile(r, "webhooks", "hmac"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Header.Get(digestHeader), "SHA-512=") {
+		t.Errorf("wrong digest header: %s", r.Header.Get(digestHeader))
+	}
+	want := `keyId="hmac",algorithm="HMAC-SHA256",created=1591130723,expires=1591131023,` +
+		`headers="(request-target) (created) (expires) digest",signature="`
+	if got := r.Header.Get(signatureHeader); !strings.HasPrefix(got, want) {
+		t.Errorf("wrong signature header\ngot  = %v,\nwant = %v...", got, want)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	r = testBenchRequest()
+	if err := hs.SignWithProfile(r, "s2s", "ecdsa"); err != nil {
+		t.Fatal(err)
+	}
+	want = `Signature keyId="ecdsa",algorithm="hs2019",headers="(request-target) host",signature="`
+	if got := r.Header.Get(authorizationHeader); !strings.HasPrefix(got, want) || r.Header.Get(signatureHeader) != "" {
+		t.Errorf("wrong authorization header\ngot  = %v,\nwant = %v...", got, want)
+	}
+
+	if err := hs.SignWithProfile(testBenchRequest(), "rsa", "hmac"); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("got error %v, want ErrAlgorithmMismatch", err)
+	}
+	err = hs.SignWithProfile(testBenchRequest(), "unknown", "hmac")
+	assert(t, nil, err, testHSErrType, "Unknown profile", nil, "profile 'unknown' not found")
+	if err == nil {
+		t.Errorf("no error, want profile not found")
+	}
+
+	// Defaults are not changed by profiles
+	if hs.defaultExpiresSec != defaultExpiresSec || len(hs.algorithmParam) > 0 || len(hs.defaultHeaders) != 1 {
+		t.Errorf("profile changed defaults")
+	}
+}
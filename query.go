@@ -0,0 +1,106 @@
+package httpsignatures
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// QueryNormalization canonicalization of the query in (request-target) (both Sign & Verify), to survive
+// intermediaries which reorder or re-encode query strings. Zero value keeps the query as is (default).
+type QueryNormalization struct {
+	// Sort sort params by name & value
+	Sort bool
+	// NormalizeEncoding decode & encode names & values again, e.g. "%7e" & "~" are the same
+	NormalizeEncoding bool
+	// DropParams names of params to drop, e.g. tracking "utm_source" added by intermediaries
+	DropParams []string
+}
+
+// enabled check any normalization is configured
+func (q QueryNormalization) enabled() bool {
+	return q.Sort || q.NormalizeEncoding || len(q.DropParams) > 0
+}
+
+// SetQueryNormalization set canonicalization of the query in (request-target), peers must use the same settings
+func (hs *HTTPSignatures) SetQueryNormalization(q QueryNormalization) {
+	q.DropParams = append([]string(nil), q.DropParams...)
+	hs.query = q
+}
+
+type queryParam struct {
+	name  string
+	value string
+	raw   string
+}
+
+// normalize normalize query of the request URI (path with query)
+func (q QueryNormalization) normalize(uri string) string {
+	i := strings.IndexByte(uri, '?')
+	if i < 0 || !q.enabled() {
+		return uri
+	}
+	params := make([]queryParam, 0, strings.Count(uri[i:], "&")+1)
+	for _, raw := range strings.Split(uri[i+1:], "&") {
+		if len(raw) == 0 {
+			continue
+		}
+		p := queryParam{name: raw, raw: raw}
+		if j := strings.IndexByte(raw, '='); j >= 0 {
+			p.name, p.value = raw[:j], raw[j+1:]
+		}
+		if q.dropped(p.name) {
+			continue
+		}
+		if q.NormalizeEncoding {
+			p.name, p.value = reencode(p.name), reencode(p.value)
+			p.raw = p.name
+			if strings.IndexByte(raw, '=') >= 0 {
+				p.raw += "=" + p.value
+			}
+		}
+		params = append(params, p)
+	}
+	if q.Sort {
+		sort.SliceStable(params, func(a, b int) bool {
+			if params[a].name != params[b].name {
+				return params[a].name < params[b].name
+			}
+			return params[a].value < params[b].value
+		})
+	}
+	if len(params) == 0 {
+		return uri[:i]
+	}
+	var b strings.Builder
+	b.WriteString(uri[:i+1])
+	for k, p := range params {
+		if k > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(p.raw)
+	}
+	return b.String()
+}
+
+// dropped check the param (encoded name) is in DropParams
+func (q QueryNormalization) dropped(name string) bool {
+	if n, err := url.QueryUnescape(name); err == nil {
+		name = n
+	}
+	for _, d := range q.DropParams {
+		if name == d {
+			return true
+		}
+	}
+	return false
+}
+
+// reencode decode & encode query component, invalid encoding is kept as is
+func reencode(s string) string {
+	d, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return url.QueryEscape(d)
+}
@@ -0,0 +1,63 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestQueryNormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		q    QueryNormalization
+		uri  string
+		want string
+	}{
+		{name: "Disabled", uri: "/foo?b=2&a=1", want: "/foo?b=2&a=1"},
+		{name: "No query", q: QueryNormalization{Sort: true}, uri: "/foo", want: "/foo"},
+		{name: "Sort", q: QueryNormalization{Sort: true}, uri: "/foo?b=2&a=3&a=1&c", want: "/foo?a=1&a=3&b=2&c"},
+		{
+			name: "Encoding",
+			q:    QueryNormalization{NormalizeEncoding: true},
+			uri:  "/foo?%7Ename=a%20b&x=%zz&flag",
+			want: "/foo?~name=a+b&x=%zz&flag",
+		},
+		{
+			name: "Drop params",
+			q:    QueryNormalization{DropParams: []string{"utm_source", "fbclid"}},
+			uri:  "/foo?utm_source=x&a=1&&fbclid=y&utm%5Fsource=z",
+			want: "/foo?a=1",
+		},
+		{name: "Drop all", q: QueryNormalization{DropParams: []string{"utm_source"}}, uri: "/foo?utm_source=x", want: "/foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.normalize(tt.uri); got != tt.want {
+				t.Errorf("normalize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryNormalizationVerify(t *testing.T) {
+	q := QueryNormalization{Sort: true, NormalizeEncoding: true, DropParams: []string{"utm_source"}}
+	hs, err := New(
+		WithSecretsStorage(testBenchSecrets),
+		WithSignatureHeaders("(request-target)", "(created)"),
+		WithQueryNormalization(q),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := http.NewRequest(http.MethodGet, "https://example.com/foo?b=%7E&a=1", nil)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	// Intermediary reorders the query & adds tracking param
+	r.URL.RawQuery = "utm_source=mail&a=1&b=~"
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := NewHTTPSignatures(testBenchSecrets).Verify(r); err == nil {
+		t.Error("Verify() without normalization: no error, want wrong signature")
+	}
+}
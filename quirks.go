@@ -0,0 +1,49 @@
+package httpsignatures
+
+// MissingHeaders handling of headers listed in the signature headers param but not found in the message
+type MissingHeaders int
+
+const (
+	// MissingHeadersError reject the signature (default)
+	MissingHeadersError MissingHeaders = iota
+	// MissingHeadersEmpty use empty value, e.g. "x-request-id: "
+	MissingHeadersEmpty
+	// MissingHeadersSkip skip the header line in the signature string
+	MissingHeadersSkip
+)
+
+// Quirks compatibility flags to verify signatures of known broken client libraries.
+// Header parsing quirks (e.g. quoted created & expires values) are set with SetParserMode,
+// the keyId is not known before the header is parsed.
+type Quirks struct {
+	// AllowMissingAlgorithm verify signatures without algorithm param with the key algorithm
+	AllowMissingAlgorithm bool
+	// CaseSensitiveHeaders use header names of the headers param as is in the signature string, e.g. "Host: ..."
+	CaseSensitiveHeaders bool
+	// MissingHeaders handling of signed headers not found in the message. Signing uses the value set by SetQuirks.
+	MissingHeaders MissingHeaders
+	// SignatureEncoding encoding of the signature param, e.g. base64url. Signing uses the value set by SetQuirks
+	// (or by the profile).
+	SignatureEncoding SignatureEncoding
+}
+
+// SetQuirks set compatibility quirks for all keys (none by default)
+func (hs *HTTPSignatures) SetQuirks(q Quirks) {
+	hs.quirks = q
+}
+
+// SetKeyQuirks set compatibility quirks for keyId, they replace quirks set by SetQuirks
+func (hs *HTTPSignatures) SetKeyQuirks(keyID string, q Quirks) {
+	if hs.keyQuirks == nil {
+		hs.keyQuirks = make(map[string]Quirks)
+	}
+	hs.keyQuirks[keyID] = q
+}
+
+// quirksFor return quirks of keyId
+func (hs *HTTPSignatures) quirksFor(keyID string) Quirks {
+	if q, ok := hs.keyQuirks[keyID]; ok {
+		return q
+	}
+	return hs.quirks
+}
@@ -0,0 +1,158 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestQuirks(t *testing.T) {
+	secret := Secret{KeyID: "hmac", PrivateKey: "secret", PublicKey: "secret", Algorithm: algHmacSha256}
+	sign := func(s string) string {
+		sig, _ := HmacSha256{}.Create(secret, []byte(s))
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	mixedCase := `keyId="hmac",algorithm="hmac-sha256",headers="Host Date",signature="` +
+		sign("Host: "+testHostExample+"\nDate: "+testDateExample) + `"`
+	noAlgorithm := `keyId="hmac",headers="host",signature="` + sign("host: "+testHostExample) + `"`
+
+	tests := []struct {
+		name      string
+		header    string
+		quirks    Quirks
+		keyQuirks map[string]Quirks
+		wantErr   error
+	}{
+		{
+			name:    "Case-sensitive headers without quirk",
+			header:  mixedCase,
+			wantErr: ErrWrongSignature,
+		},
+		{
+			name:   "Case-sensitive headers",
+			header: mixedCase,
+			quirks: Quirks{CaseSensitiveHeaders: true},
+		},
+		{
+			name:    "Missing algorithm without quirk",
+			header:  noAlgorithm,
+			wantErr: ErrAlgorithmMismatch,
+		},
+		{
+			name:   "Missing algorithm",
+			header: noAlgorithm,
+			quirks: Quirks{AllowMissingAlgorithm: true},
+		},
+		{
+			name:      "Missing algorithm for keyId",
+			header:    noAlgorithm,
+			keyQuirks: map[string]Quirks{"hmac": {AllowMissingAlgorithm: true}},
+		},
+		{
+			name:      "Key quirks replace instance quirks",
+			header:    noAlgorithm,
+			quirks:    Quirks{AllowMissingAlgorithm: true},
+			keyQuirks: map[string]Quirks{"hmac": {CaseSensitiveHeaders: true}},
+			wantErr:   ErrAlgorithmMismatch,
+		},
+		{
+			name:      "Quirks of other keyId",
+			header:    noAlgorithm,
+			keyQuirks: map[string]Quirks{"rsa": {AllowMissingAlgorithm: true}},
+			wantErr:   ErrAlgorithmMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetQuirks(tt.quirks)
+			for keyID, q := range tt.keyQuirks {
+				hs.SetKeyQuirks(keyID, q)
+			}
+			r := testBenchRequest()
+			r.Header.Set(signatureHeader, tt.header)
+			err := hs.Verify(r)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithQuirks(t *testing.T) {
+	q := Quirks{AllowMissingAlgorithm: true}
+	hs, err := New(WithQuirks(q), WithKeyQuirks("key", Quirks{CaseSensitiveHeaders: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hs.quirksFor("other") != q || !hs.quirksFor("key").CaseSensitiveHeaders {
+		t.Errorf("quirks are not set")
+	}
+}
+
+func TestMissingHeaders(t *testing.T) {
+	secret := Secret{KeyID: "hmac", PrivateKey: "secret", PublicKey: "secret", Algorithm: algHmacSha256}
+	sign := func(s string) string {
+		sig, _ := HmacSha256{}.Create(secret, []byte(s))
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	header := func(s string) string {
+		return `keyId="hmac",algorithm="hmac-sha256",headers="x-missing host",signature="` + sign(s) + `"`
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		quirks  Quirks
+		wantErr error
+	}{
+		{
+			name:    "Error",
+			header:  header("host: " + testHostExample),
+			wantErr: ErrRequiredHeaderNotFound,
+		},
+		{
+			name:   "Empty",
+			header: header("x-missing: \nhost: " + testHostExample),
+			quirks: Quirks{MissingHeaders: MissingHeadersEmpty},
+		},
+		{
+			name:   "Skip",
+			header: header("host: " + testHostExample),
+			quirks: Quirks{MissingHeaders: MissingHeadersSkip},
+		},
+		{
+			name:    "Skip, signed empty",
+			header:  header("x-missing: \nhost: " + testHostExample),
+			quirks:  Quirks{MissingHeaders: MissingHeadersSkip},
+			wantErr: ErrWrongSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"hmac": secret}))
+			hs.SetKeyQuirks("hmac", tt.quirks)
+			r := testBenchRequest()
+			r.Header.Set(signatureHeader, tt.header)
+			err := hs.Verify(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Signer uses quirks set for all keys
+	hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"hmac": secret}))
+	hs.SetDefaultSignatureHeaders([]string{"x-missing", "host"})
+	hs.SetQuirks(Quirks{MissingHeaders: MissingHeadersSkip})
+	r := testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
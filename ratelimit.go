@@ -0,0 +1,72 @@
+package httpsignatures
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitedSecrets secrets storage wrapper which limits calls to the backend (token bucket), so a flood of
+// requests with random keyIds can't exhaust remote API quotas (Vault, AWS etc.). Calls over the limit fail fast with
+// ErrKeyFetchRateLimited, they are not queued.
+type RateLimitedSecrets struct {
+	ss     Secrets
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimitedSecrets wrap storage with rate limiter: rate calls per second with bursts of up to burst calls.
+// Wrap each backend once & share the storage between HTTPSignatures instances of the backend.
+func NewRateLimitedSecrets(ss Secrets, rate float64, burst int) Secrets {
+	s := new(RateLimitedSecrets)
+	s.ss = ss
+	s.rate = rate
+	s.burst = float64(burst)
+	s.tokens = s.burst
+	s.now = time.Now
+	s.last = s.now()
+	return s
+}
+
+// Get get secret from the backend if the limit isn't exceeded
+func (s *RateLimitedSecrets) Get(keyID string) (Secret, error) {
+	if !s.allow() {
+		return Secret{}, &ErrSecret{Message: "key fetch rate limit exceeded", kind: ErrKeyFetchRateLimited}
+	}
+	return s.ss.Get(keyID)
+}
+
+// GetContext get secret from the backend with context (if it's supported) if the limit isn't exceeded
+func (s *RateLimitedSecrets) GetContext(ctx context.Context, keyID string) (Secret, error) {
+	cs, ok := s.ss.(ContextSecrets)
+	if !ok {
+		return s.Get(keyID)
+	}
+	if !s.allow() {
+		return Secret{}, &ErrSecret{Message: "key fetch rate limit exceeded", kind: ErrKeyFetchRateLimited}
+	}
+	return cs.GetContext(ctx, keyID)
+}
+
+// allow take a token from the bucket refilled since the last call
+func (s *RateLimitedSecrets) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if elapsed := now.Sub(s.last); elapsed > 0 {
+		s.tokens += elapsed.Seconds() * s.rate
+		if s.tokens > s.burst {
+			s.tokens = s.burst
+		}
+	}
+	s.last = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
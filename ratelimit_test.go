@@ -0,0 +1,57 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedSecrets(t *testing.T) {
+	now := time.Unix(1591130723, 0)
+	ss := NewRateLimitedSecrets(testBenchSecrets, 2, 3).(*RateLimitedSecrets)
+	ss.now = func() time.Time { return now }
+	ss.last = now
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		keyID      string
+		wantErrMsg string
+	}{
+		{name: "Burst 1", keyID: "hmac"},
+		{name: "Burst 2", keyID: "rsa"},
+		{name: "Burst 3 not found", keyID: "random", wantErrMsg: "ErrSecret: secret not found"},
+		{name: "Limit exceeded", keyID: "hmac", wantErrMsg: "ErrSecret: key fetch rate limit exceeded"},
+		{name: "Refilled", advance: 500 * time.Millisecond, keyID: "hmac"},
+		{name: "Limit exceeded again", keyID: "hmac", wantErrMsg: "ErrSecret: key fetch rate limit exceeded"},
+		{name: "Refilled up to burst", advance: time.Hour, keyID: "ecdsa"},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		got, err := ss.GetContext(context.Background(), tt.keyID)
+		if err == nil && len(tt.wantErrMsg) > 0 {
+			t.Errorf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+		}
+		if err == nil && got.KeyID != tt.keyID {
+			t.Errorf(tt.name+"\nKeyID = %s, want %s", got.KeyID, tt.keyID)
+		}
+		if err != nil && err.Error() != tt.wantErrMsg {
+			t.Errorf(tt.name+"\nerror message = `%s`, wantErrMsg = `%s`", err.Error(), tt.wantErrMsg)
+		}
+	}
+	if ss.tokens != 2 {
+		t.Errorf("tokens = %v, want 2", ss.tokens)
+	}
+}
+
+func TestRateLimitedSecretsVerify(t *testing.T) {
+	hs := NewHTTPSignatures(NewRateLimitedSecrets(testBenchSecrets, 0, 1))
+	r := testBenchRequest()
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); !errors.Is(err, ErrKeyFetchRateLimited) {
+		t.Errorf("Verify() error = %v, want ErrKeyFetchRateLimited", err)
+	}
+}
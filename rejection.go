@@ -0,0 +1,113 @@
+package httpsignatures
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Rejection reason of request rejected by VerifyRequests middleware
+type Rejection struct {
+	// Status response status code
+	Status int
+	// Reason short public reason: "signature required", "malformed signature", "invalid signature",
+	// "unknown tenant" (Tenants) or "unauthorized" (SetUniformErrors)
+	Reason string
+	// Err verification error (don't send it to the client, it may disclose verification details)
+	Err error
+}
+
+// RejectionRenderer write rejection response. WWW-Authenticate challenge is already set for 401 responses.
+type RejectionRenderer func(w http.ResponseWriter, r *http.Request, rej Rejection)
+
+// Rejections middleware rejection responses. Zero status is 401 Unauthorized (default for all rejections).
+type Rejections struct {
+	// Missing status of requests without signature, e.g. 401
+	Missing int
+	// Malformed status of requests with malformed signature or digest header, e.g. 400
+	Malformed int
+	// Invalid status of requests with wrong, expired signature, unknown keyId or policy violation, e.g. 403
+	Invalid int
+	// Render write response, plain text status by default (TextRejection)
+	Render RejectionRenderer
+}
+
+// SetRejections set middleware rejection statuses & response renderer
+func (hs *HTTPSignatures) SetRejections(r Rejections) {
+	hs.rejections = r
+}
+
+// SetUniformErrors reject all requests by VerifyRequests middleware with the same status (Rejections.Invalid,
+// 401 Unauthorized by default) & reason, so clients get no feedback which helps forging signatures. Detailed errors
+// are logged & reported (SetVerificationReporter) only. Use SetBufferRequestBody to verify digest before the handler,
+// otherwise the handler gets digest error reading the body.
+func (hs *HTTPSignatures) SetUniformErrors(v bool) {
+	hs.uniformErrors = v
+}
+
+// reject classify verification error & write rejection response
+func (hs *HTTPSignatures) reject(w http.ResponseWriter, r *http.Request, err error) {
+	rej := classifyRejection(err, hs.rejections, hs.uniformErrors)
+	if rej.Status == http.StatusUnauthorized && hs.policy.Challenge {
+		w.Header().Set(wwwAuthenticateHeader, hs.Challenge())
+	}
+	renderRejection(w, r, rej, hs.rejections)
+}
+
+// classifyRejection rejection status & public reason of verification error
+func classifyRejection(err error, rejections Rejections, uniformErrors bool) Rejection {
+	rej := Rejection{Err: err}
+	var pErr *ErrParser
+	switch {
+	case uniformErrors:
+		rej.Status, rej.Reason = rejections.Invalid, "unauthorized"
+	case errors.Is(err, ErrSignatureHeaderNotFound):
+		rej.Status, rej.Reason = rejections.Missing, "signature required"
+	case errors.As(err, &pErr):
+		rej.Status, rej.Reason = rejections.Malformed, "malformed signature"
+	case errors.Is(err, ErrUnknownTenant):
+		rej.Status, rej.Reason = rejections.Invalid, "unknown tenant"
+	default:
+		rej.Status, rej.Reason = rejections.Invalid, "invalid signature"
+	}
+	if rej.Status == 0 {
+		rej.Status = http.StatusUnauthorized
+	}
+	return rej
+}
+
+// renderRejection write rejection response with the configured renderer
+func renderRejection(w http.ResponseWriter, r *http.Request, rej Rejection, rejections Rejections) {
+	render := rejections.Render
+	if render == nil {
+		render = TextRejection
+	}
+	render(w, r, rej)
+}
+
+// TextRejection write plain text status rejection response (default)
+func TextRejection(w http.ResponseWriter, _ *http.Request, rej Rejection) {
+	http.Error(w, http.StatusText(rej.Status), rej.Status)
+}
+
+// problem application/problem+json body (RFC 7807)
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// ProblemJSONRejection write application/problem+json rejection response (RFC 7807) with public reason
+func ProblemJSONRejection(w http.ResponseWriter, _ *http.Request, rej Rejection) {
+	b, _ := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(rej.Status),
+		Status: rej.Status,
+		Detail: rej.Reason,
+	})
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(rej.Status)
+	_, _ = w.Write(b)
+}
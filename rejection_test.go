@@ -0,0 +1,117 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyRequestsRejections(t *testing.T) {
+	rejections := Rejections{
+		Missing:   http.StatusUnauthorized,
+		Malformed: http.StatusBadRequest,
+		Invalid:   http.StatusForbidden,
+		Render:    ProblemJSONRejection,
+	}
+	tests := []struct {
+		name          string
+		rejections    Rejections
+		signature     string
+		wantStatus    int
+		wantChallenge bool
+		wantBody      string
+	}{
+		{
+			name:          "Missing signature",
+			rejections:    rejections,
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: true,
+			wantBody:      `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"signature required"}`,
+		},
+		{
+			name:       "Malformed signature",
+			rejections: rejections,
+			signature:  `keyId="Test",signature`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"malformed signature"}`,
+		},
+		{
+			name:       "Invalid signature",
+			rejections: rejections,
+			signature:  `keyId="Test",algorithm="hmac-sha256",headers="(request-target)",signature="YWJj"`,
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"type":"about:blank","title":"Forbidden","status":403,"detail":"invalid signature"}`,
+		},
+		{
+			name:          "Default response",
+			signature:     `keyId="Test",algorithm="hmac-sha256",headers="(request-target)",signature="YWJj"`,
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: true,
+			wantBody:      "Unauthorized\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetPolicy(Policy{Challenge: true})
+			hs.SetRejections(tt.rejections)
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, testHostExamplePath, nil)
+			if len(tt.signature) > 0 {
+				r.Header.Set(signatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus || rec.Body.String() != tt.wantBody {
+				t.Errorf(tt.name+"\ngot status = %d, body = %s, want %d, %s", rec.Code, rec.Body.String(),
+					tt.wantStatus, tt.wantBody)
+			}
+			if got := len(rec.Header().Get(wwwAuthenticateHeader)) > 0; got != tt.wantChallenge {
+				t.Errorf(tt.name+"\ngot challenge = %v, want %v", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestsUniformErrors(t *testing.T) {
+	signatures := map[string]string{
+		"Missing signature":   "",
+		"Malformed signature": `keyId="Test",signature`,
+		"Invalid signature":   `keyId="Test",algorithm="hmac-sha256",headers="(request-target)",signature="YWJj"`,
+		"Unknown keyId":       `keyId="Unknown",algorithm="hmac-sha256",headers="(request-target)",signature="YWJj"`,
+	}
+	for name, signature := range signatures {
+		t.Run(name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetRejections(Rejections{
+				Missing:   http.StatusUnauthorized,
+				Malformed: http.StatusBadRequest,
+				Invalid:   http.StatusForbidden,
+				Render:    ProblemJSONRejection,
+			})
+			hs.SetUniformErrors(true)
+			var reported error
+			hs.SetVerificationReporter(func(r *http.Request, res VerificationResult) {
+				reported = res.Err
+			})
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, testHostExamplePath, nil)
+			if len(signature) > 0 {
+				r.Header.Set(signatureHeader, signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			want := `{"type":"about:blank","title":"Forbidden","status":403,"detail":"unauthorized"}`
+			if rec.Code != http.StatusForbidden || rec.Body.String() != want {
+				t.Errorf("got status = %d, body = %s, want 403, %s", rec.Code, rec.Body.String(), want)
+			}
+			if reported == nil {
+				t.Error("detailed error is not reported")
+			}
+		})
+	}
+}
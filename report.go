@@ -0,0 +1,88 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// VerificationResult result of the request verification by VerifyRequests middleware
+type VerificationResult struct {
+	KeyID string
+	// Err verification error, nil if signature is valid. Digest (and signatures sent in trailers) are verified
+	// while the handler reads the body, so Err is updated at the end of the body.
+	Err error
+}
+
+type verificationResultContextKey struct{}
+
+// SetReportOnly enable report-only mode: VerifyRequests middleware verifies signatures, stores the result in the
+// request context (VerificationResultFromContext) & reports it, but never rejects the request. Digest errors are
+// not returned by the body reader. Used to roll out mandatory signing gradually & measure breakage.
+func (hs *HTTPSignatures) SetReportOnly(v bool) {
+	hs.reportOnly = v
+}
+
+// SetVerificationReporter set func called with the result of every middleware verification (e.g. for metrics).
+// It's called again if digest verification fails when the body is read.
+func (hs *HTTPSignatures) SetVerificationReporter(f func(r *http.Request, res VerificationResult)) {
+	hs.reporter = f
+}
+
+// VerificationResultFromContext return verification result stored by VerifyRequests middleware in report-only mode
+func VerificationResultFromContext(ctx context.Context) (*VerificationResult, bool) {
+	res, ok := ctx.Value(verificationResultContextKey{}).(*VerificationResult)
+	return res, ok
+}
+
+// reportResult report verification result & wrap the body to report errors at the end of the body. In report-only
+// mode the result is stored in the context & body errors are not returned.
+func (hs *HTTPSignatures) reportResult(r *http.Request, res *VerificationResult) *http.Request {
+	if hs.reporter != nil {
+		hs.reporter(r, *res)
+	}
+	if hs.reportOnly {
+		r = r.WithContext(context.WithValue(r.Context(), verificationResultContextKey{}, res))
+	}
+	if (hs.reporter != nil || hs.reportOnly) && r.Body != nil && r.Body != http.NoBody {
+		r.Body = &reportReader{ReadCloser: r.Body, hs: hs, r: r, res: res}
+	}
+	return r
+}
+
+// reportReader body reader which reports digest verification errors
+type reportReader struct {
+	io.ReadCloser
+	hs       *HTTPSignatures
+	r        *http.Request
+	res      *VerificationResult
+	reported bool
+}
+
+// Read read body, report verification error at the end of the body
+func (rr *reportReader) Read(p []byte) (int, error) {
+	n, err := rr.ReadCloser.Read(p)
+	if err == nil || err == io.EOF || !isVerificationErr(err) {
+		return n, err
+	}
+	if !rr.reported {
+		rr.reported = true
+		rr.res.Err = err
+		if rr.hs.reporter != nil {
+			rr.hs.reporter(rr.r, *rr.res)
+		}
+	}
+	if rr.hs.reportOnly {
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// isVerificationErr check error is signature or digest verification error (not I/O error)
+func isVerificationErr(err error) bool {
+	var hErr *ErrHS
+	var dErr *ErrDigest
+	var pErr *ErrParser
+	return errors.As(err, &hErr) || errors.As(err, &dErr) || errors.As(err, &pErr)
+}
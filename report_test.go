@@ -0,0 +1,112 @@
+package httpsignatures
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRequestsReportOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		sign        bool
+		body        string
+		wantErr     error
+		wantReports int
+	}{
+		{
+			name:        "Valid signature",
+			sign:        true,
+			body:        testBodyExample,
+			wantReports: 1,
+		},
+		{
+			name:        "No signature",
+			body:        testBodyExample,
+			wantErr:     ErrSignatureHeaderNotFound,
+			wantReports: 1,
+		},
+		{
+			name:        "Wrong digest",
+			sign:        true,
+			body:        `{"hello": "world!"}`,
+			wantErr:     ErrDigestMismatch,
+			wantReports: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+			hs.SetReportOnly(true)
+			var reports []VerificationResult
+			hs.SetVerificationReporter(func(r *http.Request, res VerificationResult) {
+				reports = append(reports, res)
+			})
+			var res *VerificationResult
+			h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil || string(b) != tt.body {
+					t.Errorf("got body = %s, error = %v, want %s", b, err, tt.body)
+				}
+				res, _ = VerificationResultFromContext(r.Context())
+			}))
+
+			r := testGetRequest()
+			if tt.sign {
+				if err := hs.Sign("Test", r); err != nil {
+					t.Fatalf("Sign error = %v", err)
+				}
+			}
+			sr := httptest.NewRequest(r.Method, r.URL.String(), strings.NewReader(tt.body))
+			sr.Header = r.Header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, sr)
+
+			if rec.Code != http.StatusOK || res == nil {
+				t.Fatalf("got status = %d, result = %v", rec.Code, res)
+			}
+			if tt.wantErr == nil && res.Err != nil || tt.wantErr != nil && !errors.Is(res.Err, tt.wantErr) {
+				t.Errorf("got result error = %v, want %v", res.Err, tt.wantErr)
+			}
+			if len(reports) != tt.wantReports {
+				t.Errorf("got %d reports, want %d", len(reports), tt.wantReports)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestsReporter(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "digest"})
+	var reports []VerificationResult
+	hs.SetVerificationReporter(func(r *http.Request, res VerificationResult) {
+		reports = append(reports, res)
+	})
+	var readErr error
+	h := hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = ioutil.ReadAll(r.Body)
+		if _, ok := VerificationResultFromContext(r.Context()); ok {
+			t.Errorf("result is stored in context in enforcing mode")
+		}
+	}))
+
+	r := testGetRequest()
+	if err := hs.Sign("Test", r); err != nil {
+		t.Fatalf("Sign error = %v", err)
+	}
+	sr := httptest.NewRequest(r.Method, r.URL.String(), strings.NewReader(`{"hello": "world!"}`))
+	sr.Header = r.Header
+	h.ServeHTTP(httptest.NewRecorder(), sr)
+
+	if !errors.Is(readErr, ErrDigestMismatch) {
+		t.Errorf("got read error = %v, want %v", readErr, ErrDigestMismatch)
+	}
+	if len(reports) != 2 || reports[0].Err != nil || reports[0].KeyID != "Test" ||
+		!errors.Is(reports[1].Err, ErrDigestMismatch) {
+		t.Errorf("got reports = %v", reports)
+	}
+}
@@ -0,0 +1,87 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestTargetForm form of the request URI in (request-target)
+type RequestTargetForm int
+
+const (
+	// RequestTargetOrigin path & query, e.g. "/foo?param=value" (default)
+	RequestTargetOrigin RequestTargetForm = iota
+	// RequestTargetAbsolute absolute URI, e.g. "http://example.com/foo?param=value"
+	RequestTargetAbsolute
+	// RequestTargetAsReceived absolute URI if the request line of the received request is in absolute-form
+	// (proxied requests), origin-form otherwise. Client requests are always in origin-form.
+	RequestTargetAsReceived
+)
+
+// RequestTargetAuthority source of the authority in the absolute-form request target. Note: net/http server
+// replaces Host of requests received in absolute-form with the URL host.
+type RequestTargetAuthority int
+
+const (
+	// AuthorityFromURL request URL host, Host header if URL has no host (default)
+	AuthorityFromURL RequestTargetAuthority = iota
+	// AuthorityFromHost Host header, request URL host if it's not set
+	AuthorityFromHost
+)
+
+// SetRequestTargetForm set form of the request URI in (request-target) & source of the authority in the
+// absolute-form (both Sign & Verify). CONNECT request target is the authority in absolute-form modes.
+func (hs *HTTPSignatures) SetRequestTargetForm(f RequestTargetForm, a RequestTargetAuthority) {
+	hs.targetForm = f
+	hs.targetAuthority = a
+}
+
+// requestTarget (request-target) of the request
+func (hs *HTTPSignatures) requestTarget(r *http.Request) string {
+	return strings.ToLower(r.Method) + " " + hs.requestURI(r)
+}
+
+// requestURI request URI in the configured form
+func (hs *HTTPSignatures) requestURI(r *http.Request) string {
+	absolute := hs.targetForm == RequestTargetAbsolute ||
+		hs.targetForm == RequestTargetAsReceived && isAbsoluteForm(r.RequestURI)
+	if !absolute {
+		return hs.query.normalize(r.URL.RequestURI())
+	}
+	if r.Method == http.MethodConnect {
+		return hs.authority(r)
+	}
+	return hs.scheme(r) + "://" + hs.authority(r) + hs.query.normalize(r.URL.RequestURI())
+}
+
+// authority request authority by RequestTargetAuthority, X-Forwarded-Host of the request from trusted proxy
+func (hs *HTTPSignatures) authority(r *http.Request) string {
+	if h := hs.forwarded(r, forwardedHostHeader); len(h) > 0 {
+		return h
+	}
+	if hs.targetAuthority == AuthorityFromHost && len(r.Host) > 0 || len(r.URL.Host) == 0 {
+		return r.Host
+	}
+	return r.URL.Host
+}
+
+// scheme request URL scheme, for server requests in origin-form it's derived from TLS (or X-Forwarded-Proto of
+// the request from trusted proxy)
+func (hs *HTTPSignatures) scheme(r *http.Request) string {
+	if p := hs.forwarded(r, forwardedProtoHeader); len(p) > 0 {
+		return strings.ToLower(p)
+	}
+	if len(r.URL.Scheme) > 0 {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// isAbsoluteForm check request line URI is in absolute-form, e.g. "http://example.com/foo"
+func isAbsoluteForm(uri string) bool {
+	i := strings.Index(uri, "://")
+	return i > 0 && !strings.Contains(uri[:i], "/")
+}
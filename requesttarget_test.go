@@ -0,0 +1,134 @@
+package httpsignatures
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestTarget(t *testing.T) {
+	testReadRequest := func(line string) *http.Request {
+		r, err := http.ReadRequest(bufio.NewReader(strings.NewReader(line + "\r\nHost: internal.example.com\r\n\r\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	tests := []struct {
+		name      string
+		form      RequestTargetForm
+		authority RequestTargetAuthority
+		r         *http.Request
+		want      string
+	}{
+		{
+			name: "Origin-form default",
+			r:    testReadRequest("GET http://example.com/foo?a=b HTTP/1.1"),
+			want: "get /foo?a=b",
+		},
+		{
+			name: "Absolute-form received",
+			form: RequestTargetAsReceived,
+			r:    testReadRequest("GET http://example.com/foo?a=b HTTP/1.1"),
+			want: "get http://example.com/foo?a=b",
+		},
+		{
+			name:      "Absolute, Host authority",
+			form:      RequestTargetAbsolute,
+			authority: AuthorityFromHost,
+			r: (func() *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+				r.Host = "internal.example.com"
+				return r
+			})(),
+			want: "get http://internal.example.com/foo",
+		},
+		{
+			name: "Absolute, URL authority",
+			form: RequestTargetAbsolute,
+			r: (func() *http.Request {
+				r, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+				r.Host = "internal.example.com"
+				return r
+			})(),
+			want: "get http://example.com/foo",
+		},
+		{
+			name: "Origin-form received",
+			form: RequestTargetAsReceived,
+			r:    testReadRequest("GET /foo HTTP/1.1"),
+			want: "get /foo",
+		},
+		{
+			name: "Absolute origin-form request",
+			form: RequestTargetAbsolute,
+			r:    testReadRequest("POST /foo HTTP/1.1"),
+			want: "post http://internal.example.com/foo",
+		},
+		{
+			name: "Absolute TLS request",
+			form: RequestTargetAbsolute,
+			r: (func() *http.Request {
+				r := testReadRequest("GET /foo HTTP/1.1")
+				r.TLS = &tls.ConnectionState{}
+				return r
+			})(),
+			want: "get https://internal.example.com/foo",
+		},
+		{
+			name: "Absolute client request",
+			form: RequestTargetAbsolute,
+			r:    testGetRequest(),
+			want: "post https://example.com/foo?param=value&pet=dog",
+		},
+		{
+			name: "Connect",
+			form: RequestTargetAsReceived,
+			r:    testReadRequest("CONNECT example.com:443 HTTP/1.1"),
+			want: "connect /",
+		},
+		{
+			name: "Connect absolute",
+			form: RequestTargetAbsolute,
+			r:    testReadRequest("CONNECT example.com:443 HTTP/1.1"),
+			want: "connect example.com:443",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetRequestTargetForm(tt.form, tt.authority)
+			if got := hs.requestTarget(tt.r); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignVerifyAbsoluteForm(t *testing.T) {
+	// Client signs request sent to proxy in absolute-form
+	client := NewHTTPSignatures(testBenchSecrets)
+	client.SetDefaultSignatureHeaders([]string{"(request-target)", "host"})
+	client.SetRequestTargetForm(RequestTargetAbsolute, AuthorityFromURL)
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com/foo?a=b", nil)
+	if err := client.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+
+	line := "GET http://example.com/foo?a=b HTTP/1.1\r\nHost: example.com\r\nSignature: " +
+		r.Header.Get(signatureHeader) + "\r\n\r\n"
+	received, err := http.ReadRequest(bufio.NewReader(strings.NewReader(line)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := NewHTTPSignatures(testBenchSecrets)
+	if err := proxy.Verify(received); err == nil {
+		t.Errorf("origin-form target verified absolute-form signature")
+	}
+	proxy.SetRequestTargetForm(RequestTargetAsReceived, AuthorityFromURL)
+	if err := proxy.Verify(received); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
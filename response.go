@@ -0,0 +1,163 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ResponseWriter http.ResponseWriter decorator to sign responses.
+// Response body is buffered: status & headers are sent on Close, after Digest, Content-Digest & Signature headers
+// are set, because the digest has to cover the whole body. Without signed digest, Flush sends signed headers &
+// streams the rest of the body.
+type ResponseWriter struct {
+	hs          *HTTPSignatures
+	w           http.ResponseWriter
+	secretKeyID string
+	status      int
+	body        bytes.Buffer
+	closed      bool
+	wroteHeader bool
+}
+
+// NewResponseWriter create response writer which signs the response with secretKeyID
+func (hs *HTTPSignatures) NewResponseWriter(w http.ResponseWriter, secretKeyID string) *ResponseWriter {
+	rw := new(ResponseWriter)
+	rw.hs = hs
+	rw.w = w
+	rw.secretKeyID = secretKeyID
+	rw.status = http.StatusOK
+	return rw
+}
+
+// Header return the header map of the wrapped writer
+func (rw *ResponseWriter) Header() http.Header {
+	return rw.w.Header()
+}
+
+// Write buffer response body, or write it to the wrapped writer after headers are sent by Flush
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if rw.closed {
+		return 0, &ErrHS{Message: "response writer is closed"}
+	}
+	if rw.wroteHeader {
+		return rw.w.Write(b)
+	}
+	return rw.body.Write(b)
+}
+
+// WriteHeader save status code, it's sent on Close (or Flush)
+func (rw *ResponseWriter) WriteHeader(statusCode int) {
+	rw.status = statusCode
+}
+
+// Flush send signed headers & buffered body, then flush the wrapped writer (http.Flusher).
+// If digest or content-digest is in response signature headers, it does nothing: headers can't be signed before
+// the whole body is written, so the response is sent on Close.
+func (rw *ResponseWriter) Flush() {
+	if rw.closed || rw.signsDigest() {
+		return
+	}
+	// If signing fails, the body stays buffered & Close returns the error
+	if !rw.wroteHeader && rw.send() != nil {
+		return
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close create Digest (if "digest" is in response signature headers), Content-Digest (if the body is digested)
+// & Signature headers, then send status, headers & body to the wrapped writer
+func (rw *ResponseWriter) Close() error {
+	if rw.closed {
+		return nil
+	}
+	rw.closed = true
+	if rw.wroteHeader {
+		return nil
+	}
+	return rw.send()
+}
+
+// signsDigest check if response signature covers the whole body
+func (rw *ResponseWriter) signsDigest() bool {
+	h := rw.hs.defaultResponseHeaders
+	return rw.hs.hasDigest(h) || rw.hs.inHeaders(strings.ToLower(contentDigestHeader), h)
+}
+
+// send sign headers, then send status, headers & buffered body to the wrapped writer
+func (rw *ResponseWriter) send() error {
+	body := rw.body.Bytes()
+	var digest string
+	if rw.signsDigest() {
+		var err error
+		if digest, err = rw.hs.d.create(rw.hs.d.defaultAlg, body); err != nil {
+			return err
+		}
+		rw.w.Header().Set(contentDigestHeader, contentDigest(digest))
+	}
+	createDigest := func(h []string) (string, error) {
+		if !rw.hs.hasDigest(h) {
+			return "", nil
+		}
+		return digest, nil
+	}
+	err := rw.hs.sign(context.Background(), rw.secretKeyID, rw.hs.defaultResponseHeaders, rw.w.Header(), "", "",
+		createDigest)
+	if err != nil {
+		return err
+	}
+
+	rw.w.WriteHeader(rw.status)
+	rw.wroteHeader = true
+	_, err = rw.w.Write(body)
+	rw.body.Reset()
+	return err
+}
+
+// contentDigest convert Digest header value (e.g. "SHA-256=X48E...") to Content-Digest (RFC 9530) value
+// (e.g. "sha-256=:X48E...:")
+func contentDigest(digest string) string {
+	i := strings.IndexByte(digest, '=')
+	return strings.ToLower(digest[:i]) + "=:" + digest[i+1:] + ":"
+}
+
+// SignResponses handler wrapper which signs all responses of the next handler with secretKeyID.
+// If response can't be signed, 500 Internal Server Error is sent instead.
+func (hs *HTTPSignatures) SignResponses(secretKeyID string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := hs.NewResponseWriter(w, secretKeyID)
+		next.ServeHTTP(rw, r)
+		if err := rw.Close(); err != nil && !rw.wroteHeader {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
+
+// VerifyResponse verify response signature (e.g. created by SignResponses). Signed Digest or Content-Digest
+// header is verified too, response body is read into memory then.
+func (hs *HTTPSignatures) VerifyResponse(resp *http.Response) error {
+	return hs.VerifyResponseCtx(context.Background(), resp)
+}
+
+// VerifyResponseCtx verify response signature, stop verification when ctx is done
+func (hs *HTTPSignatures) VerifyResponseCtx(ctx context.Context, resp *http.Response) error {
+	if err := hs.checkContext(ctx); err != nil {
+		return err
+	}
+	verifyDigest := func(h []string) error {
+		if !hs.defaultVerifyDigest || !hs.hasDigest(h) && !hs.inHeaders("content-digest", h) {
+			return nil
+		}
+		return hs.d.verifyResponse(resp)
+	}
+	secret, err := hs.verifyHeader(ctx, resp.Header, "", "", verifyDigest)
+	if err != nil {
+		hs.log.Error("response signature verification failed", "status", resp.StatusCode, "err", err)
+		return err
+	}
+	hs.log.Debug("response signature verified", "keyId", secret.KeyID, "status", resp.StatusCode)
+	return nil
+}
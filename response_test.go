@@ -0,0 +1,211 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	tests := []struct {
+		name              string
+		headers           []string
+		wantStatus        int
+		wantDigest        string
+		wantContentDigest string
+		wantErr           bool
+	}{
+		{
+			name:       "Sign response with digest OK",
+			headers:    []string{"(created)", "digest", "content-type"},
+			wantStatus: http.StatusCreated,
+			wantDigest: "SHA-512=WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==",
+			wantContentDigest: "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+" +
+				"AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:",
+		},
+		{
+			name:       "Sign response with content-digest OK",
+			headers:    []string{"(created)", "content-digest"},
+			wantStatus: http.StatusCreated,
+			wantContentDigest: "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+" +
+				"AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:",
+		},
+		{
+			name:       "Sign response without digest OK",
+			headers:    []string{"(created)"},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Request target is not supported",
+			headers:    []string{requestTarget},
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testSecretsStorage)
+			hs.SetDefaultResponseSignatureHeaders(tt.headers)
+			h := hs.SignResponses("Test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(testContentTypeHeader, testContentTypeJSON)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, testBodyExample)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, testGetRequest())
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf(tt.name+"\ngot status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantErr {
+				return
+			}
+			if rec.Body.String() != testBodyExample {
+				t.Errorf(tt.name+"\ngot body = %s, want %s", rec.Body.String(), testBodyExample)
+			}
+			if got := rec.Header().Get(digestHeader); got != tt.wantDigest {
+				t.Errorf(tt.name+"\ngot digest = %s, want %s", got, tt.wantDigest)
+			}
+			if got := rec.Header().Get(contentDigestHeader); got != tt.wantContentDigest {
+				t.Errorf(tt.name+"\ngot content digest = %s, want %s", got, tt.wantContentDigest)
+			}
+
+			sh, pErr := NewParser().ParseSignatureHeader(rec.Header().Get(signatureHeader))
+			if pErr != nil {
+				t.Fatalf(tt.name+"\nparse error = %v", pErr)
+			}
+			sigStr, err := hs.buildSignatureStringHeader(sh, rec.Header(), "")
+			if err != nil {
+				t.Fatalf(tt.name+"\nbuild signature string error = %v", err)
+			}
+			sig, _ := base64.StdEncoding.DecodeString(sh.Signature)
+			secret, _ := testSecretsStorage.Get("Test")
+			if err := (RsaSha256{}).Verify(secret, sigStr, sig); err != nil {
+				t.Errorf(tt.name+"\nverify error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResponseWriterClosed(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	rw := hs.NewResponseWriter(httptest.NewRecorder(), "Test")
+	if err := rw.Close(); err != nil {
+		t.Fatalf("close error = %v", err)
+	}
+	_, err := rw.Write([]byte(testBodyExample))
+	assert(t, err == nil, err, testHSErrType, "Write after Close", false, "response writer is closed")
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     []string
+		wantFlushed bool
+	}{
+		{
+			name:        "Signed headers are sent on Flush",
+			headers:     []string{"(created)", "content-type"},
+			wantFlushed: true,
+		},
+		{
+			name:    "Signed digest, response is buffered until Close",
+			headers: []string{"(created)", "digest", "content-type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetDefaultResponseSignatureHeaders(tt.headers)
+			rec := httptest.NewRecorder()
+			h := hs.SignResponses("hmac", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(testContentTypeHeader, testContentTypeJSON)
+				_, _ = io.WriteString(w, testBodyExample[:10])
+				w.(http.Flusher).Flush()
+				if rec.Flushed != tt.wantFlushed {
+					t.Errorf("got flushed = %v, want %v", rec.Flushed, tt.wantFlushed)
+				}
+				if tt.wantFlushed && (len(rec.Header().Get(signatureHeader)) == 0 || rec.Body.Len() == 0) {
+					t.Error("signed headers & body are not sent on Flush")
+				}
+				_, _ = io.WriteString(w, testBodyExample[10:])
+			}))
+			h.ServeHTTP(rec, testGetRequest())
+
+			resp := rec.Result()
+			if err := hs.VerifyResponse(resp); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if b, _ := ioutil.ReadAll(resp.Body); string(b) != testBodyExample {
+				t.Errorf("got body %s, want %s", b, testBodyExample)
+			}
+		})
+	}
+}
+
+func TestVerifyResponse(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)"})
+	hs.SetDefaultResponseSignatureHeaders([]string{"(created)", "digest", "content-type"})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(testContentTypeHeader, testContentTypeJSON)
+		_, _ = io.WriteString(w, testBodyExample)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/signed", hs.SignResponses("hmac", handler))
+	mux.Handle("/unsigned", handler)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/signed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.VerifyResponse(resp); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != testBodyExample {
+		t.Errorf("got body %s, want %s", b, testBodyExample)
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader("tampered"))
+	if err := hs.VerifyResponse(resp); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("got error %v, want ErrDigestMismatch", err)
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(testBodyExample))
+	resp.Header.Set(testContentTypeHeader, "text/plain")
+	if err := hs.VerifyResponse(resp); !errors.Is(err, ErrWrongSignature) {
+		t.Errorf("got error %v, want ErrWrongSignature", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "Signed", path: "/signed"},
+		{name: "Unsigned", path: "/unsigned", wantErr: ErrSignatureHeaderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := hs.NewTransport("hmac", nil)
+			tr.SetVerifyResponseSignature(true)
+			c := &http.Client{Transport: tr}
+			resp, err := c.Get(srv.URL + tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			_ = resp.Body.Close()
+		})
+	}
+}
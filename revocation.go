@@ -0,0 +1,199 @@
+package httpsignatures
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RevocationPolicy handling of certificates with unknown revocation status (checker error other than revocation)
+type RevocationPolicy int
+
+const (
+	// RevocationSoftFail accept certificate if revocation status can't be checked (default)
+	RevocationSoftFail RevocationPolicy = iota
+	// RevocationHardFail reject certificate if revocation status can't be checked
+	RevocationHardFail
+)
+
+// Max size of downloaded CRL
+const maxCRLSize = 10 << 20
+
+// RevocationChecker check revocation status of the certificate issued by issuer. Return error wrapping
+// ErrCertificateRevoked (errors.Is) if the certificate is revoked, other errors mean unknown status.
+type RevocationChecker interface {
+	CheckRevocation(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) error
+}
+
+// SetRevocationChecker check revocation status of validated certificate chains (SetCertificateRoots) before
+// accepting the key: CRLChecker, OCSPChecker or custom checker. Nil checker turns it off.
+func (hs *HTTPSignatures) SetRevocationChecker(c RevocationChecker, p RevocationPolicy) {
+	hs.revocation = c
+	hs.revocationPolicy = p
+}
+
+// checkRevocation check revocation status of every certificate of the chain (except the root)
+func (hs *HTTPSignatures) checkRevocation(ctx context.Context, chain []*x509.Certificate) error {
+	if hs.revocation == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		err := hs.revocation.CheckRevocation(ctx, chain[i], chain[i+1])
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrCertificateRevoked) || hs.revocationPolicy == RevocationHardFail:
+			return err
+		default:
+			hs.log.Error("certificate revocation status unknown (soft-fail)", "subject", chain[i].Subject.String(),
+				"err", err)
+		}
+	}
+	return nil
+}
+
+// CRLChecker check certificate revocation by CRLs of its distribution points. CRLs are cached until their next
+// update.
+type CRLChecker struct {
+	client *http.Client
+	now    func() time.Time
+	mu     sync.Mutex
+	crls   map[string]*pkix.CertificateList
+}
+
+// NewCRLChecker create CRL checker downloading CRLs with the client, http.DefaultClient if nil
+func NewCRLChecker(client *http.Client) *CRLChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &CRLChecker{client: client, now: time.Now, crls: map[string]*pkix.CertificateList{}}
+}
+
+// CheckRevocation check the certificate is not in CRL of its distribution points (the first available one)
+func (c *CRLChecker) CheckRevocation(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) error {
+	if len(cert.CRLDistributionPoints) == 0 {
+		return &ErrCrypto{Message: "certificate has no CRL distribution point"}
+	}
+	var lastErr error
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := c.crl(ctx, url, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, rc := range crl.TBSCertList.RevokedCertificates {
+			if rc.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return &ErrCrypto{Message: "certificate is revoked", kind: ErrCertificateRevoked}
+			}
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// crl get cached or download CRL & verify its signature
+func (c *CRLChecker) crl(ctx context.Context, url string, issuer *x509.Certificate) (*pkix.CertificateList, error) {
+	c.mu.Lock()
+	crl, ok := c.crls[url]
+	c.mu.Unlock()
+	if ok && !crl.HasExpired(c.now()) {
+		return crl, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &ErrCrypto{Message: "error downloading CRL", Err: err}
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, &ErrCrypto{Message: "error downloading CRL", Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ErrCrypto{Message: fmt.Sprintf("error downloading CRL: status %d", resp.StatusCode)}
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCRLSize+1))
+	if err != nil {
+		return nil, &ErrCrypto{Message: "error downloading CRL", Err: err}
+	}
+	if len(b) > maxCRLSize {
+		return nil, &ErrCrypto{Message: fmt.Sprintf("CRL is larger than %d bytes", maxCRLSize)}
+	}
+	crl, err = x509.ParseCRL(b)
+	if err != nil {
+		return nil, &ErrCrypto{Message: "error parsing CRL", Err: err}
+	}
+	if err := issuer.CheckCRLSignature(crl); err != nil {
+		return nil, &ErrCrypto{Message: "wrong CRL signature", Err: err}
+	}
+	if crl.HasExpired(c.now()) {
+		return nil, &ErrCrypto{Message: "CRL has expired"}
+	}
+	c.mu.Lock()
+	c.crls[url] = crl
+	c.mu.Unlock()
+	return crl, nil
+}
+
+// OCSPStatus certificate status from OCSP response
+type OCSPStatus struct {
+	Revoked    bool
+	NextUpdate time.Time
+}
+
+// OCSPQuery query OCSP responder of the certificate & return verified response status, e.g. with
+// golang.org/x/crypto/ocsp (ocsp.CreateRequest & ocsp.ParseResponseForCert)
+type OCSPQuery func(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) (OCSPStatus, error)
+
+// OCSPChecker check certificate revocation with OCSP. Responses (queried or stapled) are cached until their next
+// update, responses without next update are not cached.
+type OCSPChecker struct {
+	query OCSPQuery
+	now   func() time.Time
+	mu    sync.Mutex
+	cache map[string]OCSPStatus
+}
+
+// NewOCSPChecker create OCSP checker querying responders with query
+func NewOCSPChecker(query OCSPQuery) *OCSPChecker {
+	return &OCSPChecker{query: query, now: time.Now, cache: map[string]OCSPStatus{}}
+}
+
+// Staple cache stapled OCSP response status of the certificate (e.g. sent by the client with its certificate)
+func (c *OCSPChecker) Staple(cert *x509.Certificate, issuer *x509.Certificate, status OCSPStatus) {
+	if status.NextUpdate.IsZero() {
+		return
+	}
+	c.mu.Lock()
+	c.cache[ocspCacheKey(cert, issuer)] = status
+	c.mu.Unlock()
+}
+
+// CheckRevocation check certificate status by cached or queried OCSP response
+func (c *OCSPChecker) CheckRevocation(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) error {
+	key := ocspCacheKey(cert, issuer)
+	c.mu.Lock()
+	status, ok := c.cache[key]
+	c.mu.Unlock()
+	if !ok || !c.now().Before(status.NextUpdate) {
+		var err error
+		if status, err = c.query(ctx, cert, issuer); err != nil {
+			return &ErrCrypto{Message: "OCSP query error", Err: err}
+		}
+		c.Staple(cert, issuer, status)
+	}
+	if status.Revoked {
+		return &ErrCrypto{Message: "certificate is revoked", kind: ErrCertificateRevoked}
+	}
+	return nil
+}
+
+func ocspCacheKey(cert *x509.Certificate, issuer *x509.Certificate) string {
+	return string(issuer.RawSubjectPublicKeyInfo) + cert.SerialNumber.String()
+}
@@ -0,0 +1,137 @@
+package httpsignatures
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevocation(t *testing.T) {
+	crls := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := crls[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	ca, caKey := testCertificate(t, "ca", nil, nil, true, x509.KeyUsageCertSign|x509.KeyUsageCRLSign)
+	inter, interKey := testCertificate(t, "intermediate", ca, caKey, true,
+		x509.KeyUsageCertSign|x509.KeyUsageCRLSign, srv.URL+"/ca.crl")
+	leaf, leafKey := testCertificate(t, "leaf", inter, interKey, false, x509.KeyUsageDigitalSignature,
+		srv.URL+"/intermediate.crl")
+	revoked, revokedKey := testCertificate(t, "revoked", inter, interKey, false, x509.KeyUsageDigitalSignature,
+		srv.URL+"/intermediate.crl")
+	unknown, unknownKey := testCertificate(t, "unknown", inter, interKey, false, x509.KeyUsageDigitalSignature,
+		srv.URL+"/missing.crl")
+	crls["/ca.crl"] = testCRL(t, ca, caKey)
+	crls["/intermediate.crl"] = testCRL(t, inter, interKey, revoked)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	revokedStatus := func(_ context.Context, cert *x509.Certificate, _ *x509.Certificate) (OCSPStatus, error) {
+		if cert.SerialNumber.Cmp(unknown.SerialNumber) == 0 {
+			return OCSPStatus{}, errors.New("responder unavailable")
+		}
+		return OCSPStatus{
+			Revoked:    cert.SerialNumber.Cmp(revoked.SerialNumber) == 0,
+			NextUpdate: time.Now().Add(time.Hour),
+		}, nil
+	}
+
+	tests := []struct {
+		name        string
+		checker     RevocationChecker
+		policy      RevocationPolicy
+		leaf        *x509.Certificate
+		key         *ecdsa.PrivateKey
+		wantErrMsg  string
+		wantRevoked bool
+	}{
+		{name: "CRL valid", checker: NewCRLChecker(nil), policy: RevocationHardFail, leaf: leaf, key: leafKey},
+		{name: "CRL revoked", checker: NewCRLChecker(nil), leaf: revoked, key: revokedKey,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: ErrCrypto: certificate is revoked", wantRevoked: true},
+		{name: "CRL unavailable, soft-fail", checker: NewCRLChecker(nil), leaf: unknown, key: unknownKey},
+		{name: "CRL unavailable, hard-fail", checker: NewCRLChecker(nil), policy: RevocationHardFail, leaf: unknown,
+			key:        unknownKey,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: ErrCrypto: error downloading CRL: status 404"},
+		{name: "OCSP valid", checker: NewOCSPChecker(revokedStatus), policy: RevocationHardFail, leaf: leaf,
+			key: leafKey},
+		{name: "OCSP revoked", checker: NewOCSPChecker(revokedStatus), leaf: revoked, key: revokedKey,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: ErrCrypto: certificate is revoked", wantRevoked: true},
+		{name: "OCSP unavailable, hard-fail", checker: NewOCSPChecker(revokedStatus), policy: RevocationHardFail,
+			leaf: unknown, key: unknownKey,
+			wantErrMsg: "certificate of keyId 'cert' is not valid: ErrCrypto: OCSP query error: responder unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			private, _ := x509.MarshalECPrivateKey(tt.key)
+			hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+				"cert": {
+					KeyID: "cert",
+					PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tt.leaf.Raw})) +
+						string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: inter.Raw})),
+					PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: private})),
+					Algorithm:  algEcdsaSha256,
+				},
+			}))
+			hs.SetCertificateRoots(roots)
+			hs.SetRevocationChecker(tt.checker, tt.policy)
+			r := testBenchRequest()
+			if err := hs.Sign("cert", r); err != nil {
+				t.Fatal(err)
+			}
+			err := hs.Verify(r)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if errors.Is(err, ErrCertificateRevoked) != tt.wantRevoked {
+				t.Errorf("errors.Is(%v, ErrCertificateRevoked) = %t, want %t", err, !tt.wantRevoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestOCSPCheckerCache(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", nil, nil, true, x509.KeyUsageCertSign)
+	leaf, _ := testCertificate(t, "leaf", ca, caKey, false, x509.KeyUsageDigitalSignature)
+	queries := 0
+	c := NewOCSPChecker(func(context.Context, *x509.Certificate, *x509.Certificate) (OCSPStatus, error) {
+		queries++
+		return OCSPStatus{NextUpdate: time.Now().Add(time.Hour)}, nil
+	})
+	c.Staple(leaf, ca, OCSPStatus{Revoked: true, NextUpdate: time.Now().Add(time.Minute)})
+	if err := c.CheckRevocation(context.Background(), leaf, ca); !errors.Is(err, ErrCertificateRevoked) {
+		t.Errorf("CheckRevocation() error = %v, want stapled revoked status", err)
+	}
+	c.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	for i := 0; i < 2; i++ {
+		if err := c.CheckRevocation(context.Background(), leaf, ca); err != nil {
+			t.Errorf("CheckRevocation() error = %v", err)
+		}
+	}
+	if queries != 1 {
+		t.Errorf("queries = %d, want 1", queries)
+	}
+}
+
+func testCRL(t *testing.T, issuer *x509.Certificate, key *ecdsa.PrivateKey, revoked ...*x509.Certificate) []byte {
+	var list []pkix.RevokedCertificate
+	for _, c := range revoked {
+		list = append(list, pkix.RevokedCertificate{SerialNumber: c.SerialNumber, RevocationTime: time.Now()})
+	}
+	b, err := issuer.CreateCRL(rand.Reader, key, list, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -1,9 +1,6 @@
 package httpsignatures
 
-import (
-	"crypto"
-	"crypto/sha256"
-)
+import "crypto"
 
 const algRsaSha256 = "RSA-SHA256"
 
@@ -17,10 +14,10 @@ func (a RsaSha256) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a RsaSha256) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureRsaAlgorithmCreate(algRsaSha256, sha256.New, crypto.SHA256, secret, data)
+	return signatureRsaAlgorithmCreate(algRsaSha256, sha256Pool, crypto.SHA256, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a RsaSha256) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureRsaAlgorithmVerify(algRsaSha256, sha256.New, crypto.SHA256, secret, data, signature)
+	return signatureRsaAlgorithmVerify(algRsaSha256, sha256Pool, crypto.SHA256, secret, data, signature)
 }
@@ -1,9 +1,6 @@
 package httpsignatures
 
-import (
-	"crypto"
-	"crypto/sha512"
-)
+import "crypto"
 
 const algRsaSha512 = "RSA-SHA512"
 
@@ -17,10 +14,10 @@ func (a RsaSha512) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a RsaSha512) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureRsaAlgorithmCreate(algRsaSha512, sha512.New, crypto.SHA512, secret, data)
+	return signatureRsaAlgorithmCreate(algRsaSha512, sha512Pool, crypto.SHA512, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a RsaSha512) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureRsaAlgorithmVerify(algRsaSha512, sha512.New, crypto.SHA512, secret, data, signature)
+	return signatureRsaAlgorithmVerify(algRsaSha512, sha512Pool, crypto.SHA512, secret, data, signature)
 }
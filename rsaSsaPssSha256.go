@@ -1,9 +1,6 @@
 package httpsignatures
 
-import (
-	"crypto"
-	"crypto/sha256"
-)
+import "crypto"
 
 const algRsaSsaPssSha256 = "RSASSA-PSS-SHA256"
 
@@ -17,10 +14,10 @@ func (a RsaSsaPssSha256) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a RsaSsaPssSha256) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureRsaAlgorithmCreate(algRsaSsaPssSha256, sha256.New, crypto.SHA256, secret, data)
+	return signatureRsaAlgorithmCreate(algRsaSsaPssSha256, sha256Pool, crypto.SHA256, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a RsaSsaPssSha256) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureRsaAlgorithmVerify(algRsaSsaPssSha256, sha256.New, crypto.SHA256, secret, data, signature)
+	return signatureRsaAlgorithmVerify(algRsaSsaPssSha256, sha256Pool, crypto.SHA256, secret, data, signature)
 }
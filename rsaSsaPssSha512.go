@@ -1,9 +1,6 @@
 package httpsignatures
 
-import (
-	"crypto"
-	"crypto/sha512"
-)
+import "crypto"
 
 const algRsaSsaPssSha512 = "RSASSA-PSS-SHA512"
 
@@ -17,10 +14,10 @@ func (a RsaSsaPssSha512) Algorithm() string {
 
 // Create Create signature using passed privateKey from secret
 func (a RsaSsaPssSha512) Create(secret Secret, data []byte) ([]byte, error) {
-	return signatureRsaAlgorithmCreate(algRsaSsaPssSha512, sha512.New, crypto.SHA512, secret, data)
+	return signatureRsaAlgorithmCreate(algRsaSsaPssSha512, sha512Pool, crypto.SHA512, secret, data)
 }
 
 // Verify Verify signature using passed publicKey from secret
 func (a RsaSsaPssSha512) Verify(secret Secret, data []byte, signature []byte) error {
-	return signatureRsaAlgorithmVerify(algRsaSsaPssSha512, sha512.New, crypto.SHA512, secret, data, signature)
+	return signatureRsaAlgorithmVerify(algRsaSsaPssSha512, sha512Pool, crypto.SHA512, secret, data, signature)
 }
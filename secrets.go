@@ -1,11 +1,16 @@
 package httpsignatures
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // ErrSecret errors during retrieving secret
 type ErrSecret struct {
 	Message string
 	Err     error
+	kind    error
 }
 
 // ErrHS error message
@@ -19,15 +24,43 @@ func (e *ErrSecret) Error() string {
 	return fmt.Sprintf("ErrSecret: %s", e.Message)
 }
 
+// Unwrap return wrapped error
+func (e *ErrSecret) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel error (e.g. ErrSignatureExpired)
+func (e *ErrSecret) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
 // Secrets interface to retrieve secrets from storage (local, DB, file etc)
 type Secrets interface {
 	Get(keyID string) (Secret, error)
 }
 
+// ContextSecrets optional Secrets interface to retrieve secrets with context (e.g. cancel remote calls when
+// the request is done). Used by SignCtx & VerifyCtx if implemented.
+type ContextSecrets interface {
+	GetContext(ctx context.Context, keyID string) (Secret, error)
+}
+
+// WritableSecrets optional Secrets interface to register keys at runtime (e.g. per-customer keys). Add fails with
+// ErrSecretExists if keyId is registered, Update & Delete fail with ErrUnknownKeyID if it's not.
+type WritableSecrets interface {
+	Secrets
+	Add(s Secret) error
+	Update(s Secret) error
+	Delete(keyID string) error
+}
+
 // Secret struct to return/store secret
 type Secret struct {
 	KeyID      string
 	PublicKey  string
 	PrivateKey string
 	Algorithm  string
+	// NotBefore & NotAfter key validity window, set by storages which know it. Zero time — no limit.
+	NotBefore time.Time
+	NotAfter  time.Time
 }
@@ -7,7 +7,7 @@ import (
 
 func TestSecretsError(t *testing.T) {
 	err := errors.New("test err")
-	e := ErrSecret{"secret err", err}
+	e := ErrSecret{Message: "secret err", Err: err}
 
 	wantErrMsg := "ErrSecret: secret err: test err"
 
@@ -0,0 +1,63 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const authorizationScheme = "Signature"
+
+// BuildSignatureHeader render parsed signature params back into the Signature header value.
+// Only params that are set are rendered: realm & algorithm (if not empty), created & expires (raw CreatedParam &
+// ExpiresParam as signed if set, otherwise if not zero), headers (if any), extensions (sorted by name).
+func BuildSignatureHeader(h Headers) string {
+	header := fmt.Sprintf(`%s="%s",`, paramKeyID, quotedString(h.KeyID))
+	if len(h.Realm) > 0 {
+		header += fmt.Sprintf(`%s="%s",`, paramRealm, quotedString(h.Realm))
+	}
+	if len(h.Algorithm) > 0 {
+		header += fmt.Sprintf(`%s="%s",`, paramAlgorithm, quotedString(h.Algorithm))
+	}
+	if len(h.CreatedParam) > 0 {
+		header += fmt.Sprintf(`%s=%s,`, paramCreated, h.CreatedParam)
+	} else if !h.Created.IsZero() {
+		header += fmt.Sprintf(`%s=%s,`, paramCreated, formatTimestamp(h.Created))
+	}
+	if len(h.ExpiresParam) > 0 {
+		header += fmt.Sprintf(`%s=%s,`, paramExpires, h.ExpiresParam)
+	} else if !h.Expires.IsZero() {
+		header += fmt.Sprintf(`%s=%s,`, paramExpires, formatTimestamp(h.Expires))
+	}
+	if len(h.Headers) > 0 {
+		header += fmt.Sprintf(`%s="%s",`, paramHeaders, quotedString(strings.Join(h.Headers, " ")))
+	}
+	if len(h.Extensions) > 0 {
+		names := make([]string, 0, len(h.Extensions))
+		for name := range h.Extensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			header += fmt.Sprintf(`%s="%s",`, name, quotedString(h.Extensions[name]))
+		}
+	}
+	header += fmt.Sprintf(`%s="%s"`, paramSignature, quotedString(h.Signature))
+
+	return header
+}
+
+// quotedString escape '"' & '\' symbols of the quoted param value
+func quotedString(v string) string {
+	if strings.IndexAny(v, `"\`) < 0 {
+		return v
+	}
+	return quotedStringReplacer.Replace(v)
+}
+
+var quotedStringReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// BuildAuthorizationHeader render parsed signature params into the Authorization header value ("Signature" scheme)
+func BuildAuthorizationHeader(h Headers) string {
+	return authorizationScheme + " " + BuildSignatureHeader(h)
+}
@@ -0,0 +1,117 @@
+package httpsignatures
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSignatureHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  Headers
+		want string
+	}{
+		{
+			name: "All params",
+			arg:  testValidParsedSignatureHeader,
+			want: `keyId="Test",algorithm="rsa-sha256",created=1402170695,expires=1402170699,headers="` +
+				`(request-target) (created) (expires) host date digest content-length",signature="vSdrb+dS3EceC9bcwHSo4` +
+				`MlyKS59iFIrhgYkz8+oVLEEzmYZZvRs8rgOp+63LEM3v+MFHB32NfpB2bEKBIvB1q52LaEUHFv120V01IL+TAD48XaERZFukWgHoB` +
+				`TLMhYS2Gb51gWxpeIq8knRmPnYePbF5MOkR0Zkly4zKH7s1dE="`,
+		},
+		{
+			name: "Required params only",
+			arg: Headers{
+				KeyID:     "key1",
+				Signature: "c2lnbmF0dXJl",
+			},
+			want: `keyId="key1",signature="c2lnbmF0dXJl"`,
+		},
+		{
+			name: "Realm",
+			arg: Headers{
+				KeyID:     "key1",
+				Realm:     "example.com",
+				Algorithm: "hmac-sha256",
+				Signature: "c2lnbmF0dXJl",
+			},
+			want: `keyId="key1",realm="example.com",algorithm="hmac-sha256",signature="c2lnbmF0dXJl"`,
+		},
+		{
+			name: "Subsecond created",
+			arg: Headers{
+				KeyID:     "key1",
+				Created:   time.Unix(1402170695, 100000000),
+				Signature: "c2lnbmF0dXJl",
+			},
+			want: `keyId="key1",created=1402170695.1,signature="c2lnbmF0dXJl"`,
+		},
+		{
+			name: "Extensions",
+			arg: Headers{
+				KeyID:      "key1",
+				Signature:  "c2lnbmF0dXJl",
+				Extensions: map[string]string{"nonce": "n1", "context": "c1"},
+			},
+			want: `keyId="key1",context="c1",nonce="n1",signature="c2lnbmF0dXJl"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildSignatureHeader(tt.arg)
+			if got != tt.want {
+				t.Errorf(tt.name+"\ngot  = %v,\nwant = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAuthorizationHeader(t *testing.T) {
+	got := BuildAuthorizationHeader(Headers{KeyID: "key1", Algorithm: "hmac-sha256", Signature: "c2lnbmF0dXJl"})
+	want := `Signature keyId="key1",algorithm="hmac-sha256",signature="c2lnbmF0dXJl"`
+	if got != want {
+		t.Errorf("got  = %v,\nwant = %v", got, want)
+	}
+}
+
+func TestBuildSignatureHeaderRoundTrip(t *testing.T) {
+	p := NewParser()
+	parsed, err := p.ParseSignatureHeader(testValidSignatureHeader)
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	got := BuildSignatureHeader(parsed)
+	if got != testValidSignatureHeader {
+		t.Errorf("got  = %v,\nwant = %v", got, testValidSignatureHeader)
+	}
+}
+
+func TestBuildSignatureHeaderRoundTripMilliseconds(t *testing.T) {
+	header := `keyId="key1",algorithm="hs2019",created=1402170695123,expires=1402170699000,` +
+		`headers="(created) (expires)",signature="c2lnbmF0dXJl"`
+	p := NewParser()
+	p.SetMillisecondTimestamps(true)
+	parsed, err := p.ParseSignatureHeader(header)
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	if got := BuildSignatureHeader(parsed); got != header {
+		t.Errorf("got  = %v,\nwant = %v", got, header)
+	}
+}
+
+func TestBuildSignatureHeaderQuotedPair(t *testing.T) {
+	h := Headers{KeyID: `domain\"user"`, Signature: "c2ln"}
+	got := BuildSignatureHeader(h)
+	want := `keyId="domain\\\"user\"",signature="c2ln"`
+	if got != want {
+		t.Errorf("got  = %v,\nwant = %v", got, want)
+	}
+	parsed, err := ParseSignatureHeader(got)
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	if parsed.KeyID != h.KeyID {
+		t.Errorf("got  = %v,\nwant = %v", parsed.KeyID, h.KeyID)
+	}
+}
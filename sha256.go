@@ -1,9 +1,5 @@
 package httpsignatures
 
-import (
-	"crypto/sha256"
-)
-
 const algSha256 = "SHA-256"
 
 // Sha256 Sha256 Algorithm
@@ -16,11 +12,15 @@ func (a Sha256) Algorithm() string {
 
 // Create Create hash
 func (a Sha256) Create(data []byte) ([]byte, error) {
-	sha256.New()
-	return digestHashAlgorithmCreate(sha256.New, data)
+	return digestHashAlgorithmCreate(sha256Pool, data)
 }
 
 // Verify Verify hash
 func (a Sha256) Verify(data []byte, digest []byte) error {
-	return digestHashAlgorithmVerify(sha256.New, data, digest)
+	return digestHashAlgorithmVerify(sha256Pool, data, digest)
+}
+
+// hashPool hash instances pool to create digest while the body is read
+func (a Sha256) hashPool() *hashPool {
+	return sha256Pool
 }
@@ -1,9 +1,5 @@
 package httpsignatures
 
-import (
-	"crypto/sha512"
-)
-
 const algSha512 = "SHA-512"
 
 // Sha512 Sha512 Algorithm
@@ -16,10 +12,15 @@ func (a Sha512) Algorithm() string {
 
 // Create Create hash
 func (a Sha512) Create(data []byte) ([]byte, error) {
-	return digestHashAlgorithmCreate(sha512.New, data)
+	return digestHashAlgorithmCreate(sha512Pool, data)
 }
 
 // Verify Verify hash
 func (a Sha512) Verify(data []byte, digest []byte) error {
-	return digestHashAlgorithmVerify(sha512.New, data, digest)
+	return digestHashAlgorithmVerify(sha512Pool, data, digest)
+}
+
+// hashPool hash instances pool to create digest while the body is read
+func (a Sha512) hashPool() *hashPool {
+	return sha512Pool
 }
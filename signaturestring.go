@@ -0,0 +1,54 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SignatureStringParams message parts covered by the signature
+type SignatureStringParams struct {
+	// Method request method, used with URL for (request-target)
+	Method string
+	// URL request URL (request URI is used), nil for responses (no (request-target))
+	URL *url.URL
+	// Host used if there's no Host header (http.Request.Host)
+	Host string
+	// Header message headers
+	Header http.Header
+	// Headers covered headers & pseudo-headers in signature order, e.g. "(request-target) (created) digest"
+	Headers []string
+	// Created & Expires values of (created) & (expires) pseudo-headers
+	Created time.Time
+	Expires time.Time
+}
+
+// BuildSignatureString build signature string (signature base) with default header canonicalization, exactly as
+// signer & verifier do, for CLIs, debuggers & proxies
+func BuildSignatureString(p SignatureStringParams) (string, error) {
+	hs := HTTPSignatures{canonicalization: defaultHeaderCanonicalization}
+	return hs.BuildSignatureString(p)
+}
+
+// BuildSignatureString build signature string (signature base) with configured header canonicalization
+func (hs *HTTPSignatures) BuildSignatureString(p SignatureStringParams) (string, error) {
+	sh := Headers{Headers: p.Headers, Created: p.Created, Expires: p.Expires}
+	// Missing (created) & (expires) values are reported as not found
+	if sh.Created.IsZero() {
+		sh.Created = time.Unix(0, 0)
+	}
+	if sh.Expires.IsZero() {
+		sh.Expires = time.Unix(0, 0)
+	}
+	var target string
+	if p.URL != nil {
+		target = hs.requestTarget(&http.Request{Method: p.Method, URL: p.URL, Host: p.Host})
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := hs.writeSignatureStringQuirks(b, sh, p.Header, target, p.Host, Quirks{}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,90 @@
+package httpsignatures
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSignatureString(t *testing.T) {
+	u, _ := url.Parse(testHostExampleFullPath)
+	header := http.Header{"Date": {testDateExample}, "X-Values": {"a", "b"}}
+	tests := []struct {
+		name       string
+		params     SignatureStringParams
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "Request",
+			params: SignatureStringParams{
+				Method:  http.MethodPost,
+				URL:     u,
+				Host:    "example.com",
+				Header:  header,
+				Headers: []string{requestTarget, "host", created, expires, "date", "x-values"},
+				Created: time.Unix(1402170695, 0),
+				Expires: time.Unix(1402170995, 0),
+			},
+			want: "(request-target): post /foo?param=value&pet=dog\nhost: example.com\n(created): 1402170695\n" +
+				"(expires): 1402170995\ndate: " + testDateExample + "\nx-values: a, b",
+		},
+		{
+			name:       "Response with (request-target)",
+			params:     SignatureStringParams{Header: header, Headers: []string{requestTarget}},
+			wantErrMsg: "param '(request-target)' is not supported for responses",
+		},
+		{
+			name:       "Missing created",
+			params:     SignatureStringParams{Header: header, Headers: []string{created}},
+			wantErrMsg: "param '(created)', required in signature, not found",
+		},
+		{
+			name:       "Missing header",
+			params:     SignatureStringParams{Header: header, Headers: []string{"digest"}},
+			wantErrMsg: "header 'digest', required in signature, not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildSignatureString(tt.params)
+			assert(t, got, err, testHSErrType, tt.name, tt.want, tt.wantErrMsg)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Errorf(tt.name+"\nno error, want %s", tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestBuildSignatureStringSigned(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, "host", created, expires, "digest"})
+	r, _ := http.NewRequest(http.MethodPost, testHostExampleFullPath, strings.NewReader(testBodyExample))
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	sh, pErr := NewParser().ParseSignatureHeader(r.Header.Get(signatureHeader))
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	s, err := hs.BuildSignatureString(SignatureStringParams{
+		Method:  r.Method,
+		URL:     r.URL,
+		Host:    r.Host,
+		Header:  r.Header,
+		Headers: sh.Headers,
+		Created: sh.Created,
+		Expires: sh.Expires,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(sh.Signature)
+	secret, _ := testBenchSecrets.Get("hmac")
+	if err := (HmacSha256{}).Verify(secret, []byte(s), sig); err != nil {
+		t.Errorf("signature of built string is wrong: %v\n%s", err, s)
+	}
+}
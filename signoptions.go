@@ -0,0 +1,68 @@
+package httpsignatures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SignOption per-call signing option, overrides instance defaults for a single SignWith call only
+type SignOption func(o *signOptions)
+
+type signOptions struct {
+	keyID     string
+	algorithm string
+	headers   []string
+}
+
+// SignKeyID keyId to sign the request with
+func SignKeyID(keyID string) SignOption {
+	return func(o *signOptions) {
+		o.keyID = keyID
+	}
+}
+
+// SignAlgorithm sign with the algorithm instead of the key one (or the one selected by the key type)
+func SignAlgorithm(alg string) SignOption {
+	return func(o *signOptions) {
+		o.algorithm = alg
+	}
+}
+
+// SignHeaders sign the headers instead of default signature headers
+func SignHeaders(headers ...string) SignOption {
+	return func(o *signOptions) {
+		o.headers = headers
+	}
+}
+
+// SignWith sign request with per-call options, e.g. when one client talks to several upstreams with different keys
+func (hs *HTTPSignatures) SignWith(r *http.Request, opts ...SignOption) error {
+	return hs.SignWithCtx(context.Background(), r, opts...)
+}
+
+// SignWithCtx sign request with per-call options, stop signing when ctx is done
+func (hs *HTTPSignatures) SignWithCtx(ctx context.Context, r *http.Request, opts ...SignOption) error {
+	var o signOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.keyID) == 0 {
+		return &ErrHS{Message: "keyId is not set", kind: ErrUnknownKeyID}
+	}
+
+	ps := *hs
+	if len(o.algorithm) > 0 {
+		if _, ok := hs.alg[hs.resolveAlgorithm(o.algorithm)]; !ok {
+			return &ErrHS{
+				Message: fmt.Sprintf("algorithm '%s' not supported", o.algorithm),
+				kind:    ErrUnsupportedAlgorithm,
+			}
+		}
+		ps.signAlgorithm = o.algorithm
+	}
+	if len(o.headers) > 0 {
+		ps.defaultHeaders = o.headers
+	}
+	return ps.SignCtx(ctx, o.keyID, r)
+}
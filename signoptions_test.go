@@ -0,0 +1,91 @@
+package httpsignatures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignWith(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []SignOption
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "KeyID",
+			opts: []SignOption{SignKeyID("ed25519")},
+			want: `keyId="ed25519",algorithm="ED25519"`,
+		},
+		{
+			name: "Algorithm",
+			opts: []SignOption{SignKeyID("auto"), SignAlgorithm("RSA-SHA512")},
+			want: `keyId="auto",algorithm="RSA-SHA512"`,
+		},
+		{
+			name: "Headers",
+			opts: []SignOption{SignKeyID("hmac"), SignHeaders("(request-target)", "date")},
+			want: `headers="(request-target) date"`,
+		},
+		{
+			name:       "No keyId",
+			opts:       []SignOption{SignAlgorithm("RSA-SHA512")},
+			wantErrMsg: "keyId is not set",
+		},
+		{
+			name:       "Unsupported algorithm",
+			opts:       []SignOption{SignKeyID("auto"), SignAlgorithm("RSA-MD5")},
+			wantErrMsg: "algorithm 'RSA-MD5' not supported",
+		},
+	}
+	auto, err := GenerateSecret("auto", algRsaSha256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auto.Algorithm = ""
+	secrets := map[string]Secret{"auto": auto}
+	for _, keyID := range []string{"hmac", "ed25519"} {
+		if secrets[keyID], err = testBenchSecrets.Get(keyID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := testBenchHS()
+			hs.ss = NewSimpleSecretsStorage(secrets)
+			r := testBenchRequest()
+			err := hs.SignWith(r, tt.opts...)
+			if err == nil && len(tt.wantErrMsg) > 0 {
+				t.Fatalf(tt.name+"\nno error, wantErrMsg = `%s`", tt.wantErrMsg)
+			}
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if err != nil {
+				return
+			}
+			if got := r.Header.Get("Signature"); !strings.Contains(got, tt.want) {
+				t.Errorf("Signature = %s, want %s", got, tt.want)
+			}
+			if len(hs.signAlgorithm) > 0 || strings.Join(hs.defaultHeaders, " ") != strings.Join(testBenchHeaders, " ") {
+				t.Error("SignWith() changed instance defaults")
+			}
+		})
+	}
+}
+
+func TestSignWithVerify(t *testing.T) {
+	auto, err := GenerateSecret("auto", algRsaSha512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := auto
+	signer.Algorithm = ""
+	hs := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"auto": signer}))
+	r := testBenchRequest()
+	if err := hs.SignWith(r, SignKeyID("auto"), SignAlgorithm(algRsaSha512)); err != nil {
+		t.Fatalf("SignWith() error = %v", err)
+	}
+	verifier := NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{"auto": auto}))
+	if err := verifier.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
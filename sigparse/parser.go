@@ -0,0 +1,883 @@
+// Package sigparse parses Signature (draft-cavage-http-signatures) & Digest (RFC 3230) headers. It doesn't depend
+// on crypto & secrets code, so it could be used by tools which only need parsing, e.g. log analyzers or WAF rules.
+package sigparse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	signatureHeader     = "Signature"
+	authorizationHeader = "Authorization"
+	authorizationScheme = "Signature"
+)
+
+// Sentinel errors to check the reason of failure with errors.Is, e.g. errors.Is(err, ErrDuplicateParam)
+var (
+	ErrSignatureHeaderNotFound = errors.New("signature header not found")
+	ErrDuplicateParam          = errors.New("duplicate param")
+	ErrMissingParam            = errors.New("required param not set")
+)
+
+// ASCII codes
+const (
+	fromA  byte = 'A'
+	toZ    byte = 'Z'
+	froma  byte = 'a'
+	toz    byte = 'z'
+	equal  byte = '='
+	quote  byte = '"'
+	space  byte = ' '
+	div    byte = ','
+	from0  byte = '0'
+	to9    byte = '9'
+	min    byte = '-'
+	dot    byte = '.'
+	bslash byte = '\\'
+)
+
+const (
+	paramKeyID     = "keyId"
+	paramAlgorithm = "algorithm"
+	paramCreated   = "created"
+	paramExpires   = "expires"
+	paramHeaders   = "headers"
+	paramSignature = "signature"
+	paramRealm     = "realm"
+)
+
+// SignatureParams Signature header params
+type SignatureParams struct {
+	KeyID     string    // REQUIRED
+	Algorithm string    // RECOMMENDED
+	Created   time.Time // RECOMMENDED (subsecond precision is allowed using decimal notation)
+	Expires   time.Time // OPTIONAL (subsecond precision is allowed using decimal notation)
+	Headers   []string  // OPTIONAL
+	Signature string    // REQUIRED
+	Realm     string    // OPTIONAL (required by some gateways)
+	// CreatedParam & ExpiresParam raw created & expires values read as milliseconds (SetMillisecondTimestamps),
+	// the signature string is built with them as signed. Empty if values are seconds.
+	CreatedParam string
+	ExpiresParam string
+	// Extensions not recognized params (e.g. nonce), nil if there are no such params
+	Extensions map[string]string
+}
+
+// DigestParams Digest header params
+type DigestParams struct {
+	Alg    string // algorithm in upper case, e.g. "SHA-256"
+	Digest string // base64 encoded digest
+}
+
+// ErrParser errors during parsing
+type ErrParser struct {
+	Message string
+	Err     error
+	Pos     int    // byte position in the header counting from 1 (0 if error is not bound to a position)
+	Param   string // param which value was parsed (empty if error occurred outside of a value)
+	kind    error
+}
+
+// Error error message
+func (e *ErrParser) Error() string {
+	if e == nil {
+		return ""
+	}
+	msg := "ErrParser: " + e.Message
+	if e.Pos > 0 {
+		msg += fmt.Sprintf(" at position %d", e.Pos)
+	}
+	if len(e.Param) > 0 {
+		msg += fmt.Sprintf(" while reading '%s' value", e.Param)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap return wrapped error
+func (e *ErrParser) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel error (e.g. ErrDuplicateParam)
+func (e *ErrParser) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// at bind error to the position in the header & the param which value was parsed
+func (e *ErrParser) at(pos int, param string) *ErrParser {
+	e.Pos = pos
+	e.Param = param
+	return e
+}
+
+// parserStage current stage of the parser state machine
+type parserStage int
+
+const (
+	stageNone parserStage = iota
+	stageParam
+	stageEqual
+	stageQuote
+	stageStringValue
+	stageIntValue
+	stageDiv
+	stageAlgorithm
+	stageStringRawValue
+	stageEscape
+	stageSkip
+	stageSkipQuoted
+)
+
+// Mode how strict the parser treats unknown & malformed params
+type Mode int
+
+const (
+	// ModeDefault keep unknown params as extensions, fail on malformed params
+	ModeDefault Mode = iota
+	// ModeStrict fail on unknown params, trailing commas & malformed tokens
+	ModeStrict
+	// ModeLenient keep unknown params as extensions, skip malformed params & trailing commas
+	ModeLenient
+)
+
+// Known signature params, used to check duplicates without map lookups
+const (
+	seenKeyID uint8 = 1 << iota
+	seenAlgorithm
+	seenCreated
+	seenExpires
+	seenHeaders
+	seenSignature
+	seenRealm
+)
+
+// Timestamps greater than this are milliseconds (if SetMillisecondTimestamps is enabled)
+const maxUnixSeconds = 1e11
+
+// Initial capacity of key & value buffers (long enough for most of keys & signatures)
+const (
+	keyBufferSize   = 16
+	valueBufferSize = 512
+)
+
+// Parser parser internal struct
+type Parser struct {
+	headers      SignatureParams
+	digestHeader DigestParams
+	key          []byte
+	value        []byte
+	stage        parserStage
+	seen         uint8
+	multiple     bool
+	signatures   []SignatureParams
+	mode         Mode
+	fatal        bool
+	ciScheme     bool
+	msTimestamps bool
+	schemePrefix bool
+}
+
+// NewParser create new parser
+func NewParser() *Parser {
+	p := new(Parser)
+	p.key = make([]byte, 0, keyBufferSize)
+	p.value = make([]byte, 0, valueBufferSize)
+	return p
+}
+
+// Max capacity of key & value buffers of pooled parsers, parsers grown bigger (e.g. by huge headers) are dropped
+const maxPooledBufferSize = 64 << 10
+
+// parserPool reuse parsers with their key & value buffers
+var parserPool = sync.Pool{New: func() interface{} { return NewParser() }}
+
+// GetParser get parser with default settings from the pool, return it with PutParser
+func GetParser() *Parser {
+	p := parserPool.Get().(*Parser)
+	p.SetMode(ModeDefault)
+	p.SetCaseInsensitiveScheme(false)
+	p.SetMillisecondTimestamps(false)
+	p.SetAllowSchemePrefix(false)
+	return p
+}
+
+// PutParser return parser to the pool. Parsed params don't share memory with the parser, so they are
+// still valid.
+func PutParser(p *Parser) {
+	if cap(p.key) > maxPooledBufferSize || cap(p.value) > maxPooledBufferSize {
+		return
+	}
+	p.Reset()
+	parserPool.Put(p)
+}
+
+// ParseSignatureHeader parse Signature header with a pooled parser
+func ParseSignatureHeader(header string) (SignatureParams, error) {
+	p := GetParser()
+	defer PutParser(p)
+	h, err := p.ParseSignatureHeader(header)
+	if err != nil {
+		return SignatureParams{}, err
+	}
+	return h, nil
+}
+
+// ParseDigestHeader parse Digest header with a pooled parser
+func ParseDigestHeader(header string) (DigestParams, error) {
+	p := GetParser()
+	defer PutParser(p)
+	h, err := p.ParseDigestHeader(header)
+	if err != nil {
+		return DigestParams{}, err
+	}
+	return h, nil
+}
+
+// ParseSignatureHeaders parse one or many Signature header values, each value could contain many
+// comma-separated signatures
+func ParseSignatureHeaders(values ...string) ([]SignatureParams, error) {
+	p := GetParser()
+	defer PutParser(p)
+	h, err := p.ParseSignatureHeaders(values)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ParseFromRequest parse signatures of the request with a pooled parser
+func ParseFromRequest(r *http.Request) ([]SignatureParams, error) {
+	p := GetParser()
+	defer PutParser(p)
+	h, err := p.ParseFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ParseFromRequest parse signatures of the request. Signature header takes precedence, if it's not set
+// Authorization headers with "Signature" scheme are used. All header instances are parsed.
+func (p *Parser) ParseFromRequest(r *http.Request) ([]SignatureParams, *ErrParser) {
+	if values := r.Header.Values(signatureHeader); len(values) > 0 {
+		return p.ParseSignatureHeaders(values)
+	}
+
+	var values []string
+	for _, v := range r.Header.Values(authorizationHeader) {
+		if params, ok := p.trimAuthorizationScheme(v); ok {
+			values = append(values, params)
+		}
+	}
+	if len(values) == 0 {
+		return nil, &ErrParser{Message: "signature header not found", kind: ErrSignatureHeaderNotFound}
+	}
+	return p.ParseSignatureHeaders(values)
+}
+
+// ParseSignatureHeaders parse one or many Signature header values, each value could contain many
+// comma-separated signatures
+func (p *Parser) ParseSignatureHeaders(values []string) ([]SignatureParams, *ErrParser) {
+	var res []SignatureParams
+	for _, v := range values {
+		h, err := p.ParseMultipleSignatureHeader(v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, h...)
+	}
+	return res, nil
+}
+
+// trimAuthorizationScheme cut "Signature" scheme of the Authorization header value
+func (p *Parser) trimAuthorizationScheme(v string) (string, bool) {
+	n := len(authorizationScheme)
+	if len(v) <= n || v[n] != space {
+		return "", false
+	}
+	if v[:n] != authorizationScheme && !(p.ciScheme && strings.EqualFold(v[:n], authorizationScheme)) {
+		return "", false
+	}
+	return strings.TrimLeft(v[n:], " "), true
+}
+
+// SetMillisecondTimestamps detect created & expires sent in milliseconds by mistake: integer values greater than
+// maxUnixSeconds (year 5138) are treated as milliseconds. Not cleared by Reset.
+func (p *Parser) SetMillisecondTimestamps(v bool) {
+	p.msTimestamps = v
+}
+
+// SetAllowSchemePrefix skip redundant "Signature " keyword at the beginning of the Signature header
+// (e.g. "Signature: Signature keyId=..."). Not cleared by Reset.
+func (p *Parser) SetAllowSchemePrefix(v bool) {
+	p.schemePrefix = v
+}
+
+// schemePrefixLen length of the "Signature " keyword to skip (0 if not allowed or not found)
+func (p *Parser) schemePrefixLen(header string) int {
+	if !p.schemePrefix {
+		return 0
+	}
+	if params, ok := p.trimAuthorizationScheme(header); ok {
+		return len(header) - len(params)
+	}
+	return 0
+}
+
+// SetCaseInsensitiveScheme accept any case of the Authorization "Signature" scheme (e.g. "signature"),
+// by default the scheme is case-sensitive. Not cleared by Reset.
+func (p *Parser) SetCaseInsensitiveScheme(v bool) {
+	p.ciScheme = v
+}
+
+// SetMode set parser mode (ModeDefault by default). Mode is not cleared by Reset.
+func (p *Parser) SetMode(m Mode) {
+	p.mode = m
+}
+
+// Reset clear parser state (parsed values & buffers) to reuse it for the next header.
+// Parser is not safe for concurrent use.
+func (p *Parser) Reset() {
+	p.headers = SignatureParams{}
+	p.digestHeader = DigestParams{}
+	p.key = p.key[:0]
+	p.value = p.value[:0]
+	p.stage = stageNone
+	p.seen = 0
+	p.multiple = false
+	p.signatures = nil
+	p.fatal = false
+}
+
+// ParseSignatureHeader parse Signature header. Parser state is reset before parsing, so one parser could be
+// used for many headers (one by one).
+func (p *Parser) ParseSignatureHeader(header string) (SignatureParams, *ErrParser) {
+	p.Reset()
+	p.stage = stageParam
+	return p.parseSignature(header)
+}
+
+// ParseMultipleSignatureHeader parse Signature header which contains one or many comma-separated signatures.
+// Each keyId param (except the first one) starts a new signature, all signatures must have keyId & signature params
+// if there are many of them.
+func (p *Parser) ParseMultipleSignatureHeader(header string) ([]SignatureParams, *ErrParser) {
+	p.Reset()
+	p.stage = stageParam
+	p.multiple = true
+	h, err := p.parseSignature(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.signatures) > 0 {
+		if err := h.VerifyFields(); err != nil {
+			return nil, err
+		}
+	}
+	return append(p.signatures, h), nil
+}
+
+// ParseDigestHeader parse Digest header. Parser state is reset before parsing.
+func (p *Parser) ParseDigestHeader(header string) (DigestParams, *ErrParser) {
+	p.Reset()
+	p.stage = stageAlgorithm
+	return p.parseDigest(header)
+}
+
+func (p *Parser) parseSignature(header string) (SignatureParams, *ErrParser) {
+	if len(header) == 0 {
+		return SignatureParams{}, &ErrParser{Message: "empty header"}
+	}
+
+	var err *ErrParser
+	for i := p.schemePrefixLen(header); i < len(header); i++ {
+		cur := header[i]
+		prev := p.stage
+		switch p.stage {
+		case stageParam:
+			err = p.parseKey(cur)
+		case stageEqual:
+			err = p.parseEqual(cur)
+		case stageQuote:
+			err = p.parseQuote(cur)
+		case stageStringValue:
+			// Copy everything up to the closing quote (or escape symbol) at once
+			j := strings.IndexAny(header[i:], `"\`)
+			if j < 0 {
+				p.value = append(p.value, header[i:]...)
+				i = len(header)
+				continue
+			}
+			p.value = append(p.value, header[i:i+j]...)
+			i += j
+			if header[i] == bslash {
+				// quoted-pair: take the next symbol as is
+				if i+1 == len(header) {
+					p.stage = stageEscape
+					continue
+				}
+				i++
+				p.value = append(p.value, header[i])
+				continue
+			}
+			err = p.parseStringValue(quote)
+		case stageIntValue:
+			err = p.parseIntValue(cur)
+		case stageDiv:
+			err = p.parseDiv(cur)
+		case stageSkip:
+			p.parseSkip(cur)
+		case stageSkipQuoted:
+			if cur == bslash {
+				i++
+			} else if cur == quote {
+				p.stage = stageSkip
+			}
+		default:
+			err = &ErrParser{Message: "unexpected parser stage"}
+		}
+		if err != nil {
+			if p.mode != ModeLenient || p.fatal {
+				return SignatureParams{}, err.at(i+1, p.valueParam(prev))
+			}
+			p.skipParam(prev, header[i])
+			err = nil
+		}
+	}
+
+	err = p.handleSignatureEOF()
+	if err != nil {
+		return SignatureParams{}, err.at(len(header), p.valueParam(p.stage))
+	}
+
+	return p.headers, nil
+}
+
+func (p *Parser) parseDigest(header string) (DigestParams, *ErrParser) {
+	if len(header) == 0 {
+		return DigestParams{}, &ErrParser{Message: "empty digest header"}
+	}
+
+	var err *ErrParser
+	for i := 0; i < len(header); i++ {
+		cur := header[i]
+		switch p.stage {
+		case stageAlgorithm:
+			err = p.parseAlgorithm(cur)
+		case stageStringRawValue:
+			// The rest of the header is the digest value
+			p.value = append(p.value, header[i:]...)
+			i = len(header)
+		default:
+			err = &ErrParser{Message: "unexpected parser stage"}
+		}
+		if err != nil {
+			return DigestParams{}, err.at(i+1, "")
+		}
+	}
+
+	err = p.handleDigestEOF()
+	if err != nil {
+		return DigestParams{}, err.at(len(header), "")
+	}
+
+	return p.digestHeader, nil
+}
+
+func (p *Parser) handleSignatureEOF() *ErrParser {
+	if p.mode == ModeLenient {
+		return p.handleLenientSignatureEOF()
+	}
+	var err *ErrParser
+	switch p.stage {
+	case stageParam:
+		if len(p.key) == 0 {
+			err = &ErrParser{Message: "unexpected end of header, expected parameter"}
+		} else {
+			err = &ErrParser{Message: "unexpected end of header, expected '=' symbol and field value"}
+		}
+	case stageEqual:
+		err = &ErrParser{Message: "unexpected end of header, expected field value"}
+	case stageQuote:
+		err = &ErrParser{Message: "unexpected end of header, expected '\"' symbol and field value"}
+	case stageStringValue:
+		err = &ErrParser{Message: "unexpected end of header, expected '\"' symbol"}
+	case stageEscape:
+		err = &ErrParser{Message: "unexpected end of header, expected escaped symbol"}
+	case stageIntValue:
+		err = p.setKeyValue()
+	}
+	return err
+}
+
+// handleLenientSignatureEOF incomplete last param is ignored
+func (p *Parser) handleLenientSignatureEOF() *ErrParser {
+	if p.stage != stageIntValue {
+		return nil
+	}
+	if err := p.setKeyValue(); err != nil && p.fatal {
+		return err
+	}
+	return nil
+}
+
+// valueParam param which value is parsed at the stage (empty for other stages)
+func (p *Parser) valueParam(stage parserStage) string {
+	switch stage {
+	case stageQuote, stageStringValue, stageEscape, stageIntValue:
+		return string(p.key)
+	}
+	return ""
+}
+
+// skipParam drop malformed param & skip everything up to the next ',' symbol (lenient mode)
+func (p *Parser) skipParam(prev parserStage, cur byte) {
+	p.key = p.key[:0]
+	p.value = p.value[:0]
+	switch {
+	case cur == div:
+		p.stage = stageParam
+	case cur == quote && prev != stageStringValue:
+		p.stage = stageSkipQuoted
+	default:
+		p.stage = stageSkip
+	}
+}
+
+func (p *Parser) parseSkip(cur byte) {
+	if cur == div {
+		p.stage = stageParam
+	} else if cur == quote {
+		p.stage = stageSkipQuoted
+	}
+}
+
+func (p *Parser) handleDigestEOF() *ErrParser {
+	var err *ErrParser
+	if p.stage == stageAlgorithm {
+		err = &ErrParser{Message: "unexpected end of header, expected digest value"}
+	} else if p.stage == stageStringRawValue {
+		err = p.setDigest()
+	}
+	return err
+}
+
+func (p *Parser) parseKey(cur byte) *ErrParser {
+	if (cur >= fromA && cur <= toZ) || (cur >= froma && cur <= toz) {
+		p.key = append(p.key, cur)
+	} else if cur == equal {
+		p.stage = p.getValueStage()
+	} else if cur == space && len(p.key) > 0 {
+		p.stage = stageEqual
+	} else if cur != space {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol in key", string(cur)),
+		}
+	}
+	return nil
+}
+
+// parseAlgorithm digest algorithm is a token (RFC 7230), '/' is allowed as well (e.g. "unixsum/n")
+func (p *Parser) parseAlgorithm(cur byte) *ErrParser {
+	if isTChar(cur) || cur == '/' {
+		p.key = append(p.key, cur)
+	} else if cur == equal {
+		p.stage = stageStringRawValue
+	} else {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol in algorithm", string(cur)),
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseEqual(cur byte) *ErrParser {
+	if cur == equal {
+		p.stage = p.getValueStage()
+	} else if cur == space {
+		return nil
+	} else {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol, expected '=' or space symbol", string(cur)),
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseQuote(cur byte) *ErrParser {
+	if cur == quote {
+		p.stage = stageStringValue
+	} else if cur == space {
+		return nil
+	} else {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol, expected '\"' or space symbol", string(cur)),
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseStringValue(cur byte) *ErrParser {
+	if cur != quote {
+		p.value = append(p.value, cur)
+	} else {
+		p.stage = stageDiv
+		if err := p.setKeyValue(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseIntValue(cur byte) *ErrParser {
+	if (cur >= from0 && cur <= to9) || cur == dot {
+		p.value = append(p.value, cur)
+	} else if cur == space {
+		if len(p.value) == 0 {
+			return nil
+		}
+		p.stage = stageDiv
+		if err := p.setKeyValue(); err != nil {
+			return err
+		}
+	} else if cur == div {
+		p.stage = stageParam
+		if err := p.setKeyValue(); err != nil {
+			return err
+		}
+	} else if p.mode != ModeDefault {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol in integer value", string(cur)),
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseDiv(cur byte) *ErrParser {
+	if cur == div {
+		p.stage = stageParam
+	} else if cur == space {
+		return nil
+	} else {
+		return &ErrParser{
+			Message: fmt.Sprintf("found '%s' — unsupported symbol, expected ',' or space symbol", string(cur)),
+		}
+	}
+	return nil
+}
+
+// getValueStage created & expires are integers, all other values are quoted strings
+func (p *Parser) getValueStage() parserStage {
+	switch string(p.key) {
+	case paramCreated, paramExpires:
+		return stageIntValue
+	}
+	return stageQuote
+}
+
+// checkDuplicate mark param as seen & check if it was already set
+func (p *Parser) checkDuplicate() bool {
+	var bit uint8
+	switch string(p.key) {
+	case paramKeyID:
+		bit = seenKeyID
+	case paramAlgorithm:
+		bit = seenAlgorithm
+	case paramCreated:
+		bit = seenCreated
+	case paramExpires:
+		bit = seenExpires
+	case paramHeaders:
+		bit = seenHeaders
+	case paramSignature:
+		bit = seenSignature
+	case paramRealm:
+		bit = seenRealm
+	default:
+		_, ok := p.headers.Extensions[string(p.key)]
+		return ok
+	}
+	if p.seen&bit != 0 {
+		return true
+	}
+	p.seen |= bit
+	return false
+}
+
+func (p *Parser) setKeyValue() *ErrParser {
+	if len(p.value) == 0 {
+		return &ErrParser{
+			Message: fmt.Sprintf("empty value for key '%s'", string(p.key)),
+		}
+	}
+
+	// Second keyId starts the next signature, params are never moved between signatures by their order
+	if p.multiple && string(p.key) == paramKeyID && p.seen&seenKeyID != 0 {
+		if err := p.headers.VerifyFields(); err != nil {
+			p.fatal = true
+			return err
+		}
+		p.nextSignature()
+	}
+
+	if p.checkDuplicate() {
+		// 2.2 If any of the parameters listed above are erroneously duplicated in the associated header field,
+		// then the the signature MUST NOT be processed.
+		p.fatal = true
+		return &ErrParser{
+			Message: fmt.Sprintf("duplicate param '%s'", string(p.key)),
+			kind:    ErrDuplicateParam,
+		}
+	}
+
+	switch string(p.key) {
+	case paramKeyID:
+		p.headers.KeyID = string(p.value)
+	case paramAlgorithm:
+		p.headers.Algorithm = string(p.value)
+	case paramHeaders:
+		p.headers.Headers = strings.Fields(string(p.value))
+	case paramSignature:
+		p.headers.Signature = string(p.value)
+	case paramRealm:
+		p.headers.Realm = string(p.value)
+	case paramCreated:
+		var err error
+		if p.headers.Created, err = p.intToTime(p.value); err != nil {
+			return &ErrParser{Message: "wrong 'created' param value", Err: err}
+		}
+		if p.isMilliseconds(p.value) {
+			p.headers.CreatedParam = string(p.value)
+		}
+	case paramExpires:
+		var err error
+		if p.headers.Expires, err = p.intToTime(p.value); err != nil {
+			return &ErrParser{Message: "wrong 'expires' param value", Err: err}
+		}
+		if p.isMilliseconds(p.value) {
+			p.headers.ExpiresParam = string(p.value)
+		}
+	default:
+		if p.mode == ModeStrict {
+			return &ErrParser{
+				Message: fmt.Sprintf("unknown param '%s'", string(p.key)),
+			}
+		}
+		// 2.2 Any parameter that is not recognized as a parameter, or is not well-formed, MUST be ignored.
+		// Not recognized params are kept as extensions, it's up to the caller to use them.
+		if p.headers.Extensions == nil {
+			p.headers.Extensions = make(map[string]string)
+		}
+		p.headers.Extensions[string(p.key)] = string(p.value)
+	}
+
+	p.key = p.key[:0]
+	p.value = p.value[:0]
+
+	return nil
+}
+
+// isTChar tchar (RFC 7230)
+func isTChar(c byte) bool {
+	if (c >= fromA && c <= toZ) || (c >= froma && c <= toz) || (c >= from0 && c <= to9) {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// nextSignature save parsed signature & start a new one (multiple signatures in one header)
+func (p *Parser) nextSignature() {
+	p.signatures = append(p.signatures, p.headers)
+	p.headers = SignatureParams{}
+	p.seen = 0
+}
+
+func (p *Parser) intToTime(v []byte) (time.Time, error) {
+	var err error
+	var sec, nsec int64
+
+	// Subsecond precision is allowed using decimal notation
+	s, frac := string(v), ""
+	i := strings.IndexByte(s, dot)
+	if i >= 0 {
+		s, frac = s[:i], s[i+1:]
+	}
+	if sec, err = strconv.ParseInt(s, 10, 64); err != nil {
+		return time.Unix(0, 0), err
+	}
+	if i >= 0 {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Unix(0, 0), err
+		}
+		for j := len(frac); j < 9; j++ {
+			nsec *= 10
+		}
+	} else if p.msTimestamps && isMilliseconds(sec) {
+		return time.Unix(sec/1000, sec%1000*int64(time.Millisecond)), nil
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// isMilliseconds check valid created or expires value is read as milliseconds
+func (p *Parser) isMilliseconds(v []byte) bool {
+	if !p.msTimestamps {
+		return false
+	}
+	sec, err := strconv.ParseInt(string(v), 10, 64)
+	return err == nil && isMilliseconds(sec)
+}
+
+func isMilliseconds(v int64) bool {
+	return v > maxUnixSeconds || v < -maxUnixSeconds
+}
+
+func (p *Parser) setDigest() *ErrParser {
+	if len(p.value) == 0 {
+		return &ErrParser{
+			Message: "empty digest value",
+		}
+	}
+
+	p.digestHeader.Alg = strings.ToUpper(string(p.key))
+	p.digestHeader.Digest = string(p.value)
+
+	p.key = p.key[:0]
+	p.value = p.value[:0]
+
+	return nil
+}
+
+// VerifySignatureFields verify required fields of the last parsed signature
+func (p *Parser) VerifySignatureFields() *ErrParser {
+	return p.headers.VerifyFields()
+}
+
+// VerifyFields check required params (keyId & signature) are set
+func (h SignatureParams) VerifyFields() *ErrParser {
+	if h.KeyID == "" {
+		return &ErrParser{
+			Message: "keyId is not set in header",
+			kind:    ErrMissingParam,
+		}
+	}
+
+	if h.Signature == "" {
+		return &ErrParser{
+			Message: "signature is not set in header",
+			kind:    ErrMissingParam,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,1545 @@
+package sigparse
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testErrParserType = "*sigparse.ErrParser"
+const testValidSignatureHeader = `keyId="Test",algorithm="rsa-sha256",created=1402170695,expires=1402170699,headers="` +
+	`(request-target) (created) (expires) host date digest content-length",signature="vSdrb+dS3EceC9bcwHSo4MlyKS5` +
+	`9iFIrhgYkz8+oVLEEzmYZZvRs8rgOp+63LEM3v+MFHB32NfpB2bEKBIvB1q52LaEUHFv120V01IL+TAD48XaERZFukWgHoBTLMhYS2Gb51gW` +
+	`xpeIq8knRmPnYePbF5MOkR0Zkly4zKH7s1dE="`
+
+var testValidParsedSignatureHeader = SignatureParams{
+	KeyID:     "Test",
+	Algorithm: "rsa-sha256",
+	Created:   time.Unix(1402170695, 0),
+	Expires:   time.Unix(1402170699, 0),
+	Headers:   []string{"(request-target)", "(created)", "(expires)", "host", "date", "digest", "content-length"},
+	Signature: "vSdrb+dS3EceC9bcwHSo4MlyKS59iFIrhgYkz8+oVLEEzmYZZvRs8rgOp+63LEM3v+MFHB32NfpB2bEKBIvB1q52LaEUHFv120V01" +
+		"IL+TAD48XaERZFukWgHoBTLMhYS2Gb51gWxpeIq8knRmPnYePbF5MOkR0Zkly4zKH7s1dE=",
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		want *Parser
+	}{
+		{
+			name: "Successful",
+			want: &Parser{
+				key:   make([]byte, 0, keyBufferSize),
+				value: make([]byte, 0, valueBufferSize),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewParser(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("create() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserParseSingleFields(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Empty header",
+			args: args{
+				header: ``,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty header",
+		},
+		{
+			name: "Only spaces",
+			args: args{
+				header: `  `,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected parameter at position 2",
+		},
+		{
+			name: "Only keyId",
+			args: args{
+				header: `keyId="v1"`,
+			},
+			want: SignatureParams{
+				KeyID: "v1",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Only algorithm",
+			args: args{
+				header: `algorithm="v2"`,
+			},
+			want: SignatureParams{
+				Algorithm: "v2",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Only headers",
+			args: args{
+				header: `headers="(request-target) (created)" `,
+			},
+			want: SignatureParams{
+				Headers: []string{"(request-target)", "(created)"},
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Only realm",
+			args: args{
+				header: `realm="example.com"`,
+			},
+			want: SignatureParams{
+				Realm: "example.com",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Only signature param",
+			args: args{
+				header: `signature="test" `,
+			},
+			want: SignatureParams{
+				Signature: "test",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "All params without spaces",
+			args: args{
+				header: `keyId="v1",algorithm="v2",created=1402170695,expires=1402170699,headers="v-3 v-4",signature=` +
+					`"v5"`,
+			},
+			want: SignatureParams{
+				KeyID:     "v1",
+				Algorithm: "v2",
+				Created:   time.Unix(1402170695, 0),
+				Expires:   time.Unix(1402170699, 0),
+				Headers:   []string{"v-3", "v-4"},
+				Signature: "v5",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "All params and extra spaces",
+			args: args{
+				header: `  keyId  ="v1", algorithm  ="v2",created = 1402170695, expires = 1402170699 , headers  =  " ` +
+					`v-3 v-4  ", signature="v5"   `,
+			},
+			want: SignatureParams{
+				KeyID:     "v1",
+				Algorithm: "v2",
+				Created:   time.Unix(1402170695, 0),
+				Expires:   time.Unix(1402170699, 0),
+				Headers:   []string{"v-3", "v-4"},
+				Signature: "v5",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Signature: all params",
+			args: args{
+				header: `keyId="k1",algorithm="a1",created=1592157709,expires=1592157709,headers="h1 h2",signature=` +
+					`"s1"`,
+			},
+			want: SignatureParams{
+				KeyID:     "k1",
+				Algorithm: "a1",
+				Created:   time.Unix(1592157709, 0),
+				Expires:   time.Unix(1592157709, 0),
+				Headers:   []string{"h1", "h2"},
+				Signature: "s1",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Unsupported symbol in key",
+			args: args{
+				header: `keyId-="v1"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found '-' — unsupported symbol in key at position 6",
+		},
+		{
+			name: "Unsupported symbol, expected = symbol",
+			args: args{
+				header: `keyId :"v1"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found ':' — unsupported symbol, expected '=' or space symbol at position 7",
+		},
+		{
+			name: "Unsupported symbol, expected quote symbol",
+			args: args{
+				header: `keyId= 'v1'`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: found ''' — unsupported symbol, expected '\"' or space symbol at position 8" +
+				" while reading 'keyId' value",
+		},
+		{
+			name: "Unknown parameter",
+			args: args{
+				header: `key="v1"`,
+			},
+			want:        SignatureParams{Extensions: map[string]string{"key": "v1"}},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "unexpected end of header, expected equal symbol",
+			args: args{
+				header: `keyId`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected '=' symbol and field value at position 5",
+		},
+		{
+			name: "Expected field value",
+			args: args{
+				header: `keyId `,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected field value at position 6",
+		},
+		{
+			name: "Expected quote",
+			args: args{
+				header: `keyId= `,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: unexpected end of header, expected '\"' symbol and field value at position 7" +
+				" while reading 'keyId' value",
+		},
+		{
+			name: "Expected quote at the end",
+			args: args{
+				header: `keyId="`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: unexpected end of header, expected '\"' symbol at position 7" +
+				" while reading 'keyId' value",
+		},
+		{
+			name: "Empty value",
+			args: args{
+				header: `keyId=""`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty value for key 'keyId' at position 8 while reading 'keyId' value",
+		},
+		{
+			name: "Div symbol expected",
+			args: args{
+				header: `keyId="v1" algorithm="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found 'a' — unsupported symbol, expected ',' or space symbol at position 12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.stage = stageParam
+			var got, err = p.parseSignature(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseCreatedExpires(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Created only",
+			args: args{
+				header: `created=1402170695`,
+			},
+			want: SignatureParams{
+				Created: time.Unix(1402170695, 0),
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Expires only",
+			args: args{
+				header: `expires=1402170699`,
+			},
+			want: SignatureParams{
+				Expires: time.Unix(1402170699, 0),
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Wrong created INT value",
+			args: args{
+				header: `created=18446744073709551615`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'created' param value at position 28" +
+				" while reading 'created' value: strconv.ParseInt: parsing \"18446744073709551615\": value out of range",
+		},
+		{
+			name: "Wrong created INT value with space at the end",
+			args: args{
+				header: `created=9223372036854775808 `,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'created' param value at position 28" +
+				" while reading 'created' value: strconv.ParseInt: parsing \"9223372036854775808\": value out of range",
+		},
+		{
+			name: "Wrong created INT value with divider",
+			args: args{
+				header: `created=9223372036854775809,`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'created' param value at position 28" +
+				" while reading 'created' value: strconv.ParseInt: parsing \"9223372036854775809\": value out of range",
+		},
+		{
+			name: "Created with subsecond precision",
+			args: args{
+				header: `created=1402170695.25,expires=1402170699.000000001`,
+			},
+			want: SignatureParams{
+				Created: time.Unix(1402170695, 250000000),
+				Expires: time.Unix(1402170699, 1),
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Created with too long fraction",
+			args: args{
+				header: `created=1402170695.1234567891`,
+			},
+			want: SignatureParams{
+				Created: time.Unix(1402170695, 123456789),
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Wrong created decimal value",
+			args: args{
+				header: `created=1402170695.1.2`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'created' param value at position 22" +
+				" while reading 'created' value: strconv.ParseInt: parsing \"1.2\": invalid syntax",
+		},
+		{
+			name: "Wrong created empty fraction",
+			args: args{
+				header: `created=1402170695.`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'created' param value at position 19" +
+				" while reading 'created' value: strconv.ParseInt: parsing \"\": invalid syntax",
+		},
+		{
+			name: "Wrong expires INT value",
+			args: args{
+				header: `expires=18446744073709551615`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'expires' param value at position 28" +
+				" while reading 'expires' value: strconv.ParseInt: parsing \"18446744073709551615\": value out of range",
+		},
+		{
+			name: "Wrong expires with space at the end",
+			args: args{
+				header: `expires=9223372036854775808 `,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'expires' param value at position 28" +
+				" while reading 'expires' value: strconv.ParseInt: parsing \"9223372036854775808\": value out of range",
+		},
+		{
+			name: "Wrong expires with divider",
+			args: args{
+				header: `expires=9223372036854775809,`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: wrong 'expires' param value at position 28" +
+				" while reading 'expires' value: strconv.ParseInt: parsing \"9223372036854775809\": value out of range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.stage = stageParam
+			var got, err = p.parseSignature(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseSignature(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Signature",
+			args: args{
+				header: testValidSignatureHeader,
+			},
+			want:        testValidParsedSignatureHeader,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.ParseSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseSignatureFailed(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Current parser stage not setKeyValue",
+			args: args{
+				header: `keyId="Test"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected parser stage at position 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.parseSignature(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseDigestFailed(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        DigestParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Current parser stage not set",
+			args: args{
+				header: `MD5=test`,
+			},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected parser stage at position 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.parseDigest(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseAmbiguousParams(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Ambiguous Parameters",
+			args: args{
+				header: `keyId="v1",ambiguous="v2",digest="v3"`,
+			},
+			want: SignatureParams{
+				KeyID:      "v1",
+				Extensions: map[string]string{"ambiguous": "v2", "digest": "v3"},
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.ParseSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseDuplicateParams(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Duplicate keyId",
+			args: args{
+				header: `keyId="v1",keyId="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'keyId' at position 21 while reading 'keyId' value",
+		},
+		{
+			name: "Duplicate algorithm",
+			args: args{
+				header: `algorithm="v1",algorithm="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'algorithm' at position 29 while reading 'algorithm' value",
+		},
+		{
+			name: "Duplicate created",
+			args: args{
+				header: `created=1402170695,created=1402170695`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'created' at position 37 while reading 'created' value",
+		},
+		{
+			name: "Duplicate expires",
+			args: args{
+				header: `expires=1402170699,expires=1402170699`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'expires' at position 37 while reading 'expires' value",
+		},
+		{
+			name: "Duplicate headers",
+			args: args{
+				header: `headers="v1",headers="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'headers' at position 25 while reading 'headers' value",
+		},
+		{
+			name: "Duplicate extension",
+			args: args{
+				header: `nonce="v1",nonce="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'nonce' at position 21 while reading 'nonce' value",
+		},
+		{
+			name: "Duplicate signature",
+			args: args{
+				header: `signature="v1",signature="v2"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'signature' at position 29 while reading 'signature' value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.stage = stageParam
+			got, err := p.parseSignature(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestNotSpecifiedHeadersParams(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "No headers",
+			args: args{
+				header: `keyId="v1"`,
+			},
+			want: SignatureParams{
+				KeyID: "v1",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Empty headers",
+			args: args{
+				header: `keyId="v1",headers=""`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty value for key 'headers' at position 21 while reading 'headers' value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.ParseSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseDigestHeader(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        DigestParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "MD5 Digest",
+			args: args{
+				header: `MD5=ZDk5NTk4ODgxNjM3MDc5MDQ2MTgzNDQwMzExMThiZWI=`,
+			},
+			want: DigestParams{
+				Alg:    "MD5",
+				Digest: "ZDk5NTk4ODgxNjM3MDc5MDQ2MTgzNDQwMzExMThiZWI=",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "SHA-1 Digest",
+			args: args{
+				header: `SHA-1=ZDNiMDlhYmUzMGNmZTJlZGZmNGVlOWUwYTE0MWM5M2JmNWIzYWY4Nw==`,
+			},
+			want: DigestParams{
+				Alg:    "SHA-1",
+				Digest: "ZDNiMDlhYmUzMGNmZTJlZGZmNGVlOWUwYTE0MWM5M2JmNWIzYWY4Nw==",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "SHA-256 Digest",
+			args: args{
+				header: `SHA-256=NWY4ZjA0ZjZhM2E4OTJhYWFiYmRkYjZjZjI3Mzg5NDQ5Mzc3Mzk2MGQ0YTMyNWIxMDVmZWU0NmVlZjQzMDRm` +
+					`MQ==`,
+			},
+			want: DigestParams{
+				Alg:    "SHA-256",
+				Digest: "NWY4ZjA0ZjZhM2E4OTJhYWFiYmRkYjZjZjI3Mzg5NDQ5Mzc3Mzk2MGQ0YTMyNWIxMDVmZWU0NmVlZjQzMDRmMQ==",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "id-sha-256 Digest",
+			args: args{
+				header: `id-sha-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=`,
+			},
+			want: DigestParams{
+				Alg:    "ID-SHA-256",
+				Digest: "X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name: "Token symbols in algorithm",
+			args: args{
+				header: `UNIXsum/n+1.x_y=30637`,
+			},
+			want: DigestParams{
+				Alg:    "UNIXSUM/N+1.X_Y",
+				Digest: "30637",
+			},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+		{
+			name:        "Empty Digest header",
+			args:        args{},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty digest header",
+		},
+		{
+			name: "Empty Digest value",
+			args: args{
+				header: `md5`,
+			},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected digest value at position 3",
+		},
+		{
+			name: "Unsupported digest algorithm symbol",
+			args: args{
+				header: `md 5=`,
+			},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found ' ' — unsupported symbol in algorithm at position 3",
+		},
+		{
+			name: "Separator in digest algorithm",
+			args: args{
+				header: `md5;v=`,
+			},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: found ';' — unsupported symbol in algorithm at position 4",
+		},
+		{
+			name: "Empty digest value",
+			args: args{
+				header: `MD5=`,
+			},
+			want:        DigestParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty digest value at position 4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got, err = p.ParseDigestHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestVerifySignatureFields(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "No keyId",
+			args: args{
+				header: `algorithm="v1",headers="v-2",signature="v3"`,
+			},
+			want:        false,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: keyId is not set in header",
+		},
+		{
+			name: "No signature",
+			args: args{
+				header: `keyId="v1",headers="v-2"`,
+			},
+			want:        false,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: signature is not set in header",
+		},
+		{
+			name: "OK",
+			args: args{
+				header: testValidSignatureHeader,
+			},
+			want:        true,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			_, _ = p.ParseSignatureHeader(tt.args.header)
+			err := p.VerifySignatureFields()
+			got := err == nil
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func BenchmarkParseSignatureHeader(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewParser()
+		if _, err := p.ParseSignatureHeader(testValidSignatureHeader); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDigestHeader(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewParser()
+		if _, err := p.ParseDigestHeader("SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	p := NewParser()
+	headers := []string{testValidSignatureHeader, testValidSignatureHeader, `keyId="v1",signature="v2"`}
+	wants := []SignatureParams{
+		testValidParsedSignatureHeader,
+		testValidParsedSignatureHeader,
+		{KeyID: "v1", Signature: "v2"},
+	}
+	for i, h := range headers {
+		got, err := p.ParseSignatureHeader(h)
+		assert(t, got, err, testErrParserType, "Reuse parser", wants[i], "")
+	}
+
+	// Failed parsing doesn't affect next header
+	_, err := p.ParseSignatureHeader(`keyId=v1`)
+	if err == nil {
+		t.Error("expected parser error")
+	}
+	got, err := p.ParseSignatureHeader(`keyId="v1",signature="v2"`)
+	assert(t, got, err, testErrParserType, "Reuse parser after error", wants[2], "")
+
+	d, dErr := p.ParseDigestHeader("MD5=Sd/dVLAcvNLSq16eXua5uQ==")
+	assert(t, d, dErr, testErrParserType, "Reuse parser for digest", DigestParams{Alg: "MD5",
+		Digest: "Sd/dVLAcvNLSq16eXua5uQ=="}, "")
+}
+
+func TestParseSignatureHeaderFunc(t *testing.T) {
+	got, err := ParseSignatureHeader(testValidSignatureHeader)
+	assert(t, got, err, testErrParserType, "Valid header", testValidParsedSignatureHeader, "")
+
+	got, err = ParseSignatureHeader("")
+	assert(t, got, err, testErrParserType, "Empty header", SignatureParams{}, "ErrParser: empty header")
+}
+
+func TestParseDigestHeaderFunc(t *testing.T) {
+	want := DigestParams{Alg: "MD5", Digest: "Sd/dVLAcvNLSq16eXua5uQ=="}
+	got, err := ParseDigestHeader("MD5=Sd/dVLAcvNLSq16eXua5uQ==")
+	assert(t, got, err, testErrParserType, "Valid header", want, "")
+
+	got, err = ParseDigestHeader("")
+	assert(t, got, err, testErrParserType, "Empty header", DigestParams{}, "ErrParser: empty digest header")
+}
+
+func TestParserParseMultipleSignatureHeader(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Single signature",
+			args: args{
+				header: testValidSignatureHeader,
+			},
+			want:        []SignatureParams{testValidParsedSignatureHeader},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Two signatures",
+			args: args{
+				header: `keyId="k1",algorithm="rsa-sha256",created=1402170695,headers="(created)",signature="s1", ` +
+					`keyId="k2",algorithm="ed25519",headers="host",signature="s2"`,
+			},
+			want: []SignatureParams{
+				{
+					KeyID:     "k1",
+					Algorithm: "rsa-sha256",
+					Created:   time.Unix(1402170695, 0),
+					Headers:   []string{"(created)"},
+					Signature: "s1",
+				},
+				{
+					KeyID:     "k2",
+					Algorithm: "ed25519",
+					Headers:   []string{"host"},
+					Signature: "s2",
+				},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Extensions of each signature",
+			args: args{
+				header: `keyId="k1",nonce="n1",signature="s1",keyId="k2",nonce="n2",signature="s2"`,
+			},
+			want: []SignatureParams{
+				{KeyID: "k1", Signature: "s1", Extensions: map[string]string{"nonce": "n1"}},
+				{KeyID: "k2", Signature: "s2", Extensions: map[string]string{"nonce": "n2"}},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Params after signature",
+			args: args{
+				header: `keyId="k1",signature="s1",headers="(created)",keyId="k2",signature="s2",algorithm="hs2019"`,
+			},
+			want: []SignatureParams{
+				{KeyID: "k1", Signature: "s1", Headers: []string{"(created)"}},
+				{KeyID: "k2", Signature: "s2", Algorithm: "hs2019"},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Single signature with params after signature",
+			args: args{
+				header: `keyId="a",signature="b",headers="x"`,
+			},
+			want:        []SignatureParams{{KeyID: "a", Signature: "b", Headers: []string{"x"}}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Signature param before keyId",
+			args: args{
+				header: `signature="s1",keyId="k1",signature="s2",keyId="k2"`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'signature' at position 40 while reading 'signature' value",
+		},
+		{
+			name: "Incomplete signature",
+			args: args{
+				header: `keyId="k1",keyId="k2",signature="s2"`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: signature is not set in header at position 21 while reading 'keyId' value",
+		},
+		{
+			name: "Incomplete last signature",
+			args: args{
+				header: `keyId="k1",signature="s1",keyId="k2",headers="x"`,
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: signature is not set in header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			got, err := p.ParseMultipleSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParseSignatureHeaders(t *testing.T) {
+	got, err := ParseSignatureHeaders(`keyId="k1",signature="s1",keyId="k2",signature="s2"`, `keyId="k3",signature="s3"`)
+	want := []SignatureParams{
+		{KeyID: "k1", Signature: "s1"},
+		{KeyID: "k2", Signature: "s2"},
+		{KeyID: "k3", Signature: "s3"},
+	}
+	assert(t, got, err, testErrParserType, "Repeated headers", want, "")
+
+	got, err = ParseSignatureHeaders(`keyId="k1",signature="s1"`, ``)
+	assert(t, got, err, testErrParserType, "Empty header", []SignatureParams(nil), "ErrParser: empty header")
+}
+
+func TestParserParseQuotedPair(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Escaped quote",
+			args: args{
+				header: `keyId="my \"key\"",signature="c2ln"`,
+			},
+			want:        SignatureParams{KeyID: `my "key"`, Signature: "c2ln"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Escaped backslash",
+			args: args{
+				header: `keyId="domain\\user",signature="c2ln"`,
+			},
+			want:        SignatureParams{KeyID: `domain\user`, Signature: "c2ln"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Escaped regular symbol",
+			args: args{
+				header: `keyId="\k\e\y",signature="c2ln"`,
+			},
+			want:        SignatureParams{KeyID: "key", Signature: "c2ln"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Escape symbol at the end of header",
+			args: args{
+				header: `keyId="key\`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: unexpected end of header, expected escaped symbol at position 11" +
+				" while reading 'keyId' value",
+		},
+		{
+			name: "Escaped closing quote",
+			args: args{
+				header: `keyId="key\"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: unexpected end of header, expected '\"' symbol at position 12" +
+				" while reading 'keyId' value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			got, err := p.ParseSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserParseModes(t *testing.T) {
+	type args struct {
+		mode   Mode
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Default: unknown param kept as extension",
+			args: args{
+				mode:   ModeDefault,
+				header: `keyId="k1",foo="bar",signature="s1"`,
+			},
+			want:        SignatureParams{KeyID: "k1", Signature: "s1", Extensions: map[string]string{"foo": "bar"}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Default: trailing comma",
+			args: args{
+				mode:   ModeDefault,
+				header: `keyId="k1",signature="s1",`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected parameter at position 26",
+		},
+		{
+			name: "Strict: unknown param",
+			args: args{
+				mode:   ModeStrict,
+				header: `keyId="k1",foo="bar",signature="s1"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unknown param 'foo' at position 20 while reading 'foo' value",
+		},
+		{
+			name: "Strict: trailing comma",
+			args: args{
+				mode:   ModeStrict,
+				header: `keyId="k1",signature="s1",`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: unexpected end of header, expected parameter at position 26",
+		},
+		{
+			name: "Strict: malformed integer",
+			args: args{
+				mode:   ModeStrict,
+				header: `keyId="k1",created=14021x70695,signature="s1"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: found 'x' — unsupported symbol in integer value at position 25" +
+				" while reading 'created' value",
+		},
+		{
+			name: "Strict: valid header",
+			args: args{
+				mode:   ModeStrict,
+				header: testValidSignatureHeader,
+			},
+			want:        testValidParsedSignatureHeader,
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Lenient: malformed params skipped",
+			args: args{
+				mode: ModeLenient,
+				header: `keyId="k1",b@d="x,y",created=14021x70695,algorithm "rsa",expires=,` +
+					`signature="s1",realm = "r1" x`,
+			},
+			want:        SignatureParams{KeyID: "k1", Signature: "s1", Realm: "r1"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Lenient: trailing & empty elements",
+			args: args{
+				mode:   ModeLenient,
+				header: `,keyId="k1",,signature="s1",`,
+			},
+			want:        SignatureParams{KeyID: "k1", Signature: "s1"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Lenient: incomplete last param",
+			args: args{
+				mode:   ModeLenient,
+				header: `keyId="k1",signature="s1",headers="host`,
+			},
+			want:        SignatureParams{KeyID: "k1", Signature: "s1"},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Lenient: duplicate param",
+			args: args{
+				mode:   ModeLenient,
+				header: `keyId="k1",keyId="k2",signature="s1"`,
+			},
+			want:        SignatureParams{},
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: duplicate param 'keyId' at position 21 while reading 'keyId' value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.SetMode(tt.args.mode)
+			got, err := p.ParseSignatureHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParseFromRequest(t *testing.T) {
+	type args struct {
+		signature     []string
+		authorization []string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []SignatureParams
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Signature header",
+			args: args{
+				signature: []string{`keyId="k1",signature="s1"`},
+			},
+			want:        []SignatureParams{{KeyID: "k1", Signature: "s1"}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Signature header takes precedence",
+			args: args{
+				signature:     []string{`keyId="k1",signature="s1"`, `keyId="k2",signature="s2"`},
+				authorization: []string{`Signature keyId="k3",signature="s3"`},
+			},
+			want:        []SignatureParams{{KeyID: "k1", Signature: "s1"}, {KeyID: "k2", Signature: "s2"}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Authorization headers",
+			args: args{
+				authorization: []string{
+					`Basic dXNlcjpwYXNz`,
+					`Signature  keyId="k1",signature="s1"`,
+					`Signature keyId="k2",signature="s2",keyId="k3",signature="s3"`,
+				},
+			},
+			want: []SignatureParams{
+				{KeyID: "k1", Signature: "s1"},
+				{KeyID: "k2", Signature: "s2"},
+				{KeyID: "k3", Signature: "s3"},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Not found",
+			args: args{
+				authorization: []string{`Basic dXNlcjpwYXNz`},
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: signature header not found",
+		},
+		{
+			name: "Parser error",
+			args: args{
+				authorization: []string{`Signature keyId=`},
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg: "ErrParser: unexpected end of header, expected '\"' symbol and field value at position 6" +
+				" while reading 'keyId' value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testGetRequest()
+			for _, v := range tt.args.signature {
+				r.Header.Add("Signature", v)
+			}
+			for _, v := range tt.args.authorization {
+				r.Header.Add("Authorization", v)
+			}
+			got, err := ParseFromRequest(r)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestErrParserPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		wantPos   int
+		wantParam string
+	}{
+		{
+			name:      "Error in value",
+			header:    `keyId="Test",headers="host",created=1x`,
+			wantPos:   38,
+			wantParam: "created",
+		},
+		{
+			name:      "Error in key",
+			header:    `keyId="Test",head-ers="host"`,
+			wantPos:   18,
+			wantParam: "",
+		},
+		{
+			name:      "Error at the end of value",
+			header:    `keyId="Test",headers="host`,
+			wantPos:   26,
+			wantParam: "headers",
+		},
+		{
+			name:      "Not bound to a position",
+			header:    ``,
+			wantPos:   0,
+			wantParam: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.SetMode(ModeStrict)
+			_, err := p.ParseSignatureHeader(tt.header)
+			if err == nil {
+				t.Fatalf("error expected")
+			}
+			if err.Pos != tt.wantPos || err.Param != tt.wantParam {
+				t.Errorf("got pos = %d, param = '%s', want pos = %d, param = '%s'", err.Pos, err.Param, tt.wantPos,
+					tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestParserSetCaseInsensitiveScheme(t *testing.T) {
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		authorization   string
+		want            []SignatureParams
+		wantErrMsg      string
+	}{
+		{
+			name:            "Case-sensitive",
+			caseInsensitive: false,
+			authorization:   `Signature keyId="k1",signature="s1"`,
+			want:            []SignatureParams{{KeyID: "k1", Signature: "s1"}},
+		},
+		{
+			name:            "Case-sensitive lowercase scheme",
+			caseInsensitive: false,
+			authorization:   `signature keyId="k1",signature="s1"`,
+			want:            nil,
+			wantErrMsg:      "ErrParser: signature header not found",
+		},
+		{
+			name:            "Case-insensitive lowercase scheme",
+			caseInsensitive: true,
+			authorization:   `signature keyId="k1",signature="s1"`,
+			want:            []SignatureParams{{KeyID: "k1", Signature: "s1"}},
+		},
+		{
+			name:            "Case-insensitive uppercase scheme",
+			caseInsensitive: true,
+			authorization:   `SIGNATURE keyId="k1",signature="s1"`,
+			want:            []SignatureParams{{KeyID: "k1", Signature: "s1"}},
+		},
+		{
+			name:            "Case-insensitive other scheme",
+			caseInsensitive: true,
+			authorization:   `SignatureX keyId="k1",signature="s1"`,
+			want:            nil,
+			wantErrMsg:      "ErrParser: signature header not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testGetRequest()
+			r.Header.Set("Authorization", tt.authorization)
+			p := NewParser()
+			p.SetCaseInsensitiveScheme(tt.caseInsensitive)
+			got, err := p.ParseFromRequest(r)
+			assert(t, got, err, testErrParserType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserSetMillisecondTimestamps(t *testing.T) {
+	tests := []struct {
+		name         string
+		msTimestamps bool
+		header       string
+		want         SignatureParams
+	}{
+		{
+			name:         "After 2038",
+			msTimestamps: false,
+			header:       `created=4102444800,expires=253402300799`,
+			want:         SignatureParams{Created: time.Unix(4102444800, 0), Expires: time.Unix(253402300799, 0)},
+		},
+		{
+			name:         "Milliseconds disabled",
+			msTimestamps: false,
+			header:       `created=1402170695123`,
+			want:         SignatureParams{Created: time.Unix(1402170695123, 0)},
+		},
+		{
+			name:         "Milliseconds",
+			msTimestamps: true,
+			header:       `created=1402170695123,expires=1402170699000`,
+			want: SignatureParams{
+				Created:      time.Unix(1402170695, 123000000),
+				Expires:      time.Unix(1402170699, 0),
+				CreatedParam: "1402170695123",
+				ExpiresParam: "1402170699000",
+			},
+		},
+		{
+			name:         "Seconds with milliseconds enabled",
+			msTimestamps: true,
+			header:       `created=4102444800,expires=1402170699.5`,
+			want:         SignatureParams{Created: time.Unix(4102444800, 0), Expires: time.Unix(1402170699, 500000000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.SetMillisecondTimestamps(tt.msTimestamps)
+			got, err := p.ParseSignatureHeader(tt.header)
+			assert(t, got, err, testErrParserType, tt.name, tt.want, "")
+		})
+	}
+}
+
+func TestParserSetAllowSchemePrefix(t *testing.T) {
+	tests := []struct {
+		name         string
+		schemePrefix bool
+		header       string
+		want         SignatureParams
+		wantErrMsg   string
+	}{
+		{
+			name:         "Prefix not allowed",
+			schemePrefix: false,
+			header:       `Signature keyId="k1",signature="s1"`,
+			want:         SignatureParams{},
+			wantErrMsg:   "ErrParser: found 'k' — unsupported symbol, expected '=' or space symbol at position 11",
+		},
+		{
+			name:         "Prefix allowed",
+			schemePrefix: true,
+			header:       `Signature  keyId="k1",signature="s1"`,
+			want:         SignatureParams{KeyID: "k1", Signature: "s1"},
+		},
+		{
+			name:         "No prefix",
+			schemePrefix: true,
+			header:       `keyId="k1",signature="s1"`,
+			want:         SignatureParams{KeyID: "k1", Signature: "s1"},
+		},
+		{
+			name:         "Error position counts prefix",
+			schemePrefix: true,
+			header:       `Signature keyId="k1",sign@ture="s1"`,
+			want:         SignatureParams{},
+			wantErrMsg:   "ErrParser: found '@' — unsupported symbol in key at position 26",
+		},
+		{
+			name:         "Prefix only",
+			schemePrefix: true,
+			header:       `Signature `,
+			want:         SignatureParams{},
+			wantErrMsg:   "ErrParser: unexpected end of header, expected parameter at position 10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			p.SetAllowSchemePrefix(tt.schemePrefix)
+			got, err := p.ParseSignatureHeader(tt.header)
+			assert(t, got, err, testErrParserType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
+
+func TestParserPool(t *testing.T) {
+	p := GetParser()
+	p.SetMode(ModeStrict)
+	p.SetCaseInsensitiveScheme(true)
+	p.SetMillisecondTimestamps(true)
+	p.SetAllowSchemePrefix(true)
+	got, err := p.ParseSignatureHeader(testValidSignatureHeader)
+	if err != nil {
+		t.Fatalf("ParseSignatureHeader error = %v", err)
+	}
+	PutParser(p)
+
+	// Parsed headers are still valid after the parser is reused
+	p = GetParser()
+	if p.mode != ModeDefault || p.ciScheme || p.msTimestamps || p.schemePrefix {
+		t.Errorf("pooled parser settings are not reset: %+v", p)
+	}
+	if _, err := p.ParseSignatureHeader(`keyId="v1",signature="v2"`); err != nil {
+		t.Fatalf("ParseSignatureHeader error = %v", err)
+	}
+	PutParser(p)
+	assert(t, got, nil, testErrParserType, "Parsed headers", testValidParsedSignatureHeader, "")
+}
+
+func testGetRequest() *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "https://example.com/foo?param=value&pet=dog", nil)
+	return r
+}
+
+func assert(t *testing.T, got interface{}, err error, eType string, name string, want interface{}, wantErrMsg string) {
+	if err != nil && reflect.TypeOf(err).String() != eType {
+		t.Errorf(name+"\ngot error type %s, expected %s", reflect.TypeOf(err).String(), eType)
+	}
+	if err != nil && err.Error() != wantErrMsg {
+		t.Errorf(name+"\nerror message = `%s`, wantErrMsg = `%s`", err.Error(), wantErrMsg)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(name+"\ngot  = %v,\nwant = %v", got, want)
+	}
+}
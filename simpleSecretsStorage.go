@@ -1,11 +1,18 @@
 package httpsignatures
 
-// SimpleSecretsStorage local static secrets storage
+import (
+	"fmt"
+	"sync"
+)
+
+// SimpleSecretsStorage local static secrets storage, secrets could be added, updated & deleted at runtime
 type SimpleSecretsStorage struct {
+	mu      sync.RWMutex
 	storage map[string]Secret
 }
 
-// NewSimpleSecretsStorage create new storage
+// NewSimpleSecretsStorage create new storage. Storage map is used as is (not copied), use Add, Update & Delete
+// to change it after the storage is created.
 func NewSimpleSecretsStorage(storage map[string]Secret) Secrets {
 	s := new(SimpleSecretsStorage)
 	s.storage = storage
@@ -13,9 +20,51 @@ func NewSimpleSecretsStorage(storage map[string]Secret) Secrets {
 }
 
 // Get get secret from local storage by KeyID
-func (s SimpleSecretsStorage) Get(keyID string) (Secret, error) {
-	if secret, ok := s.storage[keyID]; ok {
+func (s *SimpleSecretsStorage) Get(keyID string) (Secret, error) {
+	s.mu.RLock()
+	secret, ok := s.storage[keyID]
+	s.mu.RUnlock()
+	if ok {
 		return secret, nil
 	}
-	return Secret{}, &ErrSecret{"secret not found", nil}
+	return Secret{}, &ErrSecret{Message: "secret not found", kind: ErrUnknownKeyID}
+}
+
+// Add add new secret, fails if secret with the same KeyID exists
+func (s *SimpleSecretsStorage) Add(secret Secret) error {
+	if len(secret.KeyID) == 0 {
+		return &ErrSecret{Message: "empty keyId"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.storage[secret.KeyID]; ok {
+		return &ErrSecret{Message: fmt.Sprintf("secret '%s' already exists", secret.KeyID), kind: ErrSecretExists}
+	}
+	if s.storage == nil {
+		s.storage = make(map[string]Secret)
+	}
+	s.storage[secret.KeyID] = secret
+	return nil
+}
+
+// Update replace existing secret
+func (s *SimpleSecretsStorage) Update(secret Secret) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.storage[secret.KeyID]; !ok {
+		return &ErrSecret{Message: "secret not found", kind: ErrUnknownKeyID}
+	}
+	s.storage[secret.KeyID] = secret
+	return nil
+}
+
+// Delete delete secret by KeyID
+func (s *SimpleSecretsStorage) Delete(keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.storage[keyID]; !ok {
+		return &ErrSecret{Message: "secret not found", kind: ErrUnknownKeyID}
+	}
+	delete(s.storage, keyID)
+	return nil
 }
@@ -1,6 +1,7 @@
 package httpsignatures
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -98,3 +99,76 @@ func TestSimpleSecretsStorageGet(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleSecretsStorageWrite(t *testing.T) {
+	s := NewSimpleSecretsStorage(map[string]Secret{"k1": {KeyID: "k1", PrivateKey: "PrivateKey1"}}).(WritableSecrets)
+	tests := []struct {
+		name       string
+		write      func() error
+		keyID      string
+		want       Secret
+		wantErrMsg string
+		wantErr    error
+	}{
+		{
+			name:  "Add",
+			write: func() error { return s.Add(Secret{KeyID: "k2", PrivateKey: "PrivateKey2"}) },
+			keyID: "k2",
+			want:  Secret{KeyID: "k2", PrivateKey: "PrivateKey2"},
+		},
+		{
+			name:       "Add existing",
+			write:      func() error { return s.Add(Secret{KeyID: "k1", PrivateKey: "Other"}) },
+			keyID:      "k1",
+			want:       Secret{KeyID: "k1", PrivateKey: "PrivateKey1"},
+			wantErrMsg: "ErrSecret: secret 'k1' already exists",
+			wantErr:    ErrSecretExists,
+		},
+		{
+			name:       "Add empty keyId",
+			write:      func() error { return s.Add(Secret{PrivateKey: "Other"}) },
+			wantErrMsg: "ErrSecret: empty keyId",
+		},
+		{
+			name:  "Update",
+			write: func() error { return s.Update(Secret{KeyID: "k1", PrivateKey: "Rotated"}) },
+			keyID: "k1",
+			want:  Secret{KeyID: "k1", PrivateKey: "Rotated"},
+		},
+		{
+			name:       "Update unknown",
+			write:      func() error { return s.Update(Secret{KeyID: "k3"}) },
+			wantErrMsg: "ErrSecret: secret not found",
+			wantErr:    ErrUnknownKeyID,
+		},
+		{
+			name:  "Delete",
+			write: func() error { return s.Delete("k2") },
+		},
+		{
+			name:       "Delete unknown",
+			write:      func() error { return s.Delete("k2") },
+			wantErrMsg: "ErrSecret: secret not found",
+			wantErr:    ErrUnknownKeyID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.write()
+			assert(t, nil, err, testSecretErrType, tt.name, nil, tt.wantErrMsg)
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Errorf("no error, want %s", tt.wantErrMsg)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.keyID) > 0 {
+				got, err := s.Get(tt.keyID)
+				assert(t, got, err, testSecretErrType, tt.name, tt.want, "")
+			}
+		})
+	}
+	if _, err := s.Get("k2"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("deleted secret found")
+	}
+}
@@ -0,0 +1,60 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// secretFetches collapse concurrent secrets storage lookups of the same keyId into one call
+type secretFetches struct {
+	mu    sync.Mutex
+	calls map[string]*secretFetch
+}
+
+type secretFetch struct {
+	done   chan struct{}
+	secret Secret
+	err    error
+}
+
+func newSecretFetches() *secretFetches {
+	return &secretFetches{calls: make(map[string]*secretFetch)}
+}
+
+// do call fetch once for concurrent callers with the same keyID. Callers wait for the result until their ctx is
+// done. If the fetch was interrupted by the first caller's context, others fetch the secret again. If the fetch
+// panics, the panic is passed to the first caller & waiters get an error.
+func (f *secretFetches) do(ctx context.Context, keyID string,
+	fetch func(ctx context.Context) (Secret, error)) (Secret, error) {
+	f.mu.Lock()
+	if c, ok := f.calls[keyID]; ok {
+		f.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return Secret{}, ctx.Err()
+		}
+		if isContextErr(c.err) && ctx.Err() == nil {
+			return f.do(ctx, keyID, fetch)
+		}
+		return c.secret, c.err
+	}
+	// The error is kept for waiters if fetch panics
+	c := &secretFetch{done: make(chan struct{}), err: &ErrSecret{Message: "secrets storage lookup panicked"}}
+	f.calls[keyID] = c
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, keyID)
+		f.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.secret, c.err = fetch(ctx)
+	return c.secret, c.err
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,154 @@
+package httpsignatures
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testSlowSecrets struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (s *testSlowSecrets) GetContext(ctx context.Context, keyID string) (Secret, error) {
+	atomic.AddInt32(&s.calls, 1)
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return Secret{}, ctx.Err()
+	}
+	return Secret{KeyID: keyID, Algorithm: algHmacSha256, PublicKey: "secret"}, nil
+}
+
+func (s *testSlowSecrets) Get(keyID string) (Secret, error) {
+	return s.GetContext(context.Background(), keyID)
+}
+
+func TestGetSecretSingleflight(t *testing.T) {
+	ss := &testSlowSecrets{release: make(chan struct{})}
+	hs := NewHTTPSignatures(ss)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := hs.getSecret(context.Background(), "Test")
+			if err == nil && s.KeyID != "Test" {
+				t.Errorf("got keyId '%s', want 'Test'", s.KeyID)
+			}
+			errs <- err
+		}()
+	}
+	for atomic.LoadInt32(&ss.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(ss.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+	if c := atomic.LoadInt32(&ss.calls); c != 1 {
+		t.Errorf("secrets storage called %d times, want 1", c)
+	}
+}
+
+func TestGetSecretSingleflightCanceled(t *testing.T) {
+	ss := &testSlowSecrets{release: make(chan struct{})}
+	hs := NewHTTPSignatures(ss)
+
+	// The first caller is canceled, the waiting caller fetches the secret again
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := hs.getSecret(ctx, "Test")
+		first <- err
+	}()
+	for atomic.LoadInt32(&ss.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error, 1)
+	go func() {
+		_, err := hs.getSecret(context.Background(), "Test")
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-first; !isContextErr(err) {
+		t.Errorf("got error %v, want context canceled", err)
+	}
+	for atomic.LoadInt32(&ss.calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(ss.release)
+	if err := <-second; err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// Waiting caller with done context doesn't wait for the fetch
+	ss = &testSlowSecrets{release: make(chan struct{})}
+	hs = NewHTTPSignatures(ss)
+	go func() { _, _ = hs.getSecret(context.Background(), "Test") }()
+	for atomic.LoadInt32(&ss.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := hs.getSecret(ctx, "Test"); !isContextErr(err) {
+		t.Errorf("got error %v, want deadline exceeded", err)
+	}
+	close(ss.release)
+}
+
+func TestSecretFetchesPanic(t *testing.T) {
+	f := newSecretFetches()
+	started, release := make(chan struct{}), make(chan struct{})
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() { panicked <- recover() }()
+		_, _ = f.do(context.Background(), "Test", func(ctx context.Context) (Secret, error) {
+			close(started)
+			<-release
+			panic("storage failure")
+		})
+	}()
+	<-started
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := f.do(context.Background(), "Test", func(ctx context.Context) (Secret, error) {
+			return Secret{KeyID: "Test"}, nil
+		})
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if p := <-panicked; p != "storage failure" {
+		t.Errorf("got panic %v, want 'storage failure'", p)
+	}
+	select {
+	case err := <-waiter:
+		if _, ok := err.(*ErrSecret); !ok {
+			t.Errorf("got waiter error %v, want ErrSecret", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter is not released")
+	}
+
+	// The key is fetched again after the panic
+	s, err := f.do(context.Background(), "Test", func(ctx context.Context) (Secret, error) {
+		return Secret{KeyID: "Test"}, nil
+	})
+	if err != nil || s.KeyID != "Test" {
+		t.Errorf("got secret %v, error %v, want fetched secret", s, err)
+	}
+}
@@ -0,0 +1,126 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// SetStreamedBodySpill spill streamed request bodies larger than threshold bytes to temporary file in dir
+// (os.TempDir() if empty) while digest is created, so the request stays replayable without holding the body in
+// memory. 0 — disabled (default). Max body size of SetStreamedBodyDigest still applies.
+func (d *Digest) SetStreamedBodySpill(threshold int64, dir string) {
+	d.spillThreshold = threshold
+	d.spillDir = dir
+}
+
+// createSpilled create digest of streamed body, spilling it to temporary file beyond threshold. Returns false if
+// the body fits in memory: it's replaced with in-memory reader & digest is created as usual.
+func (d *Digest) createSpilled(alg string, r *http.Request) (string, bool, error) {
+	var rd io.Reader = r.Body
+	if d.maxBodySize > 0 {
+		rd = io.LimitReader(r.Body, d.maxBodySize+1)
+	}
+	head, err := ioutil.ReadAll(io.LimitReader(rd, d.spillThreshold+1))
+	if err != nil {
+		return "", true, &ErrDigest{Message: "error reading body", Err: err}
+	}
+	if int64(len(head)) <= d.spillThreshold {
+		r.Body = readCloser{Reader: bytes.NewReader(head), Closer: r.Body}
+		return "", false, nil
+	}
+
+	name, h, ok := d.lookup(alg)
+	if !ok {
+		return "", true, &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	if d.decoded && !isIdentityEncoding(r.Header.Get(contentEncodingHeader)) {
+		return "", true, &ErrDigest{Message: "digest of decoded body is not supported for spilled body"}
+	}
+	f, err := ioutil.TempFile(d.spillDir, "httpsignatures-body-")
+	if err != nil {
+		return "", true, &ErrDigest{Message: "error creating temporary file", Err: err}
+	}
+	s := &spillFile{f: f}
+	w, sum := newHashWriter(h)
+	s.size, err = io.Copy(io.MultiWriter(f, w), io.MultiReader(bytes.NewReader(head), rd))
+	if err != nil {
+		_ = s.Close()
+		return "", true, &ErrDigest{Message: "error reading body", Err: err}
+	}
+	if d.maxBodySize > 0 && s.size > d.maxBodySize {
+		// Keep the body readable
+		r.Body = readCloser{Reader: io.MultiReader(s.reader(), r.Body), Closer: s.closer(r.Body)}
+		return "", true, &ErrDigest{Message: fmt.Sprintf("body is larger than %d bytes", d.maxBodySize)}
+	}
+	if err := r.Body.Close(); err != nil {
+		_ = s.Close()
+		return "", true, &ErrDigest{Message: "error closing body", Err: err}
+	}
+	r.Body = readCloser{Reader: s.reader(), Closer: s}
+	r.GetBody = s.getBody
+
+	hash, err := sum()
+	if err != nil {
+		return "", true, &ErrDigest{Message: fmt.Sprintf("error creating digest hash '%s'", alg), Err: err}
+	}
+	return name + "=" + base64.StdEncoding.EncodeToString(hash), true, nil
+}
+
+// spillFile temporary file with spilled body. The file is closed & removed when any body reader is closed, so
+// GetBody fails after that.
+type spillFile struct {
+	f      *os.File
+	size   int64
+	mu     sync.Mutex
+	closed bool
+}
+
+// reader new reader of the whole body
+func (s *spillFile) reader() io.Reader {
+	return io.NewSectionReader(s.f, 0, s.size)
+}
+
+// getBody new body reader, error if the file is already removed
+func (s *spillFile) getBody() (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, &ErrDigest{Message: "spilled body is closed"}
+	}
+	return readCloser{Reader: s.reader(), Closer: s}, nil
+}
+
+// closer closer which removes the file & closes c
+func (s *spillFile) closer(c io.Closer) io.Closer {
+	return closerFunc(func() error {
+		_ = s.Close()
+		return c.Close()
+	})
+}
+
+// Close close & remove the file
+func (s *spillFile) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	_ = s.f.Close()
+	return os.Remove(s.f.Name())
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
@@ -0,0 +1,107 @@
+package httpsignatures
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func testSpillFiles(t *testing.T, dir string) int {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func TestDigestStreamedBodySpill(t *testing.T) {
+	want, _ := NewDigest().create(algSha256, []byte(testBodyExample))
+	tests := []struct {
+		name        string
+		threshold   int64
+		maxBodySize int64
+		wantFiles   int
+		wantErrMsg  string
+	}{
+		{
+			name:      "Spilled to file",
+			threshold: 10,
+			wantFiles: 1,
+		},
+		{
+			name:      "Fits in memory",
+			threshold: int64(len(testBodyExample)),
+		},
+		{
+			name:        "Max body size",
+			threshold:   10,
+			maxBodySize: 15,
+			wantFiles:   1,
+			wantErrMsg:  "ErrDigest: body is larger than 15 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testTempDir(t)
+			d := NewDigest()
+			d.SetStreamedBodyDigest(StreamedBodyDigestBuffer, tt.maxBodySize)
+			d.SetStreamedBodySpill(tt.threshold, dir)
+			r := testPipeRequest(testBodyExample)
+			got, err := d.Create(algSha256, r)
+			if len(tt.wantErrMsg) > 0 {
+				assert(t, nil, err, testErrDigestType, tt.name, nil, tt.wantErrMsg)
+				if err == nil {
+					t.Errorf("no error, want %s", tt.wantErrMsg)
+				}
+			} else if err != nil || got != want {
+				t.Errorf("got digest %s, error %v, want %s", got, err, want)
+			}
+			if n := testSpillFiles(t, dir); n != tt.wantFiles {
+				t.Errorf("got %d temporary files, want %d", n, tt.wantFiles)
+			}
+
+			// Body is readable & replayable until it's closed
+			b, _ := ioutil.ReadAll(r.Body)
+			if string(b) != testBodyExample {
+				t.Errorf("body = %s, want %s", b, testBodyExample)
+			}
+			if r.GetBody != nil {
+				rc, _ := r.GetBody()
+				b, _ = ioutil.ReadAll(rc)
+				if string(b) != testBodyExample {
+					t.Errorf("GetBody = %s, want %s", b, testBodyExample)
+				}
+			}
+			_ = r.Body.Close()
+			if n := testSpillFiles(t, dir); n != 0 {
+				t.Errorf("got %d temporary files after body close, want 0", n)
+			}
+			if r.GetBody != nil && tt.wantFiles > 0 && len(tt.wantErrMsg) == 0 {
+				_, err := r.GetBody()
+				assert(t, nil, err, testErrDigestType, tt.name, nil, "ErrDigest: spilled body is closed")
+				if err == nil {
+					t.Error("GetBody() error = nil after body close")
+				}
+			}
+		})
+	}
+}
+
+func TestDigestStreamedBodySpillDir(t *testing.T) {
+	d := NewDigest()
+	d.SetStreamedBodySpill(10, testTempDir(t)+"/missing")
+	_, err := d.Create(algSha256, testPipeRequest(testBodyExample))
+	if err == nil || !strings.HasPrefix(err.Error(), "ErrDigest: error creating temporary file") {
+		t.Errorf("got error %v, want temporary file error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultAlgorithmRanking algorithms from the strongest, see SetAlgorithmRanking
+var defaultAlgorithmRanking = []string{
+	algED25519,
+	algEcdsaSha512,
+	algRsaSsaPssSha512,
+	algEcdsaSha256,
+	algRsaSsaPssSha256,
+	algRsaSha512,
+	algHmacSha512,
+	algRsaSha256,
+	algHmacSha256,
+}
+
+// SetAlgorithmRanking verify only the strongest acceptable of multiple signatures (comma-separated or repeated
+// Signature headers). Ranking lists acceptable algorithms from the strongest, empty ranking is the default one:
+// ED25519, ECDSA-SHA512, RSASSA-PSS-SHA512, ECDSA-SHA256, RSASSA-PSS-SHA256, RSA-SHA512, HMAC-SHA512, RSA-SHA256,
+// HMAC-SHA256. Signatures with other or unsupported algorithms are ignored, signatures with algorithm derived from
+// the key ("hs2019" or missing algorithm, if allowed) are the weakest. Nil ranking turns it off (default): the first
+// signature is verified.
+func (hs *HTTPSignatures) SetAlgorithmRanking(ranking []string) error {
+	if ranking == nil {
+		hs.algRanking = nil
+		return nil
+	}
+	if len(ranking) == 0 {
+		ranking = defaultAlgorithmRanking
+	}
+	res := make([]string, len(ranking))
+	for i, name := range ranking {
+		alg := hs.resolveAlgorithm(name)
+		if _, ok := hs.alg[alg]; !ok {
+			return &ErrHS{Message: fmt.Sprintf("algorithm '%s' not supported", name), kind: ErrUnsupportedAlgorithm}
+		}
+		res[i] = alg
+	}
+	hs.algRanking = res
+	return nil
+}
+
+// strongestSignature pick the strongest acceptable signature by algorithm ranking
+func (hs *HTTPSignatures) strongestSignature(signatures []Headers) (Headers, error) {
+	best, bestRank := -1, len(hs.algRanking)+1
+	for i, sh := range signatures {
+		rank, ok := hs.algorithmRank(sh)
+		if ok && rank < bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	if best < 0 {
+		return Headers{}, &ErrHS{Message: "no signature with acceptable algorithm", kind: ErrUnsupportedAlgorithm}
+	}
+	return signatures[best], nil
+}
+
+// algorithmRank rank of the signature algorithm, algorithm derived from the key is the weakest acceptable
+func (hs *HTTPSignatures) algorithmRank(sh Headers) (int, bool) {
+	alg := hs.resolveAlgorithm(sh.Algorithm)
+	if len(alg) == 0 {
+		derived := len(sh.Algorithm) > 0 || hs.quirksFor(sh.KeyID).AllowMissingAlgorithm
+		return len(hs.algRanking), derived
+	}
+	for i, a := range hs.algRanking {
+		if strings.EqualFold(a, alg) {
+			return i, true
+		}
+	}
+	return 0, false
+}
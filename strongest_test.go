@@ -0,0 +1,71 @@
+package httpsignatures
+
+import (
+	"strings"
+	"testing"
+)
+
+// testSign sign bench request & return its Signature & Digest headers
+func testSign(t *testing.T, hs *HTTPSignatures, keyID string) (string, string) {
+	r := testBenchRequest()
+	if err := hs.Sign(keyID, r); err != nil {
+		t.Fatal(err)
+	}
+	return r.Header.Get("Signature"), r.Header.Get("Digest")
+}
+
+func TestAlgorithmRanking(t *testing.T) {
+	hs := testBenchHS()
+	hs.SetDefaultExpiresSeconds(0)
+	hmac, digest := testSign(t, hs, "hmac")
+	ed, _ := testSign(t, hs, "ed25519")
+	unsupported := `keyId="x",algorithm="dsa-sha1",signature="c2ln"`
+	brokenEd := strings.Replace(ed, `signature="`, `signature="AAAA`, 1)
+
+	tests := []struct {
+		name       string
+		ranking    []string
+		signatures []string
+		wantKeyID  string
+		wantErrMsg string
+	}{
+		{name: "Strongest", ranking: []string{}, signatures: []string{hmac, ed}, wantKeyID: "ed25519"},
+		{name: "Repeated header", ranking: []string{}, signatures: []string{hmac + ", " + ed}, wantKeyID: "ed25519"},
+		{name: "Custom ranking", ranking: []string{algHmacSha256, algED25519}, signatures: []string{ed, hmac},
+			wantKeyID: "hmac"},
+		{name: "Unsupported ignored", ranking: []string{}, signatures: []string{unsupported, hmac}, wantKeyID: "hmac"},
+		{name: "Only strongest verified", ranking: []string{}, signatures: []string{hmac, brokenEd},
+			wantErrMsg: "wrong signature: ErrCrypto: signature verification error"},
+		{name: "No acceptable", ranking: []string{algRsaSha256}, signatures: []string{hmac, unsupported},
+			wantErrMsg: "no signature with acceptable algorithm"},
+		{name: "Off, unknown keyId skipped", signatures: []string{unsupported, hmac}, wantKeyID: "hmac"},
+		{name: "Off, no known keyId", signatures: []string{unsupported, strings.Replace(unsupported, "x", "y", 1)},
+			wantErrMsg: "keyID 'x' not found: ErrSecret: secret not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hs.SetAlgorithmRanking(tt.ranking); err != nil {
+				t.Fatal(err)
+			}
+			r := testBenchRequest()
+			r.Header.Set("Digest", digest)
+			for _, s := range tt.signatures {
+				r.Header.Add("Signature", s)
+			}
+			secret, err := hs.VerifyAndIdentify(r)
+			assert(t, nil, err, testHSErrType, tt.name, nil, tt.wantErrMsg)
+			if len(tt.wantErrMsg) == 0 && secret.KeyID != tt.wantKeyID {
+				t.Errorf("keyId = %s, want %s", secret.KeyID, tt.wantKeyID)
+			}
+			if len(tt.wantErrMsg) > 0 && err == nil {
+				t.Errorf("no error, want %s", tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestSetAlgorithmRanking(t *testing.T) {
+	hs := NewHTTPSignatures(testSecretsStorage)
+	err := hs.SetAlgorithmRanking([]string{"DSA"})
+	assert(t, nil, err, testHSErrType, "Unsupported", nil, "algorithm 'DSA' not supported")
+}
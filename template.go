@@ -0,0 +1,118 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// signatureTemplate static parts of the Signature header for the same keyId, algorithm, realm & headers list.
+// Only created, expires & signature are formatted for every signed message.
+type signatureTemplate struct {
+	headers      []string
+	prefix       string
+	headersParam string
+	hasCreated   bool
+	hasExpires   bool
+}
+
+type templateKey struct {
+	keyID     string
+	algorithm string
+	realm     string
+}
+
+// signatureTemplates templates cache, cleared when it's full. Every headers list of the same key adds a template,
+// so templates of all keys are counted.
+type signatureTemplates struct {
+	mu        sync.RWMutex
+	templates map[templateKey][]*signatureTemplate
+	size      int
+}
+
+func newSignatureTemplates() *signatureTemplates {
+	return &signatureTemplates{templates: make(map[templateKey][]*signatureTemplate)}
+}
+
+// get return cached template for the signature params or create it
+func (c *signatureTemplates) get(h Headers) *signatureTemplate {
+	k := templateKey{keyID: h.KeyID, algorithm: h.Algorithm, realm: h.Realm}
+	c.mu.RLock()
+	for _, t := range c.templates[k] {
+		if equalHeaders(t.headers, h.Headers) {
+			c.mu.RUnlock()
+			return t
+		}
+	}
+	c.mu.RUnlock()
+
+	t := newSignatureTemplate(h)
+	c.mu.Lock()
+	if c.size >= maxKeyCacheSize {
+		c.templates = make(map[templateKey][]*signatureTemplate)
+		c.size = 0
+	}
+	c.templates[k] = append(c.templates[k], t)
+	c.size++
+	c.mu.Unlock()
+	return t
+}
+
+func newSignatureTemplate(h Headers) *signatureTemplate {
+	t := &signatureTemplate{headers: append([]string(nil), h.Headers...)}
+	t.prefix = fmt.Sprintf(`%s="%s",`, paramKeyID, quotedString(h.KeyID))
+	if len(h.Realm) > 0 {
+		t.prefix += fmt.Sprintf(`%s="%s",`, paramRealm, quotedString(h.Realm))
+	}
+	if len(h.Algorithm) > 0 {
+		t.prefix += fmt.Sprintf(`%s="%s",`, paramAlgorithm, quotedString(h.Algorithm))
+	}
+	if len(h.Headers) > 0 {
+		t.headersParam = fmt.Sprintf(`%s="%s",`, paramHeaders, quotedString(strings.Join(h.Headers, " ")))
+	}
+	for _, name := range h.Headers {
+		switch name {
+		case created:
+			t.hasCreated = true
+		case expires:
+			t.hasExpires = true
+		}
+	}
+	return t
+}
+
+// build render Signature header, same as BuildSignatureHeader without extensions
+func (t *signatureTemplate) build(created time.Time, expires time.Time, signature string) string {
+	var b strings.Builder
+	b.Grow(len(t.prefix) + len(t.headersParam) + len(signature) + 64)
+	var ts [32]byte
+	b.WriteString(t.prefix)
+	if t.hasCreated && !created.IsZero() {
+		b.WriteString(paramCreated + "=")
+		b.Write(appendTimestamp(ts[:0], created))
+		b.WriteByte(',')
+	}
+	if t.hasExpires && !expires.IsZero() {
+		b.WriteString(paramExpires + "=")
+		b.Write(appendTimestamp(ts[:0], expires))
+		b.WriteByte(',')
+	}
+	b.WriteString(t.headersParam)
+	b.WriteString(paramSignature + `="`)
+	b.WriteString(quotedString(signature))
+	b.WriteByte('"')
+	return b.String()
+}
+
+func equalHeaders(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSignatureTemplateBuild(t *testing.T) {
+	tests := []Headers{
+		{
+			KeyID:     "key1",
+			Algorithm: "hmac-sha256",
+			Headers:   []string{"host"},
+			Signature: "c2lnbmF0dXJl",
+		},
+		{
+			KeyID:     `key"2\`,
+			Realm:     "realm",
+			Algorithm: "rsa-sha256",
+			Created:   time.Unix(1591130723, 0),
+			Expires:   time.Unix(1591130753, 0),
+			Headers:   []string{"(request-target)", "(created)", "(expires)", "digest"},
+			Signature: "c2lnbmF0dXJl",
+		},
+		{
+			KeyID:     "key3",
+			Created:   time.Unix(1591130723, 500000000),
+			Headers:   []string{"(created)"},
+			Signature: "c2lnbmF0dXJl",
+		},
+		{
+			KeyID:     "key4",
+			Signature: "c2lnbmF0dXJl",
+		},
+	}
+	for _, h := range tests {
+		t.Run(h.KeyID, func(t *testing.T) {
+			got := newSignatureTemplate(h).build(h.Created, h.Expires, h.Signature)
+			want := BuildSignatureHeader(h)
+			if got != want {
+				t.Errorf("wrong signature header\ngot  = %v,\nwant = %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSignatureTemplatesGet(t *testing.T) {
+	c := newSignatureTemplates()
+	h := Headers{KeyID: "key1", Algorithm: "hmac-sha256", Headers: []string{"(created)", "host"}}
+	t1 := c.get(h)
+	if c.get(h) != t1 {
+		t.Errorf("template is not cached")
+	}
+	h.Headers[1] = "date"
+	t2 := c.get(h)
+	if t2 == t1 || t2.headersParam != `headers="(created) date",` {
+		t.Errorf("template is not created for new headers list: %v", t2.headersParam)
+	}
+	h.Headers = []string{"(created)", "host"}
+	if c.get(h) != t1 {
+		t.Errorf("template for the first headers list is not cached")
+	}
+	h.Realm = "realm"
+	if c.get(h) == t1 {
+		t.Errorf("same template for different realm")
+	}
+}
+
+func TestSignatureTemplatesSize(t *testing.T) {
+	c := newSignatureTemplates()
+	for i := 0; i <= maxKeyCacheSize; i++ {
+		c.get(Headers{KeyID: "key1", Headers: []string{"(created)", fmt.Sprintf("x-header-%d", i)}})
+	}
+	k := templateKey{keyID: "key1"}
+	if c.size != 1 || len(c.templates[k]) != 1 {
+		t.Errorf("got %d templates (%d of key1), want cache cleared when full", c.size, len(c.templates[k]))
+	}
+}
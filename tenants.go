@@ -0,0 +1,123 @@
+package httpsignatures
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Tenants multi-tenant verifier: one gateway verifies signatures for many tenants with isolated key spaces.
+// Tenant HTTPSignatures (secrets storage, policy, etc.) is selected by the request host or tenant ID header.
+// Safe for concurrent use, tenants could be added & removed at runtime.
+type Tenants struct {
+	mu            sync.RWMutex
+	header        string
+	tenants       map[string]*HTTPSignatures
+	fallback      *HTTPSignatures
+	rejections    Rejections
+	uniformErrors bool
+}
+
+// NewTenants create multi-tenant verifier. Tenant is selected by header value (e.g. "X-Tenant-ID"),
+// by request host (without port) if header is empty.
+func NewTenants(header string) *Tenants {
+	return &Tenants{header: header, tenants: make(map[string]*HTTPSignatures)}
+}
+
+// Set set tenant verifier (tenant ID or host, case-insensitive)
+func (t *Tenants) Set(tenant string, hs *HTTPSignatures) {
+	t.mu.Lock()
+	t.tenants[strings.ToLower(tenant)] = hs
+	t.mu.Unlock()
+}
+
+// Delete remove tenant
+func (t *Tenants) Delete(tenant string) {
+	t.mu.Lock()
+	delete(t.tenants, strings.ToLower(tenant))
+	t.mu.Unlock()
+}
+
+// SetFallback set verifier of requests of unknown tenants, nil to reject them (default)
+func (t *Tenants) SetFallback(hs *HTTPSignatures) {
+	t.mu.Lock()
+	t.fallback = hs
+	t.mu.Unlock()
+}
+
+// SetRejections set rejection status (Rejections.Invalid) & renderer of requests of unknown tenants in the
+// VerifyRequests middleware. Tenant verifiers reject requests with their own Rejections.
+func (t *Tenants) SetRejections(r Rejections) {
+	t.mu.Lock()
+	t.rejections = r
+	t.mu.Unlock()
+}
+
+// SetUniformErrors reject requests of unknown tenants with "unauthorized" reason, as tenant verifiers with
+// SetUniformErrors do, so clients can't probe which tenants exist
+func (t *Tenants) SetUniformErrors(v bool) {
+	t.mu.Lock()
+	t.uniformErrors = v
+	t.mu.Unlock()
+}
+
+// Lookup return verifier of the request tenant, ErrUnknownTenant if tenant is not set & there's no fallback
+func (t *Tenants) Lookup(r *http.Request) (*HTTPSignatures, error) {
+	tenant := t.tenant(r)
+	t.mu.RLock()
+	hs, ok := t.tenants[tenant]
+	if !ok {
+		hs = t.fallback
+	}
+	t.mu.RUnlock()
+	if hs == nil {
+		return nil, &ErrHS{Message: fmt.Sprintf("unknown tenant '%s'", tenant), kind: ErrUnknownTenant}
+	}
+	return hs, nil
+}
+
+// Verify verify request signature with the tenant verifier
+func (t *Tenants) Verify(r *http.Request) error {
+	_, err := t.VerifyAndIdentifyCtx(r.Context(), r)
+	return err
+}
+
+// VerifyAndIdentifyCtx verify request signature with the tenant verifier & return secret which validated it
+func (t *Tenants) VerifyAndIdentifyCtx(ctx context.Context, r *http.Request) (Secret, error) {
+	hs, err := t.Lookup(r)
+	if err != nil {
+		return Secret{}, err
+	}
+	return hs.VerifyAndIdentifyCtx(ctx, r)
+}
+
+// VerifyRequests handler wrapper which verifies requests with the tenant VerifyRequests middleware.
+// Requests of unknown tenants are rejected with Rejections.Invalid status (401 Unauthorized by default).
+func (t *Tenants) VerifyRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs, err := t.Lookup(r)
+		if err != nil {
+			t.mu.RLock()
+			rejections, uniformErrors := t.rejections, t.uniformErrors
+			t.mu.RUnlock()
+			renderRejection(w, r, classifyRejection(err, rejections, uniformErrors), rejections)
+			return
+		}
+		hs.VerifyRequests(next).ServeHTTP(w, r)
+	})
+}
+
+// tenant tenant of the request: header value or host without port
+func (t *Tenants) tenant(r *http.Request) string {
+	if len(t.header) > 0 {
+		return strings.ToLower(strings.TrimSpace(r.Header.Get(t.header)))
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,148 @@
+package httpsignatures
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	tenantHS := func(secret string) *HTTPSignatures {
+		return NewHTTPSignatures(NewSimpleSecretsStorage(map[string]Secret{
+			"hmac": {KeyID: "hmac", PrivateKey: secret, Algorithm: algHmacSha256},
+		}))
+	}
+	a, b := tenantHS("secret-a"), tenantHS("secret-b")
+	signed := func(host string, tenant string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://"+host+"/foo", nil)
+		r.Header.Set("X-Tenant-ID", tenant)
+		if err := a.Sign("hmac", r); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	byHost := NewTenants("")
+	byHost.Set("A.example.com", a)
+	byHost.Set("b.example.com", b)
+	byHeader := NewTenants("X-Tenant-ID")
+	byHeader.Set("a", a)
+	byHeader.Set("b", b)
+
+	tests := []struct {
+		name    string
+		tenants *Tenants
+		r       *http.Request
+		wantErr error
+	}{
+		{name: "Host", tenants: byHost, r: signed("a.example.com:8080", "")},
+		{name: "Other tenant keys", tenants: byHost, r: signed("b.example.com", ""), wantErr: ErrWrongSignature},
+		{name: "Unknown host", tenants: byHost, r: signed("c.example.com", ""), wantErr: ErrUnknownTenant},
+		{name: "Header", tenants: byHeader, r: signed("example.com", "A")},
+		{name: "Header other tenant", tenants: byHeader, r: signed("example.com", "b"), wantErr: ErrWrongSignature},
+		{name: "Header not set", tenants: byHeader, r: signed("example.com", ""), wantErr: ErrUnknownTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tenants.Verify(tt.r); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	byHost.Delete("a.example.com")
+	if err := byHost.Verify(signed("a.example.com", "")); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("got error %v, want ErrUnknownTenant", err)
+	}
+	byHost.SetFallback(a)
+	if err := byHost.Verify(signed("c.example.com", "")); err != nil {
+		t.Errorf("fallback: unexpected error: %s", err)
+	}
+}
+
+func TestTenantsVerifyRequests(t *testing.T) {
+	hs := NewHTTPSignatures(testBenchSecrets)
+	tenants := NewTenants("X-Tenant-ID")
+	tenants.Set("a", hs)
+	h := tenants.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		tenant     string
+		wantStatus int
+	}{
+		{name: "Verified", tenant: "a", wantStatus: http.StatusNoContent},
+		{name: "Unknown tenant", tenant: "b", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, testHostExamplePath, nil)
+			r.Header.Set("X-Tenant-ID", tt.tenant)
+			if err := hs.Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTenantsVerifyRequestsRejections(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		name        string
+		uniform     bool
+		wantStatus  int
+		wantDetail  string
+		wantContent string
+	}{
+		{
+			name:        "Configured status & renderer",
+			wantStatus:  http.StatusForbidden,
+			wantDetail:  "unknown tenant",
+			wantContent: "application/problem+json",
+		},
+		{
+			name:        "Uniform errors",
+			uniform:     true,
+			wantStatus:  http.StatusForbidden,
+			wantDetail:  "unauthorized",
+			wantContent: "application/problem+json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := NewTenants("X-Tenant-ID")
+			tenants.Set("a", NewHTTPSignatures(testBenchSecrets))
+			tenants.SetRejections(Rejections{Invalid: http.StatusForbidden, Render: ProblemJSONRejection})
+			tenants.SetUniformErrors(tt.uniform)
+
+			r := httptest.NewRequest(http.MethodGet, testHostExamplePath, nil)
+			r.Header.Set("X-Tenant-ID", "b")
+			rec := httptest.NewRecorder()
+			tenants.VerifyRequests(next).ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContent {
+				t.Errorf("got Content-Type %s, want %s", got, tt.wantContent)
+			}
+			var p problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("got detail %s, want %s", p.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package httpsignatures
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// signTrailer sign streamed body request (StreamedBodyDigestSignedTrailer): Digest & Signature are set in trailers
+// when the body is sent, so the signature covers the body hash without buffering the body
+func (hs *HTTPSignatures) signTrailer(ctx context.Context, secretKeyID string, r *http.Request) error {
+	// Fail before the request is sent if keyId is unknown
+	if _, err := hs.getSecret(ctx, secretKeyID); errors.Is(err, ErrKeyResolutionTimeout) {
+		return err
+	} else if err != nil {
+		return &ErrHS{Message: fmt.Sprintf("keyId '%s' not found", secretKeyID), Err: err, kind: ErrUnknownKeyID}
+	}
+	if err := hs.checkSignatureHeaders(hs.defaultHeaders); err != nil {
+		return err
+	}
+
+	target, host := hs.requestTarget(r), r.Host
+	err := hs.d.setTrailer(hs.d.defaultAlg, r, func(digest string) error {
+		header := r.Header.Clone()
+		header.Set(digestHeader, digest)
+		err := hs.sign(ctx, secretKeyID, hs.defaultHeaders, header, target, host, func([]string) (string, error) {
+			return "", nil
+		})
+		if err != nil {
+			return err
+		}
+		r.Trailer.Set(signatureHeader, header.Get(signatureHeader))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.Trailer[signatureHeader] = nil
+	return nil
+}
+
+// isSignedTrailer check request signature is sent in trailer (StreamedBodyDigestSignedTrailer)
+func (hs *HTTPSignatures) isSignedTrailer(r *http.Request) bool {
+	_, ok := r.Trailer[signatureHeader]
+	return ok && hs.d.streamed == StreamedBodyDigestSignedTrailer
+}
+
+// verifyTrailer verify signature & digest sent in trailers. The body is read here (up to max body size) & replaced
+// with in-memory copy: trailers are received at the end of the body, so the request is never passed on (e.g. by
+// VerifyRequests to the next handler) before its signature is verified.
+func (hs *HTTPSignatures) verifyTrailer(ctx context.Context, r *http.Request) (Secret, error) {
+	name, h, ok := hs.d.lookup(hs.d.defaultAlg)
+	if !ok {
+		return Secret{}, &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", hs.d.defaultAlg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	body := r.Body
+	if body == nil {
+		body = http.NoBody
+	}
+
+	var secret Secret
+	w, sum := newHashWriter(h)
+	r.Body = &trailerReader{
+		body: body,
+		r:    io.TeeReader(body, w),
+		done: func() error {
+			hash, err := sum()
+			if err != nil {
+				return &ErrDigest{Message: "error creating digest", Err: err}
+			}
+			secret, err = hs.verifyTrailers(ctx, r, name, hash)
+			return err
+		},
+	}
+	var rd io.Reader = r.Body
+	if hs.d.maxBodySize > 0 {
+		rd = io.LimitReader(r.Body, hs.d.maxBodySize+1)
+	}
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return Secret{}, err
+	}
+	if hs.d.maxBodySize > 0 && int64(len(b)) > hs.d.maxBodySize {
+		// Keep the body readable
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(b), r.Body), Closer: r.Body}
+		return Secret{}, &ErrDigest{Message: fmt.Sprintf("body is larger than %d bytes", hs.d.maxBodySize)}
+	}
+	_ = r.Body.Close()
+	hs.d.resetBody(r, b)
+	return secret, nil
+}
+
+// verifyTrailers verify Signature trailer (over request headers & trailers) & compare Digest trailer with body hash
+func (hs *HTTPSignatures) verifyTrailers(ctx context.Context, r *http.Request, alg string, hash []byte) (Secret,
+	error) {
+	header := r.Header.Clone()
+	for k, v := range r.Trailer {
+		header[k] = v
+	}
+	tr := r.WithContext(ctx)
+	tr.Header = header
+	tr.Body = http.NoBody
+	// Missing Signature trailer must fail as missing header, not be looked up in trailers again
+	tr.Trailer = nil
+	// Digest is compared below with the hash of the read body
+	v := *hs
+	v.defaultVerifyDigest = false
+	secret, err := v.verifyRequest(ctx, tr, false)
+	if err != nil {
+		return Secret{}, err
+	}
+	if !hs.defaultVerifyDigest {
+		return secret, nil
+	}
+
+	p := getParser()
+	dh, pErr := p.ParseDigestHeader(r.Trailer.Get(digestHeader))
+	putParser(p)
+	if pErr != nil {
+		return Secret{}, pErr
+	}
+	if name, _, ok := hs.d.lookup(dh.Alg); !ok || name != alg {
+		return Secret{}, &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", dh.Alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	digest, err := base64.StdEncoding.DecodeString(dh.Digest)
+	if err != nil {
+		return Secret{}, &ErrDigest{Message: "error decode digest from base64", Err: err}
+	}
+	if subtle.ConstantTimeCompare(hash, digest) != 1 {
+		return Secret{}, &ErrDigest{Message: "wrong digest", Err: &ErrCrypto{Message: "wrong hash"}, kind: ErrDigestMismatch}
+	}
+	return secret, nil
+}
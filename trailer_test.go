@@ -0,0 +1,193 @@
+package httpsignatures
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testMultipartRequest streamed multipart/form-data upload built with multipart.Writer & io.Pipe
+func testMultipartRequest(url string, content string) *http.Request {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		fw, err := mw.CreateFormFile("file", "upload.txt")
+		if err == nil {
+			_, err = io.Copy(fw, strings.NewReader(content))
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	r, _ := http.NewRequest(http.MethodPost, url, pr)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func testSignedTrailerHS(ss Secrets) *HTTPSignatures {
+	hs := NewHTTPSignatures(ss)
+	hs.SetDefaultSignatureHeaders([]string{requestTarget, created, "content-type", "digest"})
+	hs.SetStreamedBodyDigest(StreamedBodyDigestSignedTrailer, 0)
+	return hs
+}
+
+func TestSignedTrailerMultipart(t *testing.T) {
+	content := strings.Repeat(testBodyExample, 1000)
+	tests := []struct {
+		name       string
+		server     Secrets
+		wantStatus int
+	}{
+		{
+			name:       "Valid signature & digest",
+			server:     testBenchSecrets,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Wrong signature",
+			server: NewSimpleSecretsStorage(map[string]Secret{
+				"hmac": {KeyID: "hmac", PrivateKey: "other", PublicKey: "other", Algorithm: algHmacSha256},
+			}),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotContent string
+			srv := httptest.NewServer(testSignedTrailerHS(tt.server).VerifyRequests(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mr, err := r.MultipartReader()
+					if err != nil {
+						t.Errorf("MultipartReader() error = %v", err)
+						return
+					}
+					part, err := mr.NextPart()
+					if err != nil {
+						t.Errorf("NextPart() error = %v", err)
+						return
+					}
+					b, _ := ioutil.ReadAll(part)
+					gotContent = string(b)
+				})))
+			defer srv.Close()
+
+			r := testMultipartRequest(srv.URL, content)
+			if err := testSignedTrailerHS(testBenchSecrets).Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.Header.Get(signatureHeader)) > 0 || len(r.Header.Get(digestHeader)) > 0 {
+				t.Errorf("signature & digest must be sent in trailers")
+			}
+			resp, err := srv.Client().Do(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && gotContent != content {
+				t.Errorf("got content length %d, want %d", len(gotContent), len(content))
+			}
+			if tt.wantStatus != http.StatusOK && len(gotContent) > 0 {
+				t.Errorf("handler is called for request with wrong signature")
+			}
+		})
+	}
+}
+
+func TestSignedTrailerUnsigned(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(testSignedTrailerHS(testBenchSecrets).VerifyRequests(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})))
+	defer srv.Close()
+
+	// Signature trailer is announced, but never sent
+	r := testMultipartRequest(srv.URL, testBodyExample)
+	r.Trailer = http.Header{signatureHeader: nil}
+	resp, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || called {
+		t.Errorf("got status %d & handler called %v, want 401 & handler not called", resp.StatusCode, called)
+	}
+}
+
+func TestSignedTrailerVerify(t *testing.T) {
+	hs := testSignedTrailerHS(testBenchSecrets)
+	var keyID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, err := hs.VerifyAndIdentify(r)
+		if err != nil {
+			t.Errorf("VerifyAndIdentify() error = %v", err)
+		}
+		keyID = secret.KeyID
+		// Body is kept for the handler
+		b, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(b), testBodyExample) {
+			t.Errorf("body is not readable after verification: %s", b)
+		}
+	}))
+	defer srv.Close()
+
+	r := testMultipartRequest(srv.URL, testBodyExample)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if keyID != "hmac" {
+		t.Errorf("got keyId %s, want hmac", keyID)
+	}
+}
+
+func TestSignedTrailerUnknownKey(t *testing.T) {
+	r := testMultipartRequest(testFullHostExample, testBodyExample)
+	err := testSignedTrailerHS(testBenchSecrets).Sign("unknown", r)
+	if !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("got error = %v, want %v", err, ErrUnknownKeyID)
+	}
+	if r.Trailer != nil {
+		t.Errorf("trailers are set for failed signing")
+	}
+}
+
+func TestSignedTrailerPrincipal(t *testing.T) {
+	hs := testSignedTrailerHS(testBenchSecrets)
+	hs.SetPrincipalResolver(func(ctx context.Context, secret Secret) (interface{}, error) {
+		return "user:" + secret.KeyID, nil
+	})
+	var principal interface{}
+	srv := httptest.NewServer(hs.VerifyRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFromContext(r.Context())
+	})))
+	defer srv.Close()
+
+	r := testMultipartRequest(srv.URL, testBodyExample)
+	if err := testSignedTrailerHS(testBenchSecrets).Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || principal != "user:hmac" {
+		t.Errorf("got status %d & principal %v, want 200 & user:hmac", resp.StatusCode, principal)
+	}
+}
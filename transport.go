@@ -0,0 +1,169 @@
+package httpsignatures
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const contentDigestHeader = "Content-Digest"
+
+// ResponseDigest response Digest/Content-Digest verification policy of the Transport
+type ResponseDigest int
+
+const (
+	// ResponseDigestIgnore don't verify response digest (default)
+	ResponseDigestIgnore ResponseDigest = iota
+	// ResponseDigestIfPresent verify digest if response has Digest or Content-Digest header
+	ResponseDigestIfPresent
+	// ResponseDigestRequired verify digest, responses without Digest & Content-Digest headers are rejected
+	ResponseDigestRequired
+)
+
+// Transport http.RoundTripper which signs requests & verifies responses digest (and signature) before returning them
+type Transport struct {
+	hs                *HTTPSignatures
+	keyID             string
+	base              http.RoundTripper
+	responseDigest    ResponseDigest
+	responseSignature bool
+}
+
+// NewTransport create transport signing requests with keyID secret, base is http.DefaultTransport if nil
+func (hs *HTTPSignatures) NewTransport(keyID string, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{hs: hs, keyID: keyID, base: base}
+}
+
+// SetResponseDigest set response digest verification policy (ResponseDigestIgnore by default).
+// Body of verified responses is read into memory (up to the digest max body size).
+func (t *Transport) SetResponseDigest(p ResponseDigest) {
+	t.responseDigest = p
+}
+
+// SetVerifyResponseSignature verify response signature with VerifyResponse, unsigned responses are rejected
+func (t *Transport) SetVerifyResponseSignature(v bool) {
+	t.responseSignature = v
+}
+
+// RoundTrip sign request (a copy, the request is not modified) & verify response
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	req := r.Clone(r.Context())
+	if err := t.hs.SignCtx(req.Context(), t.keyID, req); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verifyResponse(req.Context(), resp); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// verifyResponse verify response digest by policy & signature if enabled
+func (t *Transport) verifyResponse(ctx context.Context, resp *http.Response) error {
+	if err := t.verifyResponseDigest(resp); err != nil {
+		return err
+	}
+	if t.responseSignature {
+		return t.hs.VerifyResponseCtx(ctx, resp)
+	}
+	return nil
+}
+
+// verifyResponseDigest verify response digest by policy
+func (t *Transport) verifyResponseDigest(resp *http.Response) error {
+	if t.responseDigest == ResponseDigestIgnore {
+		return nil
+	}
+	if len(resp.Header.Get(contentDigestHeader)) == 0 && len(resp.Header.Get(digestHeader)) == 0 {
+		if t.responseDigest == ResponseDigestRequired {
+			return &ErrDigest{Message: "response digest header not found"}
+		}
+		return nil
+	}
+	return t.hs.d.verifyResponse(resp)
+}
+
+// verifyResponse verify response body with Content-Digest (preferred) or Digest header
+func (d *Digest) verifyResponse(resp *http.Response) error {
+	var alg, digest string
+	if h := resp.Header.Get(contentDigestHeader); len(h) > 0 {
+		var ok bool
+		if alg, digest, ok = d.parseContentDigest(h); !ok {
+			return &ErrDigest{
+				Message: fmt.Sprintf("unsupported digest hash algorithm in '%s'", h),
+				kind:    ErrUnsupportedAlgorithm,
+			}
+		}
+	} else {
+		p := getParser()
+		dh, pErr := p.ParseDigestHeader(resp.Header.Get(digestHeader))
+		putParser(p)
+		if pErr != nil {
+			return pErr
+		}
+		alg, digest = dh.Alg, dh.Digest
+	}
+	_, h, ok := d.lookup(alg)
+	if !ok {
+		return &ErrDigest{
+			Message: fmt.Sprintf("unsupported digest hash algorithm '%s'", alg),
+			kind:    ErrUnsupportedAlgorithm,
+		}
+	}
+	if resp.Uncompressed && !d.decoded {
+		return &ErrDigest{Message: "response body is decompressed by transport, digest of raw body can't be verified"}
+	}
+
+	b, dErr := d.readResponseBody(resp)
+	if dErr != nil {
+		return dErr
+	}
+	b, dErr = d.decodeBody(resp.Header, b)
+	if dErr != nil {
+		return dErr
+	}
+	sum, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return &ErrDigest{Message: "error decode digest from base64", Err: err}
+	}
+	if err = h.Verify(b, sum); err != nil {
+		return &ErrDigest{Message: "wrong digest", Err: err, kind: ErrDigestMismatch}
+	}
+	return nil
+}
+
+// parseContentDigest return the first supported algorithm & digest of Content-Digest header (RFC 9530),
+// e.g. "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+func (d *Digest) parseContentDigest(h string) (string, string, bool) {
+	for _, item := range strings.Split(h, ",") {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			continue
+		}
+		alg := strings.TrimSpace(item[:i])
+		v := strings.TrimSpace(item[i+1:])
+		if len(v) < 2 || v[0] != ':' || v[len(v)-1] != ':' {
+			continue
+		}
+		if _, _, ok := d.lookup(alg); ok {
+			return alg, v[1 : len(v)-1], true
+		}
+	}
+	return "", "", false
+}
+
+// readResponseBody read response body (up to max body size) & replace it with in-memory copy
+func (d *Digest) readResponseBody(resp *http.Response) ([]byte, *ErrDigest) {
+	b, rc, dErr := d.bufferBody(resp.Body)
+	resp.Body = rc
+	return b, dErr
+}
@@ -0,0 +1,98 @@
+package httpsignatures
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	const (
+		digestSha256  = "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="
+		contentDigest = "sha-1=:e30=:, sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+		body          = `{"hello": "world"}`
+	)
+	verifier := NewHTTPSignatures(testBenchSecrets)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/digest":
+			w.Header().Set(digestHeader, digestSha256)
+		case "/content-digest":
+			w.Header().Set(contentDigestHeader, contentDigest)
+		case "/wrong":
+			w.Header().Set(digestHeader, "SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+		case "/unsupported":
+			w.Header().Set(contentDigestHeader, "sha-1=:e30=:")
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		policy     ResponseDigest
+		wantErr    error
+		wantErrMsg string
+	}{
+		{name: "Ignore", path: "/wrong", policy: ResponseDigestIgnore},
+		{name: "Digest", path: "/digest", policy: ResponseDigestIfPresent},
+		{name: "Content-Digest", path: "/content-digest", policy: ResponseDigestRequired},
+		{name: "Wrong digest", path: "/wrong", policy: ResponseDigestIfPresent, wantErr: ErrDigestMismatch},
+		{
+			name:    "Unsupported algorithm",
+			path:    "/unsupported",
+			policy:  ResponseDigestIfPresent,
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{name: "Missing digest", path: "/", policy: ResponseDigestIfPresent},
+		{
+			name:       "Missing required digest",
+			path:       "/",
+			policy:     ResponseDigestRequired,
+			wantErrMsg: "ErrDigest: response digest header not found",
+		},
+	}
+	hs := NewHTTPSignatures(testBenchSecrets)
+	hs.SetDefaultSignatureHeaders([]string{"(request-target)", "(created)"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := hs.NewTransport("hmac", nil)
+			tr.SetResponseDigest(tt.policy)
+			c := &http.Client{Transport: tr}
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			resp, err := c.Do(req)
+			if req.Header.Get(signatureHeader) != "" {
+				t.Errorf("original request is modified")
+			}
+			if tt.wantErr == nil && len(tt.wantErrMsg) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				b, _ := ioutil.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if resp.StatusCode != http.StatusOK || string(b) != body {
+					t.Errorf("got status %d & body %s", resp.StatusCode, b)
+				}
+				return
+			}
+			if err == nil {
+				_ = resp.Body.Close()
+				t.Fatalf("no error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.wantErrMsg) > 0 && !strings.HasSuffix(err.Error(), tt.wantErrMsg) {
+				t.Errorf("got error %v, want %s", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	forwardedHostHeader  = "X-Forwarded-Host"
+	forwardedProtoHeader = "X-Forwarded-Proto"
+)
+
+// SetTrustedProxies set CIDRs of trusted proxies (e.g. load balancers), nil to trust none (default).
+// For requests from trusted proxies host & scheme are taken from X-Forwarded-Host & X-Forwarded-Proto headers
+// (the right-most values, set by the nearest proxy), because the client signed the external host while r.Host is
+// the internal one.
+func (hs *HTTPSignatures) SetTrustedProxies(cidrs ...string) error {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return &ErrHS{Message: fmt.Sprintf("wrong trusted proxy CIDR '%s'", c), Err: err}
+		}
+		nets = append(nets, n)
+	}
+	hs.trustedProxies = nets
+	return nil
+}
+
+// trustedProxy check the request is received from trusted proxy
+func (hs *HTTPSignatures) trustedProxy(r *http.Request) bool {
+	if len(hs.trustedProxies) == 0 || len(r.RemoteAddr) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range hs.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwarded return the last value of X-Forwarded-* header of the request from trusted proxy. Proxies append values,
+// so the left ones could be sent by the client & only the right-most one (added by the nearest proxy) is trusted.
+func (hs *HTTPSignatures) forwarded(r *http.Request, header string) string {
+	if !hs.trustedProxy(r) {
+		return ""
+	}
+	values := r.Header.Values(header)
+	if len(values) == 0 {
+		return ""
+	}
+	v := values[len(values)-1]
+	if i := strings.LastIndexByte(v, ','); i >= 0 {
+		v = v[i+1:]
+	}
+	return strings.TrimSpace(v)
+}
+
+// requestHost host of the request, X-Forwarded-Host of the request from trusted proxy
+func (hs *HTTPSignatures) requestHost(r *http.Request) string {
+	if h := hs.forwarded(r, forwardedHostHeader); len(h) > 0 {
+		return h
+	}
+	return r.Host
+}
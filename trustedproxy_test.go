@@ -0,0 +1,74 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	client := NewHTTPSignatures(testBenchSecrets)
+	client.SetDefaultSignatureHeaders([]string{"(request-target)", "host"})
+	client.SetRequestTargetForm(RequestTargetAbsolute, AuthorityFromURL)
+	r, _ := http.NewRequest(http.MethodGet, "https://api.example.com/foo", nil)
+	if err := client.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		forwarded  []string
+		wantErr    bool
+	}{
+		{name: "Not trusted", remoteAddr: "10.0.0.5:1234", forwarded: []string{"api.example.com"}, wantErr: true},
+		{name: "Trusted", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.5:1234",
+			forwarded: []string{"api.example.com"}},
+		{name: "Trusted IPv6", proxies: []string{"fd00::/8"}, remoteAddr: "[fd00::1]:1234",
+			forwarded: []string{"api.example.com"}},
+		{
+			name:       "Other proxy",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "192.0.2.1:1234",
+			forwarded:  []string{"api.example.com"},
+			wantErr:    true,
+		},
+		{name: "Not forwarded", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.5:1234", wantErr: true},
+		// Client sent X-Forwarded-Host, the proxy appended the host the request was received for
+		{name: "Appended by proxy", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.5:1234",
+			forwarded: []string{"evil.com, api.example.com"}},
+		{name: "Sent by client", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.5:1234",
+			forwarded: []string{"api.example.com, evil.com"}, wantErr: true},
+		{name: "Appended header line", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.5:1234",
+			forwarded: []string{"evil.com", "api.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetRequestTargetForm(RequestTargetAbsolute, AuthorityFromHost)
+			if err := hs.SetTrustedProxies(tt.proxies...); err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "http://internal:8080/foo", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(signatureHeader, r.Header.Get(signatureHeader))
+			if len(tt.forwarded) > 0 {
+				req.Header[forwardedHostHeader] = tt.forwarded
+				req.Header.Set(forwardedProtoHeader, "http, https")
+			}
+			err := hs.Verify(req)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	hs := NewHTTPSignatures(testBenchSecrets)
+	err := hs.SetTrustedProxies("10.0.0.0")
+	assert(t, nil, err, testHSErrType, "Wrong CIDR", nil,
+		"wrong trusted proxy CIDR '10.0.0.0': invalid CIDR address: 10.0.0.0")
+	if err == nil {
+		t.Error("no error, want wrong CIDR")
+	}
+}
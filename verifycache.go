@@ -0,0 +1,104 @@
+package httpsignatures
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// verifyCache LRU cache of successful verifications by keyId, signature & hash of the key & signature string.
+// Entries expire with the signature validity window.
+type verifyCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type verifyCacheEntry struct {
+	key     string
+	expires time.Time
+}
+
+func newVerifyCache(size int) *verifyCache {
+	return &verifyCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get check verification is cached & not expired
+func (c *verifyCache) get(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if now.After(e.Value.(*verifyCacheEntry).expires) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+		return false
+	}
+	c.ll.MoveToFront(e)
+	return true
+}
+
+// add cache verification till expires, the least recently used entry is evicted when the cache is full
+func (c *verifyCache) add(key string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*verifyCacheEntry).expires = expires
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&verifyCacheEntry{key: key, expires: expires})
+	if c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*verifyCacheEntry).key)
+	}
+}
+
+// verifyCacheKey cache key: keyId, signature & hash of the verifying key & signature string (covered data). Key
+// material is the public key & the private key, which is the key of symmetric (HMAC) algorithms, so cached
+// verifications are not used after key rotation or revocation.
+func verifyCacheKey(secret Secret, sh Headers, sigStr []byte) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(secret.Algorithm + "\n" + secret.PublicKey + "\n" + secret.PrivateKey + "\n"))
+	_, _ = h.Write(sigStr)
+	return sh.KeyID + "\n" + sh.Signature + "\n" + string(h.Sum(nil))
+}
+
+// SetVerifyCache cache up to size successful verifications, so identical retried requests (e.g. webhook
+// redelivery) skip the public key operation. Only signatures with validity window (signed expires, created with
+// Policy.MaxAge or Date with Policy.MaxDateAge) are cached till it ends. 0 — disabled (default).
+func (hs *HTTPSignatures) SetVerifyCache(size int) {
+	hs.verified = nil
+	if size > 0 {
+		hs.verified = newVerifyCache(size)
+	}
+}
+
+// verifyCacheExpires end of the signature validity window (zero if signature has no window)
+func (hs *HTTPSignatures) verifyCacheExpires(sh Headers, date string) time.Time {
+	var res time.Time
+	earliest := func(t time.Time) {
+		if res.IsZero() || t.Before(res) {
+			res = t
+		}
+	}
+	if hs.inHeaders(expires, sh.Headers) && !sh.Expires.IsZero() {
+		earliest(sh.Expires.Add(hs.defaultTimeGap))
+	}
+	if hs.policy.MaxAge > 0 && hs.inHeaders(created, sh.Headers) && !sh.Created.IsZero() &&
+		sh.Created != time.Unix(0, 0) {
+		earliest(sh.Created.Add(hs.policy.MaxAge + hs.defaultTimeGap))
+	}
+	if hs.policy.MaxDateAge > 0 && len(withoutHeader(sh.Headers, "date")) < len(sh.Headers) {
+		if d, err := http.ParseTime(date); err == nil {
+			earliest(d.Add(hs.policy.MaxDateAge + hs.defaultTimeGap))
+		}
+	}
+	return res
+}
@@ -0,0 +1,124 @@
+package httpsignatures
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCountingHmac HMAC-SHA256 which counts Verify calls
+type testCountingHmac struct {
+	HmacSha256
+	verified *int
+}
+
+func (a testCountingHmac) Verify(secret Secret, data []byte, signature []byte) error {
+	*a.verified++
+	return a.HmacSha256.Verify(secret, data, signature)
+}
+
+func TestVerifyCache(t *testing.T) {
+	signedAt := time.Unix(1600000000, 0)
+	tests := []struct {
+		name         string
+		headers      []string
+		expires      uint32
+		policy       Policy
+		verifyAt     []time.Duration
+		wantVerified int
+	}{
+		{
+			name:         "Cached till expires",
+			headers:      []string{created, expires, "digest"},
+			expires:      30,
+			verifyAt:     []time.Duration{0, time.Second, 20 * time.Second},
+			wantVerified: 1,
+		},
+		{
+			name:         "Cached till created max age",
+			headers:      []string{created, "digest"},
+			policy:       Policy{MaxAge: time.Minute},
+			verifyAt:     []time.Duration{0, 30 * time.Second},
+			wantVerified: 1,
+		},
+		{
+			name:         "No validity window",
+			headers:      []string{created, "digest"},
+			verifyAt:     []time.Duration{0, time.Second},
+			wantVerified: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verified := 0
+			now := signedAt
+			hs := NewHTTPSignatures(testBenchSecrets)
+			hs.SetSignatureHashAlgorithm(testCountingHmac{verified: &verified})
+			hs.SetDefaultSignatureHeaders(tt.headers)
+			hs.SetDefaultExpiresSeconds(tt.expires)
+			hs.SetPolicy(tt.policy)
+			hs.SetClock(func() time.Time { return now })
+			hs.SetVerifyCache(10)
+
+			r, _ := http.NewRequest(http.MethodPost, testHostExamplePath, strings.NewReader(testBodyExample))
+			if err := hs.Sign("hmac", r); err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range tt.verifyAt {
+				now = signedAt.Add(d)
+				if err := hs.Verify(r); err != nil {
+					t.Fatalf("Verify() at %s error = %v", d, err)
+				}
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("got %d key operations, want %d", verified, tt.wantVerified)
+			}
+
+			// Changed covered data is verified
+			r.Header.Set(digestHeader, "SHA-512=wrong")
+			if err := hs.Verify(r); err == nil {
+				t.Errorf("Verify() of changed request: no error")
+			}
+		})
+	}
+}
+
+func TestVerifyCacheEviction(t *testing.T) {
+	c := newVerifyCache(2)
+	exp := time.Unix(100, 0)
+	c.add("a", exp)
+	c.add("b", exp)
+	c.get("a", time.Unix(0, 0))
+	c.add("c", exp)
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if got := c.get(key, time.Unix(0, 0)); got != want {
+			t.Errorf("get(%s) = %v, want %v", key, got, want)
+		}
+	}
+	if c.get("a", exp.Add(time.Second)) || c.ll.Len() != 1 {
+		t.Errorf("expired entry is not removed")
+	}
+}
+
+func TestVerifyCacheKeyRotation(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	storage := map[string]Secret{"hmac": {KeyID: "hmac", PrivateKey: "secret", Algorithm: algHmacSha256}}
+	hs := NewHTTPSignatures(NewSimpleSecretsStorage(storage))
+	hs.SetDefaultSignatureHeaders([]string{created, expires})
+	hs.SetClock(func() time.Time { return now })
+	hs.SetVerifyCache(10)
+
+	r, _ := http.NewRequest(http.MethodGet, testHostExamplePath, nil)
+	if err := hs.Sign("hmac", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Verify(r); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	// Cached verification isn't used with rotated HMAC secret
+	storage["hmac"] = Secret{KeyID: "hmac", PrivateKey: "rotated", Algorithm: algHmacSha256}
+	if err := hs.Verify(r); err == nil {
+		t.Errorf("Verify() with rotated secret: no error")
+	}
+}
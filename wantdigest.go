@@ -0,0 +1,99 @@
+package httpsignatures
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WantDigest digest algorithm & its preference (qvalue) from the Want-Digest header
+type WantDigest struct {
+	Algorithm string
+	QValue    float64
+}
+
+// ParseWantDigestHeader parse Want-Digest header (RFC 3230), e.g. "SHA-512;q=0.3, SHA-256;q=1, MD5;q=0".
+// Algorithms are ordered by qvalue (most preferred first), algorithms with the same qvalue keep header order.
+// Default qvalue is 1, qvalue 0 means "not acceptable" and is kept in the list.
+func ParseWantDigestHeader(header string) ([]WantDigest, error) {
+	if len(strings.TrimSpace(header)) == 0 {
+		return nil, &ErrParser{Message: "empty want-digest header"}
+	}
+
+	var res []WantDigest
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		wd, err := parseWantDigestItem(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, wd)
+	}
+	if len(res) == 0 {
+		return nil, &ErrParser{Message: "empty want-digest header"}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].QValue > res[j].QValue
+	})
+	return res, nil
+}
+
+func parseWantDigestItem(item string) (WantDigest, *ErrParser) {
+	parts := strings.Split(item, ";")
+	wd := WantDigest{Algorithm: strings.TrimSpace(parts[0]), QValue: 1}
+	if !isDigestAlgorithmToken(wd.Algorithm) {
+		return WantDigest{}, &ErrParser{
+			Message: fmt.Sprintf("wrong want-digest algorithm '%s'", wd.Algorithm),
+		}
+	}
+
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "q") {
+			return WantDigest{}, &ErrParser{
+				Message: fmt.Sprintf("wrong want-digest param '%s' for algorithm '%s'", param, wd.Algorithm),
+			}
+		}
+		q, err := parseQValue(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return WantDigest{}, &ErrParser{
+				Message: fmt.Sprintf("wrong qvalue for algorithm '%s'", wd.Algorithm),
+				Err:     err,
+			}
+		}
+		wd.QValue = q
+	}
+	return wd, nil
+}
+
+// isDigestAlgorithmToken digest algorithm contains letters, digits & '-' only
+func isDigestAlgorithmToken(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= fromA && c <= toZ) || (c >= froma && c <= toz) || (c >= from0 && c <= to9) || c == min) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseQValue qvalue = ( "0" [ "." 0*3DIGIT ] ) / ( "1" [ "." 0*3("0") ] )
+func parseQValue(s string) (float64, error) {
+	if len(s) == 0 || len(s) > 5 || (s[0] != '0' && s[0] != '1') || (len(s) > 1 && s[1] != dot) {
+		return 0, fmt.Errorf("'%s' is not a qvalue", s)
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] < from0 || s[i] > to9 || (s[0] == '1' && s[i] != '0') {
+			return 0, fmt.Errorf("'%s' is not a qvalue", s)
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
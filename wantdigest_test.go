@@ -0,0 +1,111 @@
+package httpsignatures
+
+import (
+	"testing"
+)
+
+func TestParseWantDigestHeader(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        []WantDigest
+		wantErrType string
+		wantErrMsg  string
+	}{
+		{
+			name: "Single algorithm",
+			args: args{
+				header: "SHA-256",
+			},
+			want:        []WantDigest{{Algorithm: "SHA-256", QValue: 1}},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Ordered by qvalue",
+			args: args{
+				header: "SHA-512;q=0.3, sha-256;q=1, MD5;q=0, unixsum;q=0.3",
+			},
+			want: []WantDigest{
+				{Algorithm: "sha-256", QValue: 1},
+				{Algorithm: "SHA-512", QValue: 0.3},
+				{Algorithm: "unixsum", QValue: 0.3},
+				{Algorithm: "MD5", QValue: 0},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Spaces & empty elements",
+			args: args{
+				header: " , SHA-256 ; Q=0.5 ,,SHA-512 ",
+			},
+			want: []WantDigest{
+				{Algorithm: "SHA-512", QValue: 1},
+				{Algorithm: "SHA-256", QValue: 0.5},
+			},
+			wantErrType: testErrParserType,
+		},
+		{
+			name: "Empty header",
+			args: args{
+				header: " ",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty want-digest header",
+		},
+		{
+			name: "Only empty elements",
+			args: args{
+				header: ", ,",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: empty want-digest header",
+		},
+		{
+			name: "Wrong algorithm",
+			args: args{
+				header: "SHA_256;q=1",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong want-digest algorithm 'SHA_256'",
+		},
+		{
+			name: "Unknown param",
+			args: args{
+				header: "SHA-256;p=1",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong want-digest param 'p=1' for algorithm 'SHA-256'",
+		},
+		{
+			name: "Wrong qvalue",
+			args: args{
+				header: "SHA-256;q=1.5",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong qvalue for algorithm 'SHA-256': '1.5' is not a qvalue",
+		},
+		{
+			name: "Too precise qvalue",
+			args: args{
+				header: "SHA-256;q=0.1234",
+			},
+			want:        nil,
+			wantErrType: testErrParserType,
+			wantErrMsg:  "ErrParser: wrong qvalue for algorithm 'SHA-256': '0.1234' is not a qvalue",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWantDigestHeader(tt.args.header)
+			assert(t, got, err, tt.wantErrType, tt.name, tt.want, tt.wantErrMsg)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package webhooks signs outgoing webhook deliveries: Digest is always signed, keys are rotated with overlap
+// (deliveries carry signatures of both old & new keys while both are active) & destinations may use own signing
+// profiles.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+// DefaultProfile profile used for destinations without own profile
+const DefaultProfile = "webhooks"
+
+// DefaultHeaders headers signed by DefaultProfile
+var DefaultHeaders = []string{"(request-target)", "host", "(created)", "(expires)", "digest"}
+
+// Key signing key active from NotBefore till NotAfter (zero — no limit)
+type Key struct {
+	ID        string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// active check key is active at t
+func (k Key) active(t time.Time) bool {
+	return (k.NotBefore.IsZero() || !t.Before(k.NotBefore)) && (k.NotAfter.IsZero() || t.Before(k.NotAfter))
+}
+
+// Signer webhook deliveries signer, safe for concurrent use
+type Signer struct {
+	hs           *httpsignatures.HTTPSignatures
+	mu           sync.RWMutex
+	keys         []Key
+	destinations map[string]string
+	now          func() time.Time
+}
+
+// NewSigner create webhooks signer with keys (in priority order: new key first). DefaultProfile is registered
+// in hs if it's not set: DefaultHeaders signed, expires in 5 minutes.
+func NewSigner(hs *httpsignatures.HTTPSignatures, keys ...Key) (*Signer, error) {
+	if _, ok := hs.Profile(DefaultProfile); !ok {
+		err := hs.SetProfile(DefaultProfile, httpsignatures.Profile{Headers: DefaultHeaders, TTL: 5 * time.Minute})
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &Signer{hs: hs, destinations: make(map[string]string), now: time.Now}
+	s.SetKeys(keys...)
+	return s, nil
+}
+
+// SetKeys replace signing keys, e.g. add new key with NotBefore & set NotAfter of the old one to rotate keys
+func (s *Signer) SetKeys(keys ...Key) {
+	s.mu.Lock()
+	s.keys = append([]Key(nil), keys...)
+	s.mu.Unlock()
+}
+
+// SetDestinationProfile sign deliveries to destination (host or URL prefix) with profile registered in
+// HTTPSignatures. Profile must sign digest.
+func (s *Signer) SetDestinationProfile(destination string, profile string) error {
+	p, ok := s.hs.Profile(profile)
+	if !ok {
+		return fmt.Errorf("profile '%s' not found", profile)
+	}
+	if !hasDigest(p.Headers) {
+		return fmt.Errorf("profile '%s' doesn't sign digest", profile)
+	}
+	s.mu.Lock()
+	s.destinations[destination] = profile
+	s.mu.Unlock()
+	return nil
+}
+
+// SetClock set time source for key activity (time.Now by default)
+func (s *Signer) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	s.now = now
+	s.mu.Unlock()
+}
+
+// NewRequest create signed webhook delivery request with body
+func (s *Signer) NewRequest(ctx context.Context, method string, url string, body []byte) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Sign(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sign sign webhook delivery with every active key. Every key adds own Signature header value, receivers pick the
+// signature of the key they know. Authorization has a single value, so if the profile signs into Authorization,
+// it carries the signature of the first active key & signatures of other keys are sent in Signature header.
+func (s *Signer) Sign(r *http.Request) error {
+	s.mu.RLock()
+	profile := s.profile(r.URL)
+	var keys []Key
+	now := s.now()
+	for _, k := range s.keys {
+		if k.active(now) {
+			keys = append(keys, k)
+		}
+	}
+	s.mu.RUnlock()
+	if len(keys) == 0 {
+		return fmt.Errorf("no active webhook signing key")
+	}
+
+	// Authorization header set by the caller (e.g. token) is kept unless the profile signs into it
+	auth := r.Header["Authorization"]
+	values := make(map[string][]string, 2)
+	for i, k := range keys {
+		r.Header.Del("Signature")
+		r.Header["Authorization"] = auth
+		if err := s.hs.SignWithProfileCtx(r.Context(), r, profile, k.ID); err != nil {
+			return err
+		}
+		sig := r.Header.Get("Signature")
+		if v := r.Header["Authorization"]; len(v) == 1 && (len(auth) == 0 || v[0] != auth[0]) {
+			if i == 0 {
+				values["Authorization"] = v
+			} else if len(sig) == 0 {
+				sig = strings.TrimPrefix(v[0], "Signature ")
+			}
+		}
+		if len(sig) > 0 {
+			values["Signature"] = append(values["Signature"], sig)
+		}
+	}
+	if auth == nil {
+		r.Header.Del("Authorization")
+	}
+	for h, v := range values {
+		r.Header[h] = v
+	}
+	return nil
+}
+
+// profile destination profile: the longest matching URL prefix or host, DefaultProfile if not set
+func (s *Signer) profile(u *url.URL) string {
+	profile, match := DefaultProfile, ""
+	target := u.Host + u.Path
+	for d, p := range s.destinations {
+		d = strings.TrimPrefix(strings.TrimPrefix(d, "https://"), "http://")
+		if strings.HasPrefix(target, d) && len(d) > len(match) {
+			profile, match = p, d
+		}
+	}
+	return profile
+}
+
+func hasDigest(headers []string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, "digest") {
+			return true
+		}
+	}
+	return false
+}
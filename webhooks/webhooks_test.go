@@ -0,0 +1,221 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igor-pavlenko/httpsignatures-go"
+)
+
+var testKeys = map[string]httpsignatures.Secret{
+	"old": {KeyID: "old", PrivateKey: "old-secret", Algorithm: "HMAC-SHA256"},
+	"new": {KeyID: "new", PrivateKey: "new-secret", Algorithm: "HMAC-SHA256"},
+}
+
+func testSigner(t *testing.T, keys ...Key) *Signer {
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(testKeys))
+	s, err := NewSigner(hs, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetClock(func() time.Time { return time.Unix(1000, 0) })
+	return s
+}
+
+// testVerify verify every signature value with verifier knowing only keyID
+func testVerify(t *testing.T, r *http.Request, header string, keyID string) bool {
+	hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(
+		map[string]httpsignatures.Secret{keyID: testKeys[keyID]}))
+	hs.SetAllowSchemePrefix(true)
+	for _, v := range r.Header.Values(header) {
+		c := r.Clone(context.Background())
+		c.Header.Del(header)
+		c.Header.Set("Signature", v)
+		if hs.Verify(c) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSignRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []Key
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Single key",
+			keys: []Key{{ID: "old"}},
+			want: []string{"old"},
+		},
+		{
+			name: "Overlap",
+			keys: []Key{{ID: "new", NotBefore: time.Unix(500, 0)}, {ID: "old", NotAfter: time.Unix(2000, 0)}},
+			want: []string{"new", "old"},
+		},
+		{
+			name: "Rotated",
+			keys: []Key{{ID: "new", NotBefore: time.Unix(500, 0)}, {ID: "old", NotAfter: time.Unix(1000, 0)}},
+			want: []string{"new"},
+		},
+		{
+			name: "Not active yet",
+			keys: []Key{{ID: "new", NotBefore: time.Unix(1500, 0)}, {ID: "old"}},
+			want: []string{"old"},
+		},
+		{
+			name:    "No active key",
+			keys:    []Key{{ID: "old", NotAfter: time.Unix(1000, 0)}},
+			wantErr: "no active webhook signing key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testSigner(t, tt.keys...)
+			r, err := s.NewRequest(context.Background(), http.MethodPost, "https://example.com/hook", []byte(`{"a":1}`))
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Header.Get("Digest") == "" {
+				t.Error("digest not set")
+			}
+			headers, err := httpsignatures.ParseFromRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(headers) != len(tt.want) {
+				t.Fatalf("got %d signatures, want %d", len(headers), len(tt.want))
+			}
+			for i, h := range headers {
+				if h.KeyID != tt.want[i] {
+					t.Errorf("signature %d keyId = %s, want %s", i, h.KeyID, tt.want[i])
+				}
+				if !testVerify(t, r, "Signature", h.KeyID) {
+					t.Errorf("signature of key %s not verified", h.KeyID)
+				}
+			}
+		})
+	}
+}
+
+func TestSignDestinationProfile(t *testing.T) {
+	s := testSigner(t, Key{ID: "new"}, Key{ID: "old"})
+	err := s.hs.SetProfile("partner", httpsignatures.Profile{
+		Headers: []string{"(request-target)", "digest"},
+		Header:  "Authorization",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.hs.SetProfile("nodigest", httpsignatures.Profile{Headers: []string{"date"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDestinationProfile("partner.example.com/hooks", "partner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDestinationProfile("other.example.com", "nodigest"); err == nil {
+		t.Error("profile without digest accepted")
+	}
+	if err := s.SetDestinationProfile("other.example.com", "unknown"); err == nil {
+		t.Error("unknown profile accepted")
+	}
+
+	ctx := context.Background()
+	r, err := s.NewRequest(ctx, http.MethodPost, "https://partner.example.com/hooks/1", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Header.Values("Authorization")) != 1 || len(r.Header.Values("Signature")) != 1 {
+		t.Fatalf("got headers %v, want Authorization & Signature values", r.Header)
+	}
+	if !testVerify(t, r, "Authorization", "new") || !testVerify(t, r, "Signature", "old") {
+		t.Error("signatures not verified")
+	}
+
+	r, err = s.NewRequest(ctx, http.MethodPost, "https://partner.example.com/other", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Header.Values("Signature")) != 2 {
+		t.Errorf("got headers %v, want default profile", r.Header)
+	}
+}
+
+func TestSignBothHeaders(t *testing.T) {
+	s := testSigner(t, Key{ID: "new"}, Key{ID: "old"})
+	s.hs.SetBothSignatureHeaders(true)
+	r, err := s.NewRequest(context.Background(), http.MethodPost, "https://example.com/hook", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Header.Values("Signature")) != 2 || len(r.Header.Values("Authorization")) != 1 {
+		t.Fatalf("got headers %v, want 2 Signature & 1 Authorization values", r.Header)
+	}
+	if !testVerify(t, r, "Authorization", "new") || !testVerify(t, r, "Signature", "old") ||
+		!testVerify(t, r, "Signature", "new") {
+		t.Error("signatures not verified")
+	}
+
+	s.hs.SetBothSignatureHeaders(false)
+	r, _ = http.NewRequest(http.MethodPost, "https://example.com/hook", strings.NewReader("{}"))
+	r.Header.Set("Authorization", "Bearer token")
+	if err := s.Sign(r); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Values("Authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Errorf("got Authorization %v, want caller token kept", got)
+	}
+}
+
+func TestSetClockConcurrent(t *testing.T) {
+	s := testSigner(t, Key{ID: "new"})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			s.SetClock(func() time.Time { return time.Unix(1000, 0) })
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		r, _ := http.NewRequest(http.MethodPost, "https://example.com/hook", strings.NewReader("{}"))
+		if err := s.Sign(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestSignRotationVerify(t *testing.T) {
+	s := testSigner(t, Key{ID: "new", NotBefore: time.Unix(500, 0)}, Key{ID: "old", NotAfter: time.Unix(2000, 0)})
+	r, err := s.NewRequest(context.Background(), http.MethodPost, "https://example.com/hook", []byte(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Receivers know only one of the keys & verify the delivery as is
+	for _, keyID := range []string{"old", "new"} {
+		t.Run(keyID, func(t *testing.T) {
+			hs := httpsignatures.NewHTTPSignatures(httpsignatures.NewSimpleSecretsStorage(
+				map[string]httpsignatures.Secret{keyID: testKeys[keyID]}))
+			c := r.Clone(context.Background())
+			c.Body, _ = r.GetBody()
+			secret, err := hs.VerifyAndIdentify(c)
+			if err != nil {
+				t.Fatalf("VerifyAndIdentify() error = %v", err)
+			}
+			if secret.KeyID != keyID {
+				t.Errorf("keyId = %s, want %s", secret.KeyID, keyID)
+			}
+		})
+	}
+}